@@ -0,0 +1,619 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/bf2"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	commandBackup        = "backup"
+	subCommandBackupList = "list"
+	subCommandRestore    = "restore"
+	commandMods          = "mods"
+	commandLogoCache     = "logo-cache"
+	subCommandList       = "list"
+	subCommandPurge      = "purge"
+	commandDoctor        = "doctor"
+	commandProfiles      = "profiles"
+	commandProfile       = "profile"
+	subCommandRename     = "rename"
+	subCommandSetNick    = "set-nick"
+	subCommandSetEmail   = "set-email"
+	subCommandCreate     = "create"
+	subCommandClone      = "clone"
+	subCommandCopy       = "copy"
+	subCommandDelete     = "delete"
+	subCommandRekey      = "rekey"
+	subCommandExport     = "export"
+	subCommandImport     = "import"
+
+	importConflictFail      = "fail"
+	importConflictOverwrite = "overwrite"
+	importConflictNewKey    = "new-key"
+
+	profileSortKey      = "key"
+	profileSortName     = "name"
+	profileSortModified = "modified"
+)
+
+// Run the command given as (non-flag) arguments
+func runCommand(h *handler.Handler, g handler.Game, args []string) error {
+	switch args[0] {
+	case commandBackup:
+		return runBackupCommand(h, args[1:])
+	case commandMods:
+		return runModsCommand(h, g)
+	case commandLogoCache:
+		return runLogoCacheCommand(h, g, args[1:])
+	case commandDoctor:
+		return runDoctorCommand(h, g, args[1:])
+	case commandProfiles:
+		return runProfilesCommand(h, g, args[1:])
+	case commandProfile:
+		return runProfileCommand(h, g, args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+func runBackupCommand(h *handler.Handler, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s list | %s <id>", commandBackup, subCommandRestore)
+	}
+
+	switch args[0] {
+	case subCommandBackupList:
+		backups, err := h.ListBackups()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tCREATED\tPATH")
+		for _, backup := range backups {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", backup.ID, backup.Created.Local().Format(time.DateTime), backup.Path)
+		}
+		return w.Flush()
+	case subCommandRestore:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s %s <id>", commandBackup, subCommandRestore)
+		}
+		if err := h.RestoreBackup(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Restored backup %s\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown %s command: %s", commandBackup, args[0])
+	}
+}
+
+func runModsCommand(h *handler.Handler, g handler.Game) error {
+	mods, err := h.GetMods(g)
+	var unreadable *handler.ErrUnreadableMods
+	if errors.As(err, &unreadable) {
+		for _, modErr := range unreadable.Errors() {
+			_, _ = fmt.Fprintln(os.Stderr, modErr.Error())
+		}
+	} else if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tTITLE\tVERSION\tGAME MODES\tPATH")
+	for _, mod := range mods {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", mod.Name, mod.Title, mod.Version, strings.Join(mod.GameModes, ","), mod.Path)
+	}
+	return w.Flush()
+}
+
+func runLogoCacheCommand(h *handler.Handler, g handler.Game, args []string) error {
+	usage := fmt.Sprintf("usage: %s %s | %s [-older-than <duration>] [-max-bytes <bytes>] [host...]", commandLogoCache, subCommandList, subCommandPurge)
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case subCommandList:
+		hosts, err := h.GetLogoCacheHosts(g)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "HOST\tFILES\tBYTES\tLAST MODIFIED")
+		for _, host := range hosts {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", host.Host, host.Files, host.Bytes, host.Modified.Local().Format(time.DateTime))
+		}
+		return w.Flush()
+	case subCommandPurge:
+		fs := flag.NewFlagSet(commandLogoCache+" "+subCommandPurge, flag.ContinueOnError)
+		olderThan := fs.Duration("older-than", 0, "only purge hosts whose images have not been modified for at least the given duration (e.g. 720h)")
+		maxBytes := fs.Int64("max-bytes", 0, "purge the least recently modified hosts until the logo cache is no larger than the given number of bytes")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		var options []handler.PurgeOption
+		if fs.NArg() > 0 {
+			options = append(options, handler.PurgeHosts(fs.Args()...))
+		}
+		if *olderThan > 0 {
+			options = append(options, handler.PurgeOlderThan(*olderThan))
+		}
+		if *maxBytes > 0 {
+			options = append(options, handler.PurgeToQuota(*maxBytes))
+		}
+
+		report, err := h.PurgeLogoCacheWithReport(g, options...)
+		if err != nil {
+			return err
+		}
+		printPurgeReport(os.Stdout, report)
+		return nil
+	default:
+		return fmt.Errorf("unknown %s command: %s", commandLogoCache, args[0])
+	}
+}
+
+func runDoctorCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s is only supported for Battlefield 2", commandDoctor)
+	}
+
+	fs := flag.NewFlagSet(commandDoctor, flag.ContinueOnError)
+	fix := fs.Bool("fix", false, "fix all problems which can be fixed without losing any data")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	problems, err := bf2.Diagnose(h)
+	if err != nil {
+		return err
+	}
+
+	if *fix {
+		fixed, err := bf2.Repair(h, problems)
+		var cleanupErr *handler.ErrCleanupFailed
+		if errors.As(err, &cleanupErr) {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		} else if err != nil {
+			return err
+		}
+		for _, problem := range fixed {
+			fmt.Printf("Fixed: %s (%s)\n", problem.Message, problem.Path)
+		}
+		if len(fixed) > 0 && !h.DryRun() {
+			// Only report problems which remain after fixing
+			if problems, err = bf2.Diagnose(h); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("No problems found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SEVERITY\tPROBLEM\tFIXABLE\tPATH")
+	var errs int
+	for _, problem := range problems {
+		if problem.Severity == bf2.SeverityError {
+			errs++
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", problem.Severity, problem.Message, problem.Fixable, problem.Path)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("found %d problems with severity %s", errs, bf2.SeverityError)
+	}
+	return nil
+}
+
+func runProfilesCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case subCommandCreate:
+			return runCreateProfileCommand(h, g, args[1:])
+		case subCommandClone:
+			return runCloneProfileCommand(h, g, args[1:])
+		case subCommandCopy:
+			return runCopyProfileConfigFilesCommand(h, g, args[1:])
+		case subCommandDelete:
+			return runDeleteProfileCommand(h, g, args[1:])
+		case subCommandRekey:
+			return runRekeyProfileCommand(h, g, args[1:])
+		case subCommandExport:
+			return runExportProfileCommand(h, g, args[1:])
+		case subCommandImport:
+			return runImportProfileCommand(h, g, args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet(commandProfiles, flag.ContinueOnError)
+	sortBy := fs.String("sort", profileSortKey, fmt.Sprintf("sort profiles by %s, %s or %s (most recently modified first)", profileSortKey, profileSortName, profileSortModified))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profiles, err := refractorv2.GetProfiles(h, g)
+	var unreadable *game.ErrUnreadableProfiles
+	if errors.As(err, &unreadable) {
+		for _, profileErr := range unreadable.Errors() {
+			_, _ = fmt.Fprintln(os.Stderr, profileErr.Error())
+		}
+	} else if err != nil {
+		return err
+	}
+
+	switch *sortBy {
+	case profileSortKey:
+		// Profiles are already sorted by key
+	case profileSortName:
+		sort.SliceStable(profiles, func(i, j int) bool {
+			return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+		})
+	case profileSortModified:
+		sort.SliceStable(profiles, func(i, j int) bool {
+			return profiles[i].Modified.After(profiles[j].Modified)
+		})
+	default:
+		return fmt.Errorf("invalid sort order: %s", *sortBy)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KEY\tNAME\tNICK\tGAMESPY NICK\tTYPE\tEMAIL\tPASSWORD\tLAST MODIFIED\tCONFIG FILES")
+	for _, profile := range profiles {
+		profileType := "multiplayer"
+		if profile.Type == game.ProfileTypeSingleplayer {
+			profileType = "singleplayer"
+		}
+		_, _ = fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%t\t%t\t%s\t%d\n",
+			profile.Key, profile.Name, profile.Nick, profile.GamespyNick, profileType, profile.HasEmail, profile.HasPassword,
+			profile.Modified.Local().Format(time.DateTime), len(profile.ConfigFiles),
+		)
+	}
+	return w.Flush()
+}
+
+func runCreateProfileCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandCreate)
+	}
+
+	fs := flag.NewFlagSet(commandProfiles+" "+subCommandCreate, flag.ContinueOnError)
+	nick := fs.String("nick", "", "nick to use in game (defaults to the profile name)")
+	email := fs.String("email", "", "email address of the GameSpy account (required for multiplayer profiles)")
+	singleplayer := fs.Bool("singleplayer", false, "create a singleplayer instead of a multiplayer profile")
+	makeDefault := fs.Bool("default", false, "make the new profile the default profile")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s %s [-nick <nick>] [-email <email>] [-singleplayer] [-default] <name>", commandProfiles, subCommandCreate)
+	}
+
+	profileType := game.ProfileTypeMultiplayer
+	if *singleplayer {
+		profileType = game.ProfileTypeSingleplayer
+	}
+
+	var options []bf2.ProfileOption
+	if *nick != "" {
+		options = append(options, bf2.WithNick(*nick))
+	}
+	if *email != "" {
+		options = append(options, bf2.WithEmail(*email))
+	}
+	if *makeDefault {
+		options = append(options, bf2.AsDefault())
+	}
+
+	profile, err := bf2.CreateProfile(h, fs.Arg(0), profileType, options...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created profile %s (%s)\n", profile.Key, profile.Name)
+	return nil
+}
+
+func runCloneProfileCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandClone)
+	}
+
+	fs := flag.NewFlagSet(commandProfiles+" "+subCommandClone, flag.ContinueOnError)
+	nick := fs.String("nick", "", "nick to use in game (defaults to the source profile's nick)")
+	email := fs.String("email", "", "email address of the GameSpy account (defaults to the source profile's email address)")
+	makeDefault := fs.Bool("default", false, "make the new profile the default profile")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: %s %s [-nick <nick>] [-email <email>] [-default] <source key> <name>", commandProfiles, subCommandClone)
+	}
+
+	var options []bf2.ProfileOption
+	if *nick != "" {
+		options = append(options, bf2.WithNick(*nick))
+	}
+	if *email != "" {
+		options = append(options, bf2.WithEmail(*email))
+	}
+	if *makeDefault {
+		options = append(options, bf2.AsDefault())
+	}
+
+	profile, err := bf2.CloneProfile(h, fs.Arg(0), fs.Arg(1), options...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Cloned profile %s into %s (%s)\n", fs.Arg(0), profile.Key, profile.Name)
+	return nil
+}
+
+func runCopyProfileConfigFilesCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandCopy)
+	}
+
+	fs := flag.NewFlagSet(commandProfiles+" "+subCommandCopy, flag.ContinueOnError)
+	files := fs.String("files", "", "comma-separated list of config files to copy, e.g. Controls.con,Video.con (defaults to all but Profile.con)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: %s %s [-files <file,...>] <source key> <target key>", commandProfiles, subCommandCopy)
+	}
+
+	var configFiles []bf2.ProfileConfigFile
+	if *files != "" {
+		for _, file := range strings.Split(*files, ",") {
+			configFiles = append(configFiles, bf2.ProfileConfigFile(strings.TrimSpace(file)))
+		}
+	}
+
+	copied, err := bf2.CopyProfileConfigFiles(h, fs.Arg(0), fs.Arg(1), configFiles...)
+	if err != nil {
+		return err
+	}
+	for _, configFile := range copied {
+		fmt.Printf("Copied %s from profile %s to %s\n", configFile, fs.Arg(0), fs.Arg(1))
+	}
+	return nil
+}
+
+func runDeleteProfileCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandDelete)
+	}
+
+	fs := flag.NewFlagSet(commandProfiles+" "+subCommandDelete, flag.ContinueOnError)
+	recyclePath := fs.String("recycle", "", "move the profile into the given folder instead of removing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s %s [-recycle <path>] <key>", commandProfiles, subCommandDelete)
+	}
+
+	var options []bf2.DeleteOption
+	if *recyclePath != "" {
+		options = append(options, bf2.DeleteToRecycleFolder(*recyclePath))
+	}
+
+	defaultProfileKey, err := bf2.DeleteProfile(h, fs.Arg(0), options...)
+	var cleanupErr *handler.ErrCleanupFailed
+	if errors.As(err, &cleanupErr) {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+	} else if err != nil {
+		return err
+	}
+	fmt.Printf("Deleted profile %s\n", fs.Arg(0))
+	if defaultProfileKey != "" {
+		fmt.Printf("Default profile changed to %s\n", defaultProfileKey)
+	}
+	return nil
+}
+
+func runRekeyProfileCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandRekey)
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: %s %s <key> <new key>", commandProfiles, subCommandRekey)
+	}
+
+	if err := bf2.RekeyProfile(h, args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Changed profile key %s to %s\n", args[0], args[1])
+	return nil
+}
+
+func runProfileCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s is only supported for Battlefield 2", commandProfile)
+	}
+
+	usage := fmt.Sprintf("usage: %s %s <key> <name> | %s [-gamespy] <key> <nick> | %s <key> <email>", commandProfile, subCommandRename, subCommandSetNick, subCommandSetEmail)
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet(commandProfile+" "+args[0], flag.ContinueOnError)
+	gamespy := fs.Bool("gamespy", false, "also set the GameSpy account's nick [multiplayer profiles only]")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New(usage)
+	}
+	profileKey, value := fs.Arg(0), fs.Arg(1)
+
+	var update func(profileCon *config.Config) error
+	switch args[0] {
+	case subCommandRename:
+		update = func(profileCon *config.Config) error {
+			return bf2.SetProfileName(profileCon, value)
+		}
+	case subCommandSetNick:
+		update = func(profileCon *config.Config) error {
+			if *gamespy {
+				if err := bf2.SetProfileGamespyNick(profileCon, value); err != nil {
+					return err
+				}
+			}
+			return bf2.SetProfileNick(profileCon, value)
+		}
+	case subCommandSetEmail:
+		update = func(profileCon *config.Config) error {
+			return bf2.SetProfileEmail(profileCon, value)
+		}
+	default:
+		return fmt.Errorf("unknown %s command: %s", commandProfile, args[0])
+	}
+
+	path, err := bf2.BuildProfileConfigFilePath(h, profileKey, bf2.ProfileConfigFileProfileCon)
+	if err != nil {
+		return err
+	}
+	// Take the identity from the updated config, since nothing is written in dry-run mode
+	var identity bf2.Identity
+	err = h.UpdateConfigFile(path, func(profileCon *config.Config) error {
+		if err := update(profileCon); err != nil {
+			return err
+		}
+		identity = bf2.GetProfileIdentity(profileCon)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Updated profile %s: name %q, nick %q, GameSpy nick %q, email %q\n", profileKey, identity.Name, identity.Nick, identity.GamespyNick, identity.Email)
+	return nil
+}
+
+func runExportProfileCommand(h *handler.Handler, g handler.Game, args []string) (err error) {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandExport)
+	}
+
+	fs := flag.NewFlagSet(commandProfiles+" "+subCommandExport, flag.ContinueOnError)
+	noPassword := fs.Bool("no-password", false, "do not include the (encrypted) GameSpy password")
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: %s %s [-no-password] <key> <archive path>", commandProfiles, subCommandExport)
+	}
+
+	var options []bf2.ExportOption
+	if *noPassword {
+		options = append(options, bf2.ExportWithoutPassword())
+	}
+
+	// Still read the profile in dry-run mode, but do not write the archive
+	if h.DryRun() {
+		manifest, err := bf2.ExportProfile(h, fs.Arg(0), io.Discard, options...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Dry run: would export profile %s (%d config files) to %s\n", manifest.ProfileKey, len(manifest.Files), fs.Arg(1))
+		return nil
+	}
+
+	f, err := os.OpenFile(fs.Arg(1), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		// Do not leave a partial archive behind
+		if err != nil {
+			_ = os.Remove(fs.Arg(1))
+		}
+	}()
+
+	manifest, err := bf2.ExportProfile(h, fs.Arg(0), f, options...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported profile %s (%d config files) to %s\n", manifest.ProfileKey, len(manifest.Files), fs.Arg(1))
+	return nil
+}
+
+func runImportProfileCommand(h *handler.Handler, g handler.Game, args []string) error {
+	if g != handler.GameBf2 {
+		return fmt.Errorf("%s %s is only supported for Battlefield 2", commandProfiles, subCommandImport)
+	}
+
+	fs := flag.NewFlagSet(commandProfiles+" "+subCommandImport, flag.ContinueOnError)
+	profileKey := fs.String("key", "", "import into the given profile key (defaults to the lowest free one)")
+	onConflict := fs.String("on-conflict", importConflictFail, fmt.Sprintf("what to do if the profile key is already in use: %s, %s or %s", importConflictFail, importConflictOverwrite, importConflictNewKey))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s %s [-key <key>] [-on-conflict <%s|%s|%s>] <archive path>", commandProfiles, subCommandImport, importConflictFail, importConflictOverwrite, importConflictNewKey)
+	}
+
+	var options []bf2.ImportOption
+	if *profileKey != "" {
+		options = append(options, bf2.ImportToKey(*profileKey))
+	}
+	switch *onConflict {
+	case importConflictFail:
+		options = append(options, bf2.OnImportConflict(bf2.ImportConflictFail))
+	case importConflictOverwrite:
+		options = append(options, bf2.OnImportConflict(bf2.ImportConflictOverwrite))
+	case importConflictNewKey:
+		options = append(options, bf2.OnImportConflict(bf2.ImportConflictNewKey))
+	default:
+		return fmt.Errorf("invalid conflict handling: %s", *onConflict)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	profile, manifest, err := bf2.ImportProfile(h, f, info.Size(), options...)
+	var cleanupErr *handler.ErrCleanupFailed
+	if errors.As(err, &cleanupErr) {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+	} else if err != nil {
+		return err
+	}
+	fmt.Printf("Imported profile %s (%s) exported by conman %s as %s\n", manifest.ProfileKey, profile.Name, manifest.ConmanVersion, profile.Key)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	configFileName = "config.json"
+)
+
+// conmanConfig Contents of the bf2-conman config file
+type conmanConfig struct {
+	Mods []modConfig `json:"mods"`
+}
+
+// modConfig Standalone mod keeping its files in its own folder inside the user's Documents folder
+type modConfig struct {
+	// Name used to select the mod via -game
+	Game string `json:"game"`
+	// Name of the mod's folder inside the user's Documents folder
+	Folder string `json:"folder"`
+	// Registry key (relative to HKEY_LOCAL_MACHINE\SOFTWARE) and value containing the mod's install folder (optional)
+	InstallKeyPath   string `json:"installKeyPath"`
+	InstallValueName string `json:"installValueName"`
+	// Names of the mod's executables, used to detect whether the mod is running (optional)
+	Executables []string `json:"executables"`
+}
+
+// Load the config file and register all standalone mods configured in it, a missing default config file is ignored
+func loadConfig(configFilePath string) error {
+	required := configFilePath != ""
+	if !required {
+		configDirPath, err := os.UserConfigDir()
+		if err != nil {
+			return err
+		}
+		configFilePath = filepath.Join(configDirPath, "bf2-conman", configFileName)
+	}
+
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var cfg conmanConfig
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", configFilePath, err)
+	}
+
+	for _, mod := range cfg.Mods {
+		descriptor := handler.StandaloneModDescriptor(handler.Game(mod.Game), mod.Folder)
+		descriptor.InstallKeyPath = mod.InstallKeyPath
+		descriptor.InstallValueName = mod.InstallValueName
+		descriptor.Executables = mod.Executables
+		if err = handler.RegisterGame(descriptor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
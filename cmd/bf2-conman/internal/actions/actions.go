@@ -8,6 +8,7 @@ import (
 
 	"github.com/cetteup/conman/pkg/config"
 	"github.com/cetteup/conman/pkg/game/bf2"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
 	"github.com/cetteup/conman/pkg/handler"
 )
 
@@ -15,8 +16,8 @@ const (
 	demoBookmarkMaxAge = time.Hour * 24 * 7
 )
 
-func SetDefaultProfile(h *handler.Handler, profileKey string) error {
-	validKey, err := h.IsValidProfileKey(handler.GameBf2, profileKey)
+func SetDefaultProfile(h *handler.Handler, g handler.Game, profileKey string) error {
+	validKey, err := h.IsValidProfileKey(g, profileKey)
 	if err != nil {
 		return err
 	}
@@ -24,14 +25,10 @@ func SetDefaultProfile(h *handler.Handler, profileKey string) error {
 		return fmt.Errorf("given profile key is not valid")
 	}
 
-	globalCon, err := h.ReadGlobalConfig(handler.GameBf2)
-	if err != nil {
-		return err
-	}
-
-	bf2.SetDefaultProfile(globalCon, profileKey)
-
-	return h.WriteConfigFile(globalCon)
+	return h.UpdateGlobalConfig(g, func(globalCon *config.Config) error {
+		refractorv2.SetDefaultProfile(globalCon, profileKey)
+		return nil
+	})
 }
 
 func GetProfilePassword(h *handler.Handler, profileKey string) (string, error) {
@@ -49,7 +46,7 @@ func GetProfilePassword(h *handler.Handler, profileKey string) (string, error) {
 }
 
 func SetProfilePassword(h *handler.Handler, profileKey string, password string) error {
-	profileCon, err := bf2.ReadProfileConfigFile(h, profileKey, bf2.ProfileConfigFileProfileCon)
+	profileConPath, err := bf2.BuildProfileConfigFilePath(h, profileKey, bf2.ProfileConfigFileProfileCon)
 	if err != nil {
 		return err
 	}
@@ -59,63 +56,81 @@ func SetProfilePassword(h *handler.Handler, profileKey string, password string)
 		return err
 	}
 
-	profileCon.SetValue(bf2.ProfileConKeyPassword, *config.NewValue(encryptedPassword))
-
-	return h.WriteConfigFile(profileCon)
+	return h.UpdateConfigFile(profileConPath, func(profileCon *config.Config) error {
+		profileCon.SetValue(bf2.ProfileConKeyPassword, *config.NewValue(encryptedPassword))
+		return nil
+	})
 }
 
-func PurgeServerHistory(h *handler.Handler, profileKey string) error {
-	generalCon, err := bf2.ReadProfileConfigFile(h, profileKey, bf2.ProfileConfigFileGeneralCon)
+func PurgeServerHistory(h *handler.Handler, g handler.Game, profileKey string) error {
+	generalConPath, err := refractorv2.BuildProfileConfigFilePath(h, g, profileKey, string(bf2.ProfileConfigFileGeneralCon))
 	if err != nil {
 		return err
 	}
 
-	bf2.PurgeServerHistory(generalCon)
-
-	return h.WriteConfigFile(generalCon)
+	return h.UpdateConfigFile(generalConPath, func(generalCon *config.Config) error {
+		refractorv2.PurgeServerHistory(generalCon)
+		return nil
+	})
 }
 
-func PurgeServerFavorites(h *handler.Handler, profileKey string) error {
-	generalCon, err := bf2.ReadProfileConfigFile(h, profileKey, bf2.ProfileConfigFileGeneralCon)
+func PurgeServerFavorites(h *handler.Handler, g handler.Game, profileKey string) error {
+	generalConPath, err := refractorv2.BuildProfileConfigFilePath(h, g, profileKey, string(bf2.ProfileConfigFileGeneralCon))
 	if err != nil {
 		return err
 	}
 
-	bf2.PurgeServerFavorites(generalCon)
-
-	return h.WriteConfigFile(generalCon)
+	return h.UpdateConfigFile(generalConPath, func(generalCon *config.Config) error {
+		refractorv2.PurgeServerFavorites(generalCon)
+		return nil
+	})
 }
 
-func PurgeOldDemoBookmarks(h *handler.Handler, profileKey string) error {
-	demoBookmarksCon, err := bf2.ReadProfileConfigFile(h, profileKey, bf2.ProfileConfigFileDemoBookmarksCon)
+func PurgeOldDemoBookmarks(h *handler.Handler, g handler.Game, profileKey string) error {
+	demoBookmarksConPath, err := refractorv2.BuildProfileConfigFilePath(h, g, profileKey, string(bf2.ProfileConfigFileDemoBookmarksCon))
 	if err != nil {
-		// We want to clean the demo bookmarks, so we don't consider it an error if the file is missing
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
 		return err
 	}
 
-	bf2.PurgeOldDemoBookmarks(demoBookmarksCon, time.Now(), demoBookmarkMaxAge)
+	err = h.UpdateConfigFile(demoBookmarksConPath, func(demoBookmarksCon *config.Config) error {
+		bf2.PurgeOldDemoBookmarks(demoBookmarksCon, time.Now(), demoBookmarkMaxAge)
+		return nil
+	})
+	// We want to clean the demo bookmarks, so we don't consider it an error if the file is missing
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
 
-	return h.WriteConfigFile(demoBookmarksCon)
+	return nil
 }
 
-func MarkAllVoiceOverHelpAsPlayed(h *handler.Handler, profileKey string) error {
-	generalCon, err := bf2.ReadProfileConfigFile(h, profileKey, bf2.ProfileConfigFileGeneralCon)
+func MarkAllVoiceOverHelpAsPlayed(h *handler.Handler, g handler.Game, profileKey string) error {
+	generalConPath, err := refractorv2.BuildProfileConfigFilePath(h, g, profileKey, string(bf2.ProfileConfigFileGeneralCon))
 	if err != nil {
 		return err
 	}
 
-	bf2.MarkAllVoiceOverHelpAsPlayed(generalCon)
+	return h.UpdateConfigFile(generalConPath, func(generalCon *config.Config) error {
+		bf2.MarkAllVoiceOverHelpAsPlayed(generalCon)
+		return nil
+	})
+}
 
-	return h.WriteConfigFile(generalCon)
+func PurgeShareCache(h *handler.Handler, g handler.Game) error {
+	return h.PurgeShaderCache(g)
 }
 
-func PurgeShareCache(h *handler.Handler) error {
-	return bf2.PurgeShaderCache(h)
+func PurgeShaderCacheWithReport(h *handler.Handler, g handler.Game, mods []string, olderThan time.Duration) (*handler.PurgeReport, error) {
+	var options []handler.PurgeOption
+	if len(mods) > 0 {
+		options = append(options, handler.PurgeMods(mods...))
+	}
+	if olderThan > 0 {
+		options = append(options, handler.PurgeOlderThan(olderThan))
+	}
+	return h.PurgeShaderCacheWithReport(g, options...)
 }
 
-func PurgeLogoCache(h *handler.Handler) error {
-	return bf2.PurgeLogoCache(h)
+func PurgeLogoCache(h *handler.Handler, g handler.Game) error {
+	return h.PurgeLogoCache(g)
 }
@@ -87,7 +87,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Set as default profile",
 						OnClicked: func() {
-							err := actions.SetDefaultProfile(h, profiles[profileSelection.CurrentIndex()].Key)
+							err := actions.SetDefaultProfile(h, handler.GameBf2, profiles[profileSelection.CurrentIndex()].Key)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to set default profile", walk.MsgBoxIconError)
 							} else {
@@ -98,7 +98,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Purge server history",
 						OnClicked: func() {
-							err := actions.PurgeServerHistory(h, profiles[profileSelection.CurrentIndex()].Key)
+							err := actions.PurgeServerHistory(h, handler.GameBf2, profiles[profileSelection.CurrentIndex()].Key)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to purge server history", walk.MsgBoxIconError)
 							} else {
@@ -109,7 +109,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Purge server favorites",
 						OnClicked: func() {
-							err := actions.PurgeServerFavorites(h, profiles[profileSelection.CurrentIndex()].Key)
+							err := actions.PurgeServerFavorites(h, handler.GameBf2, profiles[profileSelection.CurrentIndex()].Key)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to purge server favorites", walk.MsgBoxIconError)
 							} else {
@@ -120,7 +120,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Purge old demo bookmarks",
 						OnClicked: func() {
-							err := actions.PurgeOldDemoBookmarks(h, profiles[profileSelection.CurrentIndex()].Key)
+							err := actions.PurgeOldDemoBookmarks(h, handler.GameBf2, profiles[profileSelection.CurrentIndex()].Key)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to purge demo bookmarks", walk.MsgBoxIconError)
 							} else {
@@ -131,7 +131,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Disable help voice overs",
 						OnClicked: func() {
-							err := actions.MarkAllVoiceOverHelpAsPlayed(h, profiles[profileSelection.CurrentIndex()].Key)
+							err := actions.MarkAllVoiceOverHelpAsPlayed(h, handler.GameBf2, profiles[profileSelection.CurrentIndex()].Key)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to disable help voice overs", walk.MsgBoxIconError)
 							} else {
@@ -204,7 +204,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Purge shader cache",
 						OnClicked: func() {
-							err := actions.PurgeShareCache(h)
+							err := actions.PurgeShareCache(h, handler.GameBf2)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to purge shader cache", walk.MsgBoxIconError)
 							} else {
@@ -215,7 +215,7 @@ func CreateMainWindow(h *handler.Handler, profiles []game.Profile, defaultProfil
 					declarative.PushButton{
 						Text: "Purge logo cache",
 						OnClicked: func() {
-							err := actions.PurgeLogoCache(h)
+							err := actions.PurgeLogoCache(h, handler.GameBf2)
 							if err != nil {
 								walk.MsgBox(mw, "Error", "Failed to purge logo cache", walk.MsgBoxIconError)
 							} else {
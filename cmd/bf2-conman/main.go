@@ -3,21 +3,39 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
-	filerepo "github.com/cetteup/filerepo/pkg"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/cetteup/conman/cmd/bf2-conman/internal/actions"
 	"github.com/cetteup/conman/cmd/bf2-conman/internal/gui"
-	"github.com/cetteup/conman/pkg/game/bf2"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
 	"github.com/cetteup/conman/pkg/handler"
+	"github.com/cetteup/conman/pkg/process"
+	"github.com/cetteup/conman/pkg/repository"
 )
 
 const (
 	logKeyProfile string = "profile"
+	logKeyGame    string = "game"
+	logKeyFiles   string = "files"
+	logKeyBytes   string = "bytes"
+
+	envKeyDocumentsPath = "BF2_CONMAN_DOCUMENTS_PATH"
+	envKeyBasePath      = "BF2_CONMAN_BASE_PATH"
+	envKeyProfilesPath  = "BF2_CONMAN_PROFILES_PATH"
+	envKeyInstallPath   = "BF2_CONMAN_INSTALL_PATH"
+	envKeyPortable      = "BF2_CONMAN_PORTABLE"
+
+	defaultBackupRetain = 10
 )
 
 func init() {
@@ -26,6 +44,7 @@ func init() {
 
 func main() {
 	var noGUI bool
+	var gameName string
 	var doPurgeServerHistory bool
 	var doPurgeServerFavorites bool
 	var doPurgeOldDemoBookmarks bool
@@ -33,26 +52,111 @@ func main() {
 	var doPurgeShaderCache bool
 	var doPurgeLogoCache bool
 	var setDefaultProfileKey string
+	var documentsPath string
+	var basePath string
+	var profilesPath string
+	var installPath string
+	var modNames string
+	var olderThan time.Duration
+	var portable bool
+	var backupDirPath string
+	var backupRetain int
+	var noBackup bool
+	var dryRun bool
+	var configFilePath string
+	var ignoreRunning bool
 	flag.BoolVar(&noGUI, "no-gui", false, "do not open/use the graphical user interface")
+	flag.StringVar(&gameName, "game", string(handler.GameBf2), "game to manage (bf2, bf2142 or a standalone mod from the config file, the graphical user interface only supports bf2)")
 	flag.BoolVar(&doPurgeServerHistory, "purge-server-history", false, "purge all server history entries from the current default profile")
 	flag.BoolVar(&doPurgeServerFavorites, "purge-server-favorites", false, "purge all server favorites from the current default profile")
 	flag.BoolVar(&doPurgeOldDemoBookmarks, "purge-old-demo-bookmarks", false, "purge all old demo bookmarks (older than 1 week) from the current default profile")
 	flag.BoolVar(&doMarkAllVoiceOverHelpAsPlayed, "disable-help-voice-overs", false, "mark all help voice over lines as played for the current default profile")
 	flag.BoolVar(&doPurgeShaderCache, "purge-shader-cache", false, "purge all shader cache files and folders")
+	flag.StringVar(&modNames, "mod", "", "only purge the shader cache of the given installed mods (comma-separated)")
+	flag.DurationVar(&olderThan, "older-than", 0, "only purge shader cache folders which have not been used for at least the given duration (e.g. 720h)")
 	flag.BoolVar(&doPurgeLogoCache, "purge-logo-cache", false, "purge cached server banner images")
 	flag.StringVar(&setDefaultProfileKey, "default-profile", "", "set the given profile as the current default profile")
+	flag.StringVar(&documentsPath, "documents-path", os.Getenv(envKeyDocumentsPath), "use the given folder instead of the current user's Documents folder")
+	flag.StringVar(&basePath, "base-path", os.Getenv(envKeyBasePath), "use the given folder as the game's base folder (containing Profiles, mods etc.)")
+	flag.StringVar(&profilesPath, "profiles-path", os.Getenv(envKeyProfilesPath), "use the given folder as the game's profiles folder")
+	flag.StringVar(&installPath, "install-path", os.Getenv(envKeyInstallPath), "use the given folder as the game's install folder instead of looking it up in the registry")
+	flag.BoolVar(&portable, "portable", os.Getenv(envKeyPortable) != "", "use the folder containing the bf2-conman executable as the game's base folder")
+	flag.StringVar(&backupDirPath, "backup-dir", "", "store backups of modified files in the given folder (defaults to bf2-conman/backups in the user's config folder)")
+	flag.IntVar(&backupRetain, "backup-retain", defaultBackupRetain, "number of backups to keep per file (0 keeps all backups)")
+	flag.BoolVar(&noBackup, "no-backup", false, "do not back up files before modifying them")
+	flag.BoolVar(&dryRun, "dry-run", false, "do not modify or remove any files, instead print which changes would be made")
+	flag.BoolVar(&ignoreRunning, "ignore-running", false, "modify files even if the game is running (the game will likely overwrite any changes once it exits)")
+	flag.StringVar(&configFilePath, "config", "", "load standalone mods from the given config file (defaults to bf2-conman/config.json in the user's config folder)")
 	flag.Parse()
 
-	fileRepository := filerepo.New()
-	h := handler.New(fileRepository)
+	if err := loadConfig(configFilePath); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config file")
+		os.Exit(1)
+	}
 
-	profiles, err := bf2.GetProfiles(h)
+	g, err := parseGame(gameName)
 	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid game")
+		os.Exit(1)
+	}
+
+	if g != handler.GameBf2 && !noGUI {
+		log.Fatal().Str(logKeyGame, string(g)).Msg("The graphical user interface only supports Battlefield 2, use -no-gui to manage other games")
+		os.Exit(1)
+	}
+
+	options, err := buildHandlerOptions(g, documentsPath, basePath, profilesPath, installPath, portable)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to determine paths")
+		os.Exit(1)
+	}
+
+	if !noBackup {
+		backupOption, err := buildBackupOption(backupDirPath, backupRetain)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to determine backup folder")
+			os.Exit(1)
+		}
+		options = append(options, backupOption)
+	}
+
+	if dryRun {
+		options = append(options, handler.WithDryRun())
+	}
+
+	options = append(options, handler.WithProcessDetector(process.NewDetector()))
+	if ignoreRunning {
+		options = append(options, handler.WithIgnoreRunningGame())
+	}
+
+	fileRepository := repository.NewOS()
+	h := handler.New(fileRepository, options...)
+
+	// Commands are run without loading any profiles, so they work even if profiles cannot be loaded
+	if flag.NArg() > 0 {
+		if err = runCommand(h, g, flag.Args()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to run command")
+			os.Exit(1)
+		}
+		if dryRun {
+			printPlan(os.Stdout, h.Plan())
+		}
+		return
+	}
+
+	profiles, err := refractorv2.GetProfiles(h, g)
+	var unreadable *game.ErrUnreadableProfiles
+	if errors.As(err, &unreadable) {
+		// Keep working with all readable profiles
+		for _, profileErr := range unreadable.Errors() {
+			log.Warn().Err(profileErr.Err).Str(logKeyProfile, profileErr.Key).Msg("Skipping profile which cannot be read")
+		}
+	} else if err != nil {
 		log.Fatal().Err(err).Msg("Failed to get list of available profiles")
 		os.Exit(1)
 	}
 
-	defaultProfileKey, err := bf2.GetDefaultProfileKey(h)
+	defaultProfileKey, err := refractorv2.GetDefaultProfileKey(h, g)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to determine current default user profile key")
 		os.Exit(1)
@@ -68,7 +172,7 @@ func main() {
 		mw.Run()
 	} else {
 		if setDefaultProfileKey != "" {
-			err = actions.SetDefaultProfile(h, setDefaultProfileKey)
+			err = actions.SetDefaultProfile(h, g, setDefaultProfileKey)
 			if err != nil {
 				log.Error().Err(err).Str(logKeyProfile, setDefaultProfileKey).Msg("Failed to update current default profile")
 			} else {
@@ -79,7 +183,7 @@ func main() {
 		}
 
 		if doPurgeServerHistory {
-			err = actions.PurgeServerHistory(h, defaultProfileKey)
+			err = actions.PurgeServerHistory(h, g, defaultProfileKey)
 			if err != nil {
 				log.Error().Err(err).Str(logKeyProfile, defaultProfileKey).Msg("Failed to purge server history from current default profile")
 			} else {
@@ -88,7 +192,7 @@ func main() {
 		}
 
 		if doPurgeServerFavorites {
-			err = actions.PurgeServerFavorites(h, defaultProfileKey)
+			err = actions.PurgeServerFavorites(h, g, defaultProfileKey)
 			if err != nil {
 				log.Error().Err(err).Str(logKeyProfile, defaultProfileKey).Msg("Failed to purge server favorites from current default profile")
 			} else {
@@ -96,8 +200,14 @@ func main() {
 			}
 		}
 
+		if descriptor, _ := handler.LookupGame(g); (doPurgeOldDemoBookmarks || doMarkAllVoiceOverHelpAsPlayed) && !descriptor.SharesConfigFormat(handler.GameBf2) {
+			log.Error().Str(logKeyGame, string(g)).Msg("Purging old demo bookmarks and disabling help voice overs is only supported for Battlefield 2 and its standalone mods")
+			doPurgeOldDemoBookmarks = false
+			doMarkAllVoiceOverHelpAsPlayed = false
+		}
+
 		if doPurgeOldDemoBookmarks {
-			err = actions.PurgeOldDemoBookmarks(h, defaultProfileKey)
+			err = actions.PurgeOldDemoBookmarks(h, g, defaultProfileKey)
 			if err != nil {
 				log.Error().Err(err).Str(logKeyProfile, defaultProfileKey).Msg("Failed to purge old demo bookmarks from current default profile")
 			} else {
@@ -106,7 +216,7 @@ func main() {
 		}
 
 		if doMarkAllVoiceOverHelpAsPlayed {
-			err = actions.MarkAllVoiceOverHelpAsPlayed(h, defaultProfileKey)
+			err = actions.MarkAllVoiceOverHelpAsPlayed(h, g, defaultProfileKey)
 			if err != nil {
 				log.Error().Err(err).Str(logKeyProfile, defaultProfileKey).Msg("Failed to mark all voice over help lines as played for current default profile")
 			} else {
@@ -115,16 +225,21 @@ func main() {
 		}
 
 		if doPurgeShaderCache {
-			err = actions.PurgeShareCache(h)
+			var mods []string
+			if modNames != "" {
+				mods = strings.Split(modNames, ",")
+			}
+			report, err := actions.PurgeShaderCacheWithReport(h, g, mods, olderThan)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to purge shader cache")
 			} else {
-				log.Info().Msg("Purged shader cache")
+				printPurgeReport(os.Stdout, report)
+				log.Info().Int(logKeyFiles, report.Files()).Int64(logKeyBytes, report.Bytes()).Msg("Purged shader cache")
 			}
 		}
 
 		if doPurgeLogoCache {
-			err = actions.PurgeLogoCache(h)
+			err = actions.PurgeLogoCache(h, g)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to purge logo cache")
 			} else {
@@ -132,4 +247,59 @@ func main() {
 			}
 		}
 	}
+
+	if dryRun {
+		printPlan(os.Stdout, h.Plan())
+	}
+}
+
+func parseGame(name string) (handler.Game, error) {
+	// Commands and actions are built on the Refractor v2 profile layout, so only those games can be managed
+	descriptor, ok := handler.LookupGame(handler.Game(name))
+	if !ok || descriptor.Engine != handler.EngineRefractorV2 {
+		return "", fmt.Errorf("unsupported game: %s", name)
+	}
+	return descriptor.Game, nil
+}
+
+func buildHandlerOptions(g handler.Game, documentsPath string, basePath string, profilesPath string, installPath string, portable bool) ([]handler.Option, error) {
+	var options []handler.Option
+	if documentsPath != "" {
+		options = append(options, handler.WithDocumentsPath(documentsPath))
+	}
+
+	// An explicitly given base path takes precedence over portable mode
+	if basePath == "" && portable {
+		executablePath, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		basePath = filepath.Dir(executablePath)
+	}
+
+	if basePath != "" {
+		options = append(options, handler.WithBasePath(g, basePath))
+	}
+
+	if profilesPath != "" {
+		options = append(options, handler.WithProfilesPath(g, profilesPath))
+	}
+
+	if installPath != "" {
+		options = append(options, handler.WithInstallPath(g, installPath))
+	}
+
+	return options, nil
+}
+
+func buildBackupOption(backupDirPath string, backupRetain int) (handler.Option, error) {
+	if backupDirPath == "" {
+		configDirPath, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		backupDirPath = filepath.Join(configDirPath, "bf2-conman", "backups")
+	}
+
+	return handler.WithBackups(backupDirPath, backupRetain), nil
 }
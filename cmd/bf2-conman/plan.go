@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+// Print all changes planned during a dry run
+func printPlan(w io.Writer, plan *handler.Plan) {
+	if plan.IsEmpty() {
+		_, _ = fmt.Fprintln(w, "Dry run: no changes would be made")
+		return
+	}
+
+	_, _ = fmt.Fprintln(w, "Dry run: the following changes would be made")
+	for _, write := range plan.Writes {
+		if write.Create {
+			_, _ = fmt.Fprintf(w, "create %s\n", write.Path)
+		} else {
+			_, _ = fmt.Fprintf(w, "update %s\n", write.Path)
+		}
+
+		for _, change := range write.Changes {
+			switch change.Type {
+			case config.ChangeTypeAdded:
+				_, _ = fmt.Fprintf(w, "  + %s %s\n", change.Key, change.New.String())
+			case config.ChangeTypeRemoved:
+				_, _ = fmt.Fprintf(w, "  - %s %s\n", change.Key, change.Old.String())
+			case config.ChangeTypeModified:
+				_, _ = fmt.Fprintf(w, "  ~ %s %s -> %s\n", change.Key, change.Old.String(), change.New.String())
+			}
+		}
+	}
+
+	for _, removal := range plan.Removals {
+		_, _ = fmt.Fprintf(w, "remove %s (%d bytes)\n", removal.Path, removal.Size)
+	}
+
+	for _, move := range plan.Moves {
+		_, _ = fmt.Fprintf(w, "move %s -> %s\n", move.From, move.To)
+	}
+}
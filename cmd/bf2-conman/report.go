@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+// Print all cache folders removed by a purge
+func printPurgeReport(w io.Writer, report *handler.PurgeReport) {
+	if len(report.Removed) == 0 {
+		_, _ = fmt.Fprintln(w, "No cache folders to purge")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tFILES\tBYTES\tLAST MODIFIED\tPATH")
+	for _, dir := range report.Removed {
+		// Shader cache folders belong to a mod, logo cache folders to a host
+		name := dir.Mod
+		if dir.Host != "" {
+			name = dir.Host
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", name, dir.Files, dir.Bytes, dir.Modified.Local().Format(time.DateTime), dir.Path)
+	}
+	_, _ = fmt.Fprintf(tw, "total\t%d\t%d\t\t\n", report.Files(), report.Bytes())
+	_ = tw.Flush()
+}
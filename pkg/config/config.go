@@ -84,6 +84,18 @@ func (c *Config) Delete(key string) {
 	delete(c.content, key)
 }
 
+// Retrieve all keys, sorted alphabetically
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.content))
+	for key := range c.content {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
 func (c *Config) ToBytes() []byte {
 	lines := make([]string, 0)
 
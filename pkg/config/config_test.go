@@ -441,3 +441,17 @@ func TestValue_Slice(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_Keys(t *testing.T) {
+	// GIVEN
+	config := New("Global.con", map[string]Value{
+		"GlobalSettings.setNamePrefix":  {content: "\"=PRE=\""},
+		"GlobalSettings.setDefaultUser": {content: "\"0010\""},
+	})
+
+	// WHEN
+	keys := config.Keys()
+
+	// THEN
+	assert.Equal(t, []string{"GlobalSettings.setDefaultUser", "GlobalSettings.setNamePrefix"}, keys)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"sort"
+)
+
+type ChangeType int
+
+const (
+	ChangeTypeAdded ChangeType = iota
+	ChangeTypeRemoved
+	ChangeTypeModified
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeTypeAdded:
+		return "added"
+	case ChangeTypeRemoved:
+		return "removed"
+	case ChangeTypeModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// Change Key-level difference between two configs (Old is empty for added keys, New is empty for removed keys)
+type Change struct {
+	Key  string
+	Type ChangeType
+	Old  Value
+	New  Value
+}
+
+// Compare two configs key by key, returning all changes sorted by key (either config may be nil, which is treated as empty)
+func Diff(old *Config, new *Config) []Change {
+	if old == nil {
+		old = New("", map[string]Value{})
+	}
+	if new == nil {
+		new = New("", map[string]Value{})
+	}
+
+	changes := make([]Change, 0)
+	for _, key := range old.Keys() {
+		oldValue := old.content[key]
+		newValue, ok := new.content[key]
+		if !ok {
+			changes = append(changes, Change{Key: key, Type: ChangeTypeRemoved, Old: oldValue})
+		} else if newValue.content != oldValue.content {
+			changes = append(changes, Change{Key: key, Type: ChangeTypeModified, Old: oldValue, New: newValue})
+		}
+	}
+
+	for _, key := range new.Keys() {
+		if _, ok := old.content[key]; !ok {
+			changes = append(changes, Change{Key: key, Type: ChangeTypeAdded, New: new.content[key]})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
@@ -0,0 +1,69 @@
+//go:build unit
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	type test struct {
+		name            string
+		givenOld        *Config
+		givenNew        *Config
+		expectedChanges []Change
+	}
+
+	tests := []test{
+		{
+			name: "detects added, removed and modified keys",
+			givenOld: New("General.con", map[string]Value{
+				"GeneralSettings.addServerHistory": {content: "\"1.1.1.1\""},
+				"GeneralSettings.setPlayedVOHelp":  {content: "\"HUD_HELP_A\""},
+				"GeneralSettings.setBFTVSaveDir":   {content: "\"Demos\""},
+			}),
+			givenNew: New("General.con", map[string]Value{
+				"GeneralSettings.setPlayedVOHelp":    {content: "\"HUD_HELP_A\";\"HUD_HELP_B\""},
+				"GeneralSettings.setBFTVSaveDir":     {content: "\"Demos\""},
+				"GeneralSettings.addFavouriteServer": {content: "\"2.2.2.2\""},
+			}),
+			expectedChanges: []Change{
+				{Key: "GeneralSettings.addFavouriteServer", Type: ChangeTypeAdded, New: Value{content: "\"2.2.2.2\""}},
+				{Key: "GeneralSettings.addServerHistory", Type: ChangeTypeRemoved, Old: Value{content: "\"1.1.1.1\""}},
+				{Key: "GeneralSettings.setPlayedVOHelp", Type: ChangeTypeModified, Old: Value{content: "\"HUD_HELP_A\""}, New: Value{content: "\"HUD_HELP_A\";\"HUD_HELP_B\""}},
+			},
+		},
+		{
+			name:     "treats missing old config as empty",
+			givenOld: nil,
+			givenNew: New("Global.con", map[string]Value{
+				"GlobalSettings.setDefaultUser": {content: "\"0001\""},
+			}),
+			expectedChanges: []Change{
+				{Key: "GlobalSettings.setDefaultUser", Type: ChangeTypeAdded, New: Value{content: "\"0001\""}},
+			},
+		},
+		{
+			name: "returns no changes for equal configs",
+			givenOld: New("Global.con", map[string]Value{
+				"GlobalSettings.setDefaultUser": {content: "\"0001\""},
+			}),
+			givenNew: New("Global.con", map[string]Value{
+				"GlobalSettings.setDefaultUser": {content: "\"0001\""},
+			}),
+			expectedChanges: []Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			changes := Diff(tt.givenOld, tt.givenNew)
+
+			// THEN
+			assert.Equal(t, tt.expectedChanges, changes)
+		})
+	}
+}
@@ -0,0 +1,5 @@
+//go:build ignore
+
+package bf1942
+
+//go:generate mockgen -source=../common.go -destination=bf1942_mock_test.go -package=$GOPACKAGE -write_package_comment=false
@@ -0,0 +1,421 @@
+package bf2
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	archiveManifestFileName = "manifest.json"
+	// archiveFileMaxSize Config files are tiny, so anything larger is not a profile archive (or a zip bomb)
+	archiveFileMaxSize = 1 << 20
+
+	conmanModulePath = "github.com/cetteup/conman"
+	unknownVersion   = "unknown"
+)
+
+type ErrInvalidArchive struct {
+	reason string
+}
+
+func (e *ErrInvalidArchive) Error() string {
+	return fmt.Sprintf("invalid profile archive: %s", e.reason)
+}
+
+type ErrProfileExists struct {
+	profileKey string
+}
+
+func (e *ErrProfileExists) Error() string {
+	return fmt.Sprintf("profile already exists: %s", e.profileKey)
+}
+
+// ExportHandler Handler used to export profiles, which needs to read config files as is
+type ExportHandler interface {
+	game.DirHandler
+	ReadFile(path string) ([]byte, error)
+}
+
+// ArchiveManifest Describes a profile exported to a zip archive
+type ArchiveManifest struct {
+	Game          handler.Game  `json:"game"`
+	ProfileKey    string        `json:"profileKey"`
+	ConmanVersion string        `json:"conmanVersion"`
+	Created       time.Time     `json:"created"`
+	Files         []ArchiveFile `json:"files"`
+	// Whether the (encrypted) GameSpy password was removed from Profile.con before exporting
+	PasswordStripped bool `json:"passwordStripped"`
+}
+
+type ArchiveFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+type exportOptions struct {
+	stripPassword bool
+}
+
+type ExportOption func(o *exportOptions)
+
+// Remove the (encrypted) GameSpy password from the exported Profile.con (the only file which is changed on export)
+func ExportWithoutPassword() ExportOption {
+	return func(o *exportOptions) {
+		o.stripPassword = true
+	}
+}
+
+type ImportConflict int
+
+const (
+	// Fail if the target profile key is already in use
+	ImportConflictFail ImportConflict = iota
+	// Replace all config files of the existing profile with the ones from the archive
+	ImportConflictOverwrite
+	// Import into the lowest free profile key instead
+	ImportConflictNewKey
+)
+
+type importOptions struct {
+	profileKey string
+	conflict   ImportConflict
+}
+
+type ImportOption func(o *importOptions)
+
+// Import into the given profile key instead of the lowest free one
+func ImportToKey(profileKey string) ImportOption {
+	return func(o *importOptions) {
+		o.profileKey = profileKey
+	}
+}
+
+// Handle the target profile key already being in use as given (defaults to ImportConflictFail)
+func OnImportConflict(conflict ImportConflict) ImportOption {
+	return func(o *importOptions) {
+		o.conflict = conflict
+	}
+}
+
+// Write all config files of the given profile to w as a zip archive, along with a manifest containing their checksums
+func ExportProfile(h ExportHandler, profileKey string, w io.Writer, options ...ExportOption) (ArchiveManifest, error) {
+	var opts exportOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	profileConPath, err := buildExistingProfileConPath(h, profileKey)
+	if err != nil {
+		return ArchiveManifest{}, err
+	}
+
+	profilePath := filepath.Dir(profileConPath)
+	entries, err := h.ReadDir(profilePath)
+	if err != nil {
+		return ArchiveManifest{}, err
+	}
+
+	manifest := ArchiveManifest{
+		Game:             handler.GameBf2,
+		ProfileKey:       profileKey,
+		ConmanVersion:    conmanVersion(),
+		Created:          time.Now().UTC(),
+		PasswordStripped: opts.stripPassword,
+	}
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".con") {
+			continue
+		}
+
+		// Export files as is, since re-serializing would reorder lines which depend on each other (e.g. in Controls.con)
+		data, err := h.ReadFile(filepath.Join(profilePath, entry.Name()))
+		if err != nil {
+			return ArchiveManifest{}, err
+		}
+
+		if opts.stripPassword && strings.EqualFold(entry.Name(), string(ProfileConfigFileProfileCon)) {
+			c := config.FromBytes(entry.Name(), data)
+			if c.HasKey(ProfileConKeyPassword) {
+				c.Delete(ProfileConKeyPassword)
+				data = c.ToBytes()
+			}
+		}
+
+		files[entry.Name()] = data
+		manifest.Files = append(manifest.Files, ArchiveFile{Name: entry.Name(), SHA256: checksum(data)})
+	}
+
+	if _, ok := findArchiveFile(files, string(ProfileConfigFileProfileCon)); !ok {
+		return ArchiveManifest{}, &os.PathError{Op: "open", Path: profileConPath, Err: os.ErrNotExist}
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Name < manifest.Files[j].Name
+	})
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return ArchiveManifest{}, err
+	}
+
+	zw := zip.NewWriter(w)
+	if err = writeArchiveFile(zw, archiveManifestFileName, manifestData, manifest.Created); err != nil {
+		return ArchiveManifest{}, err
+	}
+	for _, file := range manifest.Files {
+		if err = writeArchiveFile(zw, file.Name, files[file.Name], manifest.Created); err != nil {
+			return ArchiveManifest{}, err
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return ArchiveManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// Import a profile from a zip archive created by ExportProfile (verifying all checksums before changing anything),
+// into the lowest free profile key unless another one is given
+func ImportProfile(h ProfileHandler, r io.ReaderAt, size int64, options ...ImportOption) (game.Profile, ArchiveManifest, error) {
+	var opts importOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.profileKey != "" && !refractorv2.IsWellFormedProfileKey(opts.profileKey) {
+		return game.Profile{}, ArchiveManifest{}, fmt.Errorf("profile key is not a number with up to four digits: %s", opts.profileKey)
+	}
+
+	manifest, files, err := readArchive(r, size)
+	if err != nil {
+		return game.Profile{}, ArchiveManifest{}, err
+	}
+
+	profilesPath, err := h.BuildProfilesFolderPath(handler.GameBf2)
+	if err != nil {
+		return game.Profile{}, ArchiveManifest{}, err
+	}
+
+	// Lock the profiles folder (and allocate a key if none was given)
+	profileKey, release, err := reserveProfileKey(h, profilesPath)
+	if err != nil {
+		return game.Profile{}, ArchiveManifest{}, err
+	}
+	defer release()
+
+	tx := h.Begin()
+	if opts.profileKey != "" {
+		entries, err := h.ReadDir(filepath.Join(profilesPath, opts.profileKey))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			profileKey = opts.profileKey
+		case err != nil:
+			return game.Profile{}, ArchiveManifest{}, err
+		case opts.conflict == ImportConflictOverwrite:
+			profileKey = opts.profileKey
+			profilePath := filepath.Join(profilesPath, profileKey)
+			// Also lock the profile folder itself, since files in it are updated while holding only its lock
+			profileLock, err := h.LockDir(profilePath)
+			if err != nil {
+				return game.Profile{}, ArchiveManifest{}, err
+			}
+			defer func() {
+				_ = profileLock.Release()
+			}()
+
+			// List files again, since they may have changed before the profile folder was locked
+			if entries, err = h.ReadDir(profilePath); err != nil {
+				return game.Profile{}, ArchiveManifest{}, err
+			}
+			// Remove existing config files which are not part of the archive, but keep anything else (e.g. demos)
+			for _, entry := range entries {
+				if _, ok := files[entry.Name()]; ok || entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".con") {
+					continue
+				}
+				if err = tx.Remove(filepath.Join(profilePath, entry.Name())); err != nil {
+					return game.Profile{}, ArchiveManifest{}, err
+				}
+			}
+		case opts.conflict == ImportConflictNewKey:
+			// Keep the allocated key
+		default:
+			return game.Profile{}, ArchiveManifest{}, &ErrProfileExists{profileKey: opts.profileKey}
+		}
+	}
+
+	var configFiles []string
+	for _, file := range manifest.Files {
+		// Write files exactly as exported, checksums were verified against their raw content
+		if err = tx.WriteFile(filepath.Join(profilesPath, profileKey, file.Name), files[file.Name]); err != nil {
+			return game.Profile{}, ArchiveManifest{}, err
+		}
+		configFiles = append(configFiles, file.Name)
+	}
+
+	// Profile is imported even if replaced config files cannot be cleaned up afterwards
+	err = tx.Commit()
+	var cleanupErr *handler.ErrCleanupFailed
+	if err != nil && !errors.As(err, &cleanupErr) {
+		return game.Profile{}, ArchiveManifest{}, err
+	}
+
+	profileConData, _ := findArchiveFile(files, string(ProfileConfigFileProfileCon))
+	profileCon := config.FromBytes(string(ProfileConfigFileProfileCon), profileConData)
+	return newProfile(profileKey, profileCon, configFiles), manifest, err
+}
+
+// readArchive Read and verify the manifest and all config files of a profile archive
+func readArchive(r io.ReaderAt, size int64) (ArchiveManifest, map[string][]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: err.Error()}
+	}
+
+	contents := map[string][]byte{}
+	for _, f := range zr.File {
+		// Names differing only in case would be written to the same file on Windows
+		if _, ok := findArchiveFile(contents, f.Name); ok {
+			return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("duplicate file: %s", f.Name)}
+		}
+		data, err := readArchiveFile(f)
+		if err != nil {
+			return ArchiveManifest{}, nil, err
+		}
+		contents[f.Name] = data
+	}
+
+	manifestData, ok := contents[archiveManifestFileName]
+	if !ok {
+		return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("missing %s", archiveManifestFileName)}
+	}
+	delete(contents, archiveManifestFileName)
+
+	var manifest ArchiveManifest
+	if err = json.Unmarshal(manifestData, &manifest); err != nil {
+		return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("failed to parse %s: %s", archiveManifestFileName, err)}
+	}
+	if manifest.Game != handler.GameBf2 {
+		return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("archive contains a profile for %q, not %q", manifest.Game, handler.GameBf2)}
+	}
+
+	files := map[string][]byte{}
+	for _, file := range manifest.Files {
+		// Only accept plain config file names, so nothing can be written outside the profile folder
+		if file.Name != path.Base(file.Name) || file.Name != filepath.Base(file.Name) || !strings.EqualFold(filepath.Ext(file.Name), ".con") {
+			return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("not a config file name: %s", file.Name)}
+		}
+		data, ok := contents[file.Name]
+		if !ok {
+			return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("missing file: %s", file.Name)}
+		}
+		if checksum(data) != strings.ToLower(file.SHA256) {
+			return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("checksum mismatch: %s", file.Name)}
+		}
+		files[file.Name] = data
+	}
+
+	for name := range contents {
+		if _, ok := files[name]; !ok {
+			return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("file not listed in %s: %s", archiveManifestFileName, name)}
+		}
+	}
+
+	if _, ok := findArchiveFile(files, string(ProfileConfigFileProfileCon)); !ok {
+		return ArchiveManifest{}, nil, &ErrInvalidArchive{reason: fmt.Sprintf("missing %s", ProfileConfigFileProfileCon)}
+	}
+
+	return manifest, files, nil
+}
+
+// findArchiveFile Find a file by name, ignoring case (like Windows does)
+func findArchiveFile(files map[string][]byte, name string) ([]byte, bool) {
+	for fileName, data := range files {
+		if strings.EqualFold(fileName, name) {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+func readArchiveFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > archiveFileMaxSize {
+		return nil, &ErrInvalidArchive{reason: fmt.Sprintf("file too large: %s", f.Name)}
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, &ErrInvalidArchive{reason: err.Error()}
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	// Do not rely on the size stated in the archive
+	data, err := io.ReadAll(io.LimitReader(rc, archiveFileMaxSize+1))
+	if err != nil {
+		return nil, &ErrInvalidArchive{reason: err.Error()}
+	}
+	if len(data) > archiveFileMaxSize {
+		return nil, &ErrInvalidArchive{reason: fmt.Sprintf("file too large: %s", f.Name)}
+	}
+
+	return data, nil
+}
+
+func writeArchiveFile(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, bytes.NewReader(data))
+	return err
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// conmanVersion Determine the version of conman included in the running binary
+func conmanVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return unknownVersion
+	}
+
+	if info.Main.Path == conmanModulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == conmanModulePath {
+			return dep.Version
+		}
+	}
+
+	return unknownVersion
+}
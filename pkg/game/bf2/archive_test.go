@@ -0,0 +1,412 @@
+//go:build unit
+
+package bf2
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+const (
+	archiveProfileCon = "LocalProfile.setEmail \"mister249@example.com\"\r\nLocalProfile.setGamespyNick \"mister249\"\r\nLocalProfile.setName \"mister249\"\r\nLocalProfile.setNick \"mister249\"\r\nLocalProfile.setPassword \"encrypted\"\r\n"
+	archiveVideoCon   = "VideoSettings.setResolution 800x600@60Hz\r\n"
+)
+
+func TestExportProfile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+
+	givenFiles := fstest.MapFS{
+		"Profiles/0001/Profile.con":   {Data: []byte(archiveProfileCon)},
+		"Profiles/0001/Video.con":     {Data: []byte(archiveVideoCon)},
+		"Profiles/0001/Controls.con":  {Data: []byte(testControlsCon)},
+		"Profiles/0001/conman.lock":   {Data: []byte("{}")},
+		"Profiles/0001/Demos/a.bf2cl": {Data: []byte{}},
+	}
+
+	type test struct {
+		name            string
+		givenProfileKey string
+		givenOptions    []ExportOption
+		wantFiles       map[string]string
+		wantStripped    bool
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:            "exports all config files",
+			givenProfileKey: "0001",
+			wantFiles: map[string]string{
+				"Profile.con":  archiveProfileCon,
+				"Video.con":    archiveVideoCon,
+				"Controls.con": testControlsCon,
+			},
+		},
+		{
+			name:            "exports profile without password",
+			givenProfileKey: "0001",
+			givenOptions:    []ExportOption{ExportWithoutPassword()},
+			wantFiles: map[string]string{
+				"Profile.con":  "LocalProfile.setEmail \"mister249@example.com\"\r\nLocalProfile.setGamespyNick \"mister249\"\r\nLocalProfile.setName \"mister249\"\r\nLocalProfile.setNick \"mister249\"\r\n",
+				"Video.con":    archiveVideoCon,
+				"Controls.con": testControlsCon,
+			},
+			wantStripped: true,
+		},
+		{
+			name:            "error if profile does not exist",
+			givenProfileKey: "0002",
+			wantErrContains: "profile not found: 0002",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+			var buf bytes.Buffer
+
+			// WHEN
+			manifest, err := ExportProfile(h, tt.givenProfileKey, &buf, tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, handler.GameBf2, manifest.Game)
+			assert.Equal(t, tt.givenProfileKey, manifest.ProfileKey)
+			assert.NotEmpty(t, manifest.ConmanVersion)
+			assert.Equal(t, tt.wantStripped, manifest.PasswordStripped)
+
+			contents := readTestArchive(t, buf.Bytes())
+			var archived ArchiveManifest
+			require.NoError(t, json.Unmarshal(contents[archiveManifestFileName], &archived))
+			assert.Equal(t, manifest.Files, archived.Files)
+			delete(contents, archiveManifestFileName)
+
+			require.Len(t, contents, len(tt.wantFiles))
+			require.Len(t, manifest.Files, len(tt.wantFiles))
+			for _, file := range manifest.Files {
+				assert.Equal(t, tt.wantFiles[file.Name], string(contents[file.Name]), file.Name)
+				assert.Equal(t, checksum([]byte(tt.wantFiles[file.Name])), file.SHA256, file.Name)
+			}
+		})
+	}
+}
+
+func TestImportProfile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+
+	validFiles := map[string]string{
+		"Profile.con": archiveProfileCon,
+		"Video.con":   archiveVideoCon,
+	}
+	validManifest := ArchiveManifest{
+		Game:       handler.GameBf2,
+		ProfileKey: "0001",
+		Files: []ArchiveFile{
+			{Name: "Profile.con", SHA256: checksum([]byte(archiveProfileCon))},
+			{Name: "Video.con", SHA256: checksum([]byte(archiveVideoCon))},
+		},
+	}
+	existingFiles := fstest.MapFS{
+		"Profiles/0001/Profile.con":   {Data: []byte("LocalProfile.setName \"offline\"\r\n")},
+		"Profiles/0001/General.con":   {Data: []byte("GeneralSettings.setHUDTransparency 100\r\n")},
+		"Profiles/0001/Demos/a.bf2cl": {Data: []byte{}},
+	}
+
+	type test struct {
+		name            string
+		givenFiles      fstest.MapFS
+		givenManifest   ArchiveManifest
+		givenArchived   map[string]string
+		givenOptions    []ImportOption
+		wantKey         string
+		wantFiles       map[string]string
+		wantMissing     []string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:          "imports into lowest free profile key",
+			givenFiles:    existingFiles,
+			givenManifest: validManifest,
+			givenArchived: validFiles,
+			wantKey:       "0002",
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con": archiveProfileCon,
+				"Profiles/0002/Video.con":   archiveVideoCon,
+				"Profiles/0001/Profile.con": "LocalProfile.setName \"offline\"\r\n",
+			},
+		},
+		{
+			name:          "imports into given profile key",
+			givenFiles:    fstest.MapFS{},
+			givenManifest: validManifest,
+			givenArchived: validFiles,
+			givenOptions:  []ImportOption{ImportToKey("0007")},
+			wantKey:       "0007",
+			wantFiles: map[string]string{
+				"Profiles/0007/Profile.con": archiveProfileCon,
+				"Profiles/0007/Video.con":   archiveVideoCon,
+			},
+		},
+		{
+			name:          "overwrites config files of existing profile",
+			givenFiles:    existingFiles,
+			givenManifest: validManifest,
+			givenArchived: validFiles,
+			givenOptions:  []ImportOption{ImportToKey("0001"), OnImportConflict(ImportConflictOverwrite)},
+			wantKey:       "0001",
+			wantFiles: map[string]string{
+				"Profiles/0001/Profile.con":   archiveProfileCon,
+				"Profiles/0001/Video.con":     archiveVideoCon,
+				"Profiles/0001/Demos/a.bf2cl": "",
+			},
+			wantMissing: []string{"Profiles/0001/General.con"},
+		},
+		{
+			name:          "imports into new profile key on conflict",
+			givenFiles:    existingFiles,
+			givenManifest: validManifest,
+			givenArchived: validFiles,
+			givenOptions:  []ImportOption{ImportToKey("0001"), OnImportConflict(ImportConflictNewKey)},
+			wantKey:       "0002",
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con": archiveProfileCon,
+				"Profiles/0001/Profile.con": "LocalProfile.setName \"offline\"\r\n",
+			},
+		},
+		{
+			name: "error if existing profile is locked when overwriting it",
+			givenFiles: fstest.MapFS{
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"offline\"\r\n")},
+				// Lock file which cannot be parsed is considered held by another process
+				"Profiles/0001/conman.lock": {Data: []byte("{\"pid\":"), ModTime: time.Now()},
+			},
+			givenManifest:   validManifest,
+			givenArchived:   validFiles,
+			givenOptions:    []ImportOption{ImportToKey("0001"), OnImportConflict(ImportConflictOverwrite)},
+			wantFiles:       map[string]string{"Profiles/0001/Profile.con": "LocalProfile.setName \"offline\"\r\n"},
+			wantErrContains: "folder is locked by another process",
+		},
+		{
+			name:            "error on conflict by default",
+			givenFiles:      existingFiles,
+			givenManifest:   validManifest,
+			givenArchived:   validFiles,
+			givenOptions:    []ImportOption{ImportToKey("0001")},
+			wantFiles:       map[string]string{"Profiles/0001/Profile.con": "LocalProfile.setName \"offline\"\r\n"},
+			wantErrContains: "profile already exists: 0001",
+		},
+		{
+			name:          "error for checksum mismatch",
+			givenFiles:    fstest.MapFS{},
+			givenManifest: validManifest,
+			givenArchived: map[string]string{
+				"Profile.con": archiveProfileCon,
+				"Video.con":   "VideoSettings.setResolution 1920x1080@60Hz\r\n",
+			},
+			wantMissing:     []string{"Profiles/0001"},
+			wantErrContains: "invalid profile archive: checksum mismatch: Video.con",
+		},
+		{
+			name:       "error for archive of another game",
+			givenFiles: fstest.MapFS{},
+			givenManifest: ArchiveManifest{
+				Game:  handler.GameBf2142,
+				Files: validManifest.Files,
+			},
+			givenArchived:   validFiles,
+			wantErrContains: "invalid profile archive: archive contains a profile for \"bf2142\", not \"bf2\"",
+		},
+		{
+			name:       "error for file outside of profile folder",
+			givenFiles: fstest.MapFS{},
+			givenManifest: ArchiveManifest{
+				Game: handler.GameBf2,
+				Files: []ArchiveFile{
+					{Name: "Profile.con", SHA256: checksum([]byte(archiveProfileCon))},
+					{Name: "../Global.con", SHA256: checksum([]byte(archiveVideoCon))},
+				},
+			},
+			givenArchived: map[string]string{
+				"Profile.con":   archiveProfileCon,
+				"../Global.con": archiveVideoCon,
+			},
+			wantErrContains: "invalid profile archive: not a config file name: ../Global.con",
+		},
+		{
+			name:       "error for file names differing only in case",
+			givenFiles: fstest.MapFS{},
+			givenManifest: ArchiveManifest{
+				Game: handler.GameBf2,
+				Files: []ArchiveFile{
+					{Name: "Profile.con", SHA256: checksum([]byte(archiveProfileCon))},
+					{Name: "Video.con", SHA256: checksum([]byte(archiveVideoCon))},
+					{Name: "video.con", SHA256: checksum([]byte(archiveVideoCon))},
+				},
+			},
+			givenArchived: map[string]string{
+				"Profile.con": archiveProfileCon,
+				"Video.con":   archiveVideoCon,
+				"video.con":   archiveVideoCon,
+			},
+			wantErrContains: "invalid profile archive: duplicate file: ",
+		},
+		{
+			name:       "error for file not listed in manifest",
+			givenFiles: fstest.MapFS{},
+			givenManifest: ArchiveManifest{
+				Game:  handler.GameBf2,
+				Files: validManifest.Files[:1],
+			},
+			givenArchived:   validFiles,
+			wantErrContains: "invalid profile archive: file not listed in manifest.json: Video.con",
+		},
+		{
+			name:       "error for archive without Profile.con",
+			givenFiles: fstest.MapFS{},
+			givenManifest: ArchiveManifest{
+				Game:  handler.GameBf2,
+				Files: validManifest.Files[1:],
+			},
+			givenArchived:   map[string]string{"Video.con": archiveVideoCon},
+			wantErrContains: "invalid profile archive: missing Profile.con",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+			archive := buildTestArchive(t, tt.givenManifest, tt.givenArchived)
+
+			// WHEN
+			profile, manifest, err := ImportProfile(h, bytes.NewReader(archive), int64(len(archive)), tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.givenManifest.ProfileKey, manifest.ProfileKey)
+				assert.Equal(t, game.Profile{
+					Key:         tt.wantKey,
+					Name:        "mister249",
+					Type:        game.ProfileTypeMultiplayer,
+					Nick:        "mister249",
+					GamespyNick: "mister249",
+					HasEmail:    true,
+					HasPassword: true,
+					ConfigFiles: []string{"Profile.con", "Video.con"},
+				}, profile)
+			}
+			for path, want := range tt.wantFiles {
+				data, err := repo.ReadFile(filepath.Join(basePath, filepath.FromSlash(path)))
+				require.NoError(t, err)
+				assert.Equal(t, want, string(data), path)
+			}
+			for _, path := range tt.wantMissing {
+				_, err := repo.Stat(filepath.Join(basePath, filepath.FromSlash(path)))
+				assert.ErrorIs(t, err, os.ErrNotExist, path)
+			}
+			exists, err := repo.FileExists(filepath.Join(profilesPath, "conman.lock"))
+			require.NoError(t, err)
+			assert.False(t, exists)
+			if tt.wantKey != "" {
+				exists, err = repo.FileExists(filepath.Join(profilesPath, tt.wantKey, "conman.lock"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+			}
+		})
+	}
+}
+
+func TestExportImportProfile(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	source := repository.NewMemory()
+	require.NoError(t, source.Load(fstest.MapFS{
+		"Profiles/0003/Profile.con":  {Data: []byte(archiveProfileCon)},
+		"Profiles/0003/Video.con":    {Data: []byte(archiveVideoCon)},
+		"Profiles/0003/Controls.con": {Data: []byte(testControlsCon)},
+		"Profiles/0003/mapList.con":  {Data: []byte(testMapListCon)},
+	}, basePath))
+	target := repository.NewMemory()
+	require.NoError(t, target.Load(fstest.MapFS{}, basePath))
+	var buf bytes.Buffer
+	_, err := ExportProfile(handler.New(source, handler.WithBasePath(handler.GameBf2, basePath)), "0003", &buf)
+	require.NoError(t, err)
+
+	// WHEN
+	profile, manifest, err := ImportProfile(handler.New(target, handler.WithBasePath(handler.GameBf2, basePath)), bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "0001", profile.Key)
+	assert.Equal(t, "0003", manifest.ProfileKey)
+	for _, fileName := range []string{"Profile.con", "Video.con", "Controls.con", "mapList.con"} {
+		want, err := source.ReadFile(filepath.Join(basePath, "Profiles", "0003", fileName))
+		require.NoError(t, err)
+		got, err := target.ReadFile(filepath.Join(basePath, "Profiles", "0001", fileName))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, fileName)
+	}
+}
+
+func buildTestArchive(t *testing.T, manifest ArchiveManifest, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifestData, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	w, err := zw.Create(archiveManifestFileName)
+	require.NoError(t, err)
+	_, err = w.Write(manifestData)
+	require.NoError(t, err)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func readTestArchive(t *testing.T, archive []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	contents := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		contents[f.Name] = data
+	}
+	return contents
+}
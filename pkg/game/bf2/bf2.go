@@ -3,14 +3,13 @@ package bf2
 
 import (
 	"fmt"
-	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cetteup/conman/pkg/config"
 	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
 	"github.com/cetteup/conman/pkg/handler"
 )
 
@@ -27,21 +26,19 @@ const (
 	ProfileConfigFileServerSettingsCon ProfileConfigFile = "ServerSettings.con"
 	ProfileConfigFileVideoCon          ProfileConfigFile = "Video.con"
 
-	DefaultProfileKey = "Default"
-	// profileKeyMaxLength BF2 only uses 4 digit profile keys
-	profileKeyMaxLength         = 4
+	DefaultProfileKey           = refractorv2.DefaultProfileKey
 	demoBookmarkTimestampLayout = "2006-01-02 15:04:05"
 
-	GlobalConKeyDefaultProfileRef = "GlobalSettings.setDefaultUser"
+	GlobalConKeyDefaultProfileRef = refractorv2.GlobalConKeyDefaultProfileRef
 
-	ProfileConKeyName        = "LocalProfile.setName"
-	ProfileConKeyNick        = "LocalProfile.setNick"
-	ProfileConKeyGamespyNick = "LocalProfile.setGamespyNick"
-	ProfileConKeyEmail       = "LocalProfile.setEmail"
-	ProfileConKeyPassword    = "LocalProfile.setPassword"
+	ProfileConKeyName        = refractorv2.ProfileConKeyName
+	ProfileConKeyNick        = refractorv2.ProfileConKeyNick
+	ProfileConKeyGamespyNick = refractorv2.ProfileConKeyGamespyNick
+	ProfileConKeyEmail       = refractorv2.ProfileConKeyEmail
+	ProfileConKeyPassword    = refractorv2.ProfileConKeyPassword
 
-	GeneralConKeyServerHistory       = "GeneralSettings.addServerHistory"
-	GeneralConKeyFavoriteServer      = "GeneralSettings.addFavouriteServer"
+	GeneralConKeyServerHistory       = refractorv2.GeneralConKeyServerHistory
+	GeneralConKeyFavoriteServer      = refractorv2.GeneralConKeyFavoriteServer
 	GeneralConKeyVoiceOverHelpPlayed = "GeneralSettings.setPlayedVOHelp"
 
 	DemoBookmarksConKeyDemoBookmark = "LocalProfile.addDemoBookmark"
@@ -53,91 +50,26 @@ var (
 
 // Read a config file from the given Battlefield 2 profile
 func ReadProfileConfigFile(h game.Handler, profileKey string, configFile ProfileConfigFile) (*config.Config, error) {
-	basePath, err := h.BuildProfilesFolderPath(handler.GameBf2)
-	if err != nil {
-		return nil, err
-	}
-
-	filePath := filepath.Join(basePath, profileKey, string(configFile))
-	conFile, err := h.ReadConfigFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	return conFile, nil
+	return refractorv2.ReadProfileConfigFile(h, handler.GameBf2, profileKey, string(configFile))
 }
 
-func GetProfiles(h game.Handler) ([]game.Profile, error) {
-	profileKeys, err := h.GetProfileKeys(handler.GameBf2)
-	if err != nil {
-		return nil, err
-	}
-
-	var profiles []game.Profile
-	for _, profileKey := range profileKeys {
-		// Ignore the default profile
-		if profileKey == DefaultProfileKey {
-			continue
-		}
-
-		profileCon, err := h.ReadProfileConfig(handler.GameBf2, profileKey)
-		if err != nil {
-			return nil, err
-		}
-
-		profileName, err := profileCon.GetValue(ProfileConKeyName)
-		if err != nil {
-			return nil, err
-		}
-
-		profileType := game.ProfileTypeMultiplayer
-		// Singleplayer profiles do not contain an email address
-		if !profileCon.HasKey(ProfileConKeyEmail) {
-			profileType = game.ProfileTypeSingleplayer
-		}
-
-		profiles = append(profiles, game.Profile{
-			Key:  profileKey,
-			Name: profileName.String(),
-			Type: profileType,
-		})
-	}
+// Build the path to a config file in the given Battlefield 2 profile
+func BuildProfileConfigFilePath(h game.Handler, profileKey string, configFile ProfileConfigFile) (string, error) {
+	return refractorv2.BuildProfileConfigFilePath(h, handler.GameBf2, profileKey, string(configFile))
+}
 
-	return profiles, nil
+func GetProfiles(h game.DirHandler) ([]game.Profile, error) {
+	return refractorv2.GetProfiles(h, handler.GameBf2)
 }
 
 // Read and parse the Battlefield 2 Profile.con file for the current default profile
 func GetDefaultProfileProfileCon(h game.Handler) (*config.Config, error) {
-	profileKey, err := GetDefaultProfileKey(h)
-	if err != nil {
-		return nil, err
-	}
-
-	profileCon, err := h.ReadProfileConfig(handler.GameBf2, profileKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read Profile.con for current default profile (%s): %s", profileKey, err)
-	}
-
-	return profileCon, nil
+	return refractorv2.GetDefaultProfileProfileCon(h, handler.GameBf2)
 }
 
 // Get the default profile's key by reading and parsing the Battlefield 2 Global.con file
 func GetDefaultProfileKey(h game.Handler) (string, error) {
-	globalCon, err := h.ReadGlobalConfig(handler.GameBf2)
-	if err != nil {
-		return "", fmt.Errorf("failed to read Global.con: %s", err)
-	}
-
-	defaultUserRef, err := globalCon.GetValue(GlobalConKeyDefaultProfileRef)
-	if err != nil {
-		return "", fmt.Errorf("reference to default profile is missing from Global.con")
-	}
-	// Since BF2 only uses 4 digits for the profile key, 16 bits is plenty to store it
-	if _, err := strconv.ParseInt(defaultUserRef.String(), 10, 16); err != nil || len(defaultUserRef.String()) > profileKeyMaxLength {
-		return "", fmt.Errorf("reference to default profile in Global.con is not a valid profile key: %s", defaultUserRef.String())
-	}
-
-	return defaultUserRef.String(), nil
+	return refractorv2.GetDefaultProfileKey(h, handler.GameBf2)
 }
 
 func PurgeShaderCache(h game.Handler) error {
@@ -149,7 +81,7 @@ func PurgeLogoCache(h game.Handler) error {
 }
 
 func SetDefaultProfile(globalCon *config.Config, profileKey string) {
-	globalCon.SetValue(GlobalConKeyDefaultProfileRef, *config.NewQuotedValue(profileKey))
+	refractorv2.SetDefaultProfile(globalCon, profileKey)
 }
 
 // Extract profile name and encrypted password from a parsed Battlefield 2 Profile.con file
@@ -169,11 +101,11 @@ func GetEncryptedLogin(profileCon *config.Config) (string, string, error) {
 
 // Remove all server history entries (GeneralSettings.addServerHistory) from given General.con config
 func PurgeServerHistory(generalCon *config.Config) {
-	generalCon.Delete(GeneralConKeyServerHistory)
+	refractorv2.PurgeServerHistory(generalCon)
 }
 
 func PurgeServerFavorites(generalCon *config.Config) {
-	generalCon.Delete(GeneralConKeyFavoriteServer)
+	refractorv2.PurgeServerFavorites(generalCon)
 }
 
 // Remove all demo bookmarks older than the given duration (actual age is calculated based on the given reference)
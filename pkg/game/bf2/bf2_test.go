@@ -34,7 +34,7 @@ func TestReadProfileConfigFile(t *testing.T) {
 			givenProfileKey: "0001",
 			givenConfigFile: ProfileConfigFileProfileCon,
 			expect: func(h *MockHandler) {
-				basePath := "C:\\Users\\default\\Documents\\Battlefield 2\\Profiles"
+				basePath := filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles")
 				profileConPath := filepath.Join(basePath, "0001", "Profile.con")
 				h.EXPECT().BuildProfilesFolderPath(handler.GameBf2).Return(basePath, nil)
 				h.EXPECT().ReadConfigFile(profileConPath).Return(config.New(
@@ -45,7 +45,7 @@ func TestReadProfileConfigFile(t *testing.T) {
 				), nil)
 			},
 			wantConfig: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"),
 				map[string]config.Value{
 					"LocalProfile.setName": *config.NewValue("\"mister249\""),
 				},
@@ -65,7 +65,7 @@ func TestReadProfileConfigFile(t *testing.T) {
 			givenProfileKey: "0001",
 			givenConfigFile: ProfileConfigFileProfileCon,
 			expect: func(h *MockHandler) {
-				basePath := "C:\\Users\\default\\Documents\\Battlefield 2\\Profiles"
+				basePath := filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles")
 				h.EXPECT().BuildProfilesFolderPath(handler.GameBf2).Return(basePath, nil)
 				h.EXPECT().ReadConfigFile(filepath.Join(basePath, "0001", "Profile.con")).Return(nil, fmt.Errorf("some-error"))
 			},
@@ -99,7 +99,7 @@ func TestReadProfileConfigFile(t *testing.T) {
 func TestGetProfiles(t *testing.T) {
 	type test struct {
 		name            string
-		expect          func(h *MockHandler)
+		expect          func(h *MockDirHandler)
 		wantProfiles    []game.Profile
 		wantErrContains string
 	}
@@ -107,27 +107,31 @@ func TestGetProfiles(t *testing.T) {
 	tests := []test{
 		{
 			name: "successfully gets profiles",
-			expect: func(h *MockHandler) {
+			expect: func(h *MockDirHandler) {
 				h.EXPECT().GetProfileKeys(handler.GameBf2).Return([]string{"0001", "0002"}, nil)
+				h.EXPECT().BuildProfilesFolderPath(handler.GameBf2).Return(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles"), nil)
 				h.EXPECT().ReadProfileConfig(handler.GameBf2, "0001").Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"),
 					map[string]config.Value{
 						ProfileConKeyName:  *config.NewValue("some-multiplayer-profile"),
 						ProfileConKeyEmail: *config.NewValue("some-address@some-domain.some-tld"),
 					},
 				), nil)
+				h.EXPECT().ReadDir(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001")).Return(nil, nil)
 				h.EXPECT().ReadProfileConfig(handler.GameBf2, "0002").Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0002\\Profile.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0002/Profile.con"),
 					map[string]config.Value{
 						ProfileConKeyName: *config.NewValue("some-singleplayer-profile"),
 					},
 				), nil)
+				h.EXPECT().ReadDir(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0002")).Return(nil, nil)
 			},
 			wantProfiles: []game.Profile{
 				{
-					Key:  "0001",
-					Name: "some-multiplayer-profile",
-					Type: game.ProfileTypeMultiplayer,
+					Key:      "0001",
+					Name:     "some-multiplayer-profile",
+					Type:     game.ProfileTypeMultiplayer,
+					HasEmail: true,
 				},
 				{
 					Key:  "0002",
@@ -138,14 +142,16 @@ func TestGetProfiles(t *testing.T) {
 		},
 		{
 			name: "ignores default profile",
-			expect: func(h *MockHandler) {
+			expect: func(h *MockDirHandler) {
 				h.EXPECT().GetProfileKeys(handler.GameBf2).Return([]string{"0001", DefaultProfileKey}, nil)
+				h.EXPECT().BuildProfilesFolderPath(handler.GameBf2).Return(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles"), nil)
 				h.EXPECT().ReadProfileConfig(handler.GameBf2, "0001").Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"),
 					map[string]config.Value{
 						ProfileConKeyName: *config.NewValue("some-profile"),
 					},
 				), nil)
+				h.EXPECT().ReadDir(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001")).Return(nil, nil)
 			},
 			wantProfiles: []game.Profile{
 				{
@@ -157,25 +163,27 @@ func TestGetProfiles(t *testing.T) {
 		},
 		{
 			name: "error getting profile keys",
-			expect: func(h *MockHandler) {
+			expect: func(h *MockDirHandler) {
 				h.EXPECT().GetProfileKeys(handler.GameBf2).Return([]string{}, fmt.Errorf("some-error"))
 			},
 			wantErrContains: "some-error",
 		},
 		{
 			name: "error reading profile's Profile.con",
-			expect: func(h *MockHandler) {
+			expect: func(h *MockDirHandler) {
 				h.EXPECT().GetProfileKeys(handler.GameBf2).Return([]string{"0001"}, nil)
+				h.EXPECT().BuildProfilesFolderPath(handler.GameBf2).Return(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles"), nil)
 				h.EXPECT().ReadProfileConfig(handler.GameBf2, "0001").Return(nil, fmt.Errorf("some-error"))
 			},
 			wantErrContains: "some-error",
 		},
 		{
 			name: "error for Profile.con not containing profile name",
-			expect: func(h *MockHandler) {
+			expect: func(h *MockDirHandler) {
 				h.EXPECT().GetProfileKeys(handler.GameBf2).Return([]string{"0001"}, nil)
+				h.EXPECT().BuildProfilesFolderPath(handler.GameBf2).Return(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles"), nil)
 				h.EXPECT().ReadProfileConfig(handler.GameBf2, "0001").Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"),
 					map[string]config.Value{
 						"some-other-key": *config.NewValue("some-other-value"),
 					},
@@ -189,7 +197,7 @@ func TestGetProfiles(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
 			ctrl := gomock.NewController(t)
-			h := NewMockHandler(ctrl)
+			h := NewMockDirHandler(ctrl)
 
 			// EXPECT
 			tt.expect(h)
@@ -222,13 +230,13 @@ func TestGetDefaultProfileProfileCon(t *testing.T) {
 			expect: func(h *MockHandler) {
 				profileKey := "0001"
 				h.EXPECT().ReadGlobalConfig(handler.GameBf2).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con"),
 					map[string]config.Value{
 						GlobalConKeyDefaultProfileRef: *config.NewValue(profileKey),
 					},
 				), nil)
 				h.EXPECT().ReadProfileConfig(handler.GameBf2, profileKey).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"),
 					map[string]config.Value{
 						ProfileConKeyGamespyNick: *config.NewValue("some-nick"),
 						ProfileConKeyPassword:    *config.NewValue("some-encrypted-password"),
@@ -236,7 +244,7 @@ func TestGetDefaultProfileProfileCon(t *testing.T) {
 				), nil)
 			},
 			expectedProfileCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"),
 				map[string]config.Value{
 					ProfileConKeyGamespyNick: *config.NewValue("some-nick"),
 					ProfileConKeyPassword:    *config.NewValue("some-encrypted-password"),
@@ -255,7 +263,7 @@ func TestGetDefaultProfileProfileCon(t *testing.T) {
 			expect: func(h *MockHandler) {
 				profileKey := "0001"
 				h.EXPECT().ReadGlobalConfig(handler.GameBf2).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con"),
 					map[string]config.Value{
 						GlobalConKeyDefaultProfileRef: *config.NewValue(profileKey),
 					},
@@ -302,7 +310,7 @@ func TestGetDefaultProfileKey(t *testing.T) {
 			name: "successfully retrieves default user profile key",
 			expect: func(h *MockHandler) {
 				h.EXPECT().ReadGlobalConfig(handler.GameBf2).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con"),
 					map[string]config.Value{
 						GlobalConKeyDefaultProfileRef: *config.NewValue("0001"),
 					},
@@ -321,7 +329,7 @@ func TestGetDefaultProfileKey(t *testing.T) {
 			name: "error if default user reference is missing from Global.con",
 			expect: func(h *MockHandler) {
 				h.EXPECT().ReadGlobalConfig(handler.GameBf2).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con"),
 					map[string]config.Value{},
 				), nil)
 			},
@@ -331,7 +339,7 @@ func TestGetDefaultProfileKey(t *testing.T) {
 			name: "error if default user reference is non-numeric",
 			expect: func(h *MockHandler) {
 				h.EXPECT().ReadGlobalConfig(handler.GameBf2).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con"),
 					map[string]config.Value{
 						GlobalConKeyDefaultProfileRef: *config.NewValue("abcd"),
 					},
@@ -343,7 +351,7 @@ func TestGetDefaultProfileKey(t *testing.T) {
 			name: "error if default user reference exceeds max length",
 			expect: func(h *MockHandler) {
 				h.EXPECT().ReadGlobalConfig(handler.GameBf2).Return(config.New(
-					"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+					filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con"),
 					map[string]config.Value{
 						GlobalConKeyDefaultProfileRef: *config.NewValue("00001"),
 					},
@@ -478,14 +486,14 @@ func TestSetDefaultProfile(t *testing.T) {
 		{
 			name: "sets default profile in Global.con",
 			givenGlobalCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GlobalSettings.setNamePrefix": *config.NewQuotedValue("=DOG="),
 				},
 			),
 			givenProfileKey: "0001",
 			wantGlobalCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					GlobalConKeyDefaultProfileRef:  *config.NewQuotedValue("0001"),
 					"GlobalSettings.setNamePrefix": *config.NewQuotedValue("=DOG="),
@@ -495,7 +503,7 @@ func TestSetDefaultProfile(t *testing.T) {
 		{
 			name: "overwrites existing default profile in Global.con",
 			givenGlobalCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					GlobalConKeyDefaultProfileRef:  *config.NewQuotedValue("0001"),
 					"GlobalSettings.setNamePrefix": *config.NewQuotedValue("=DOG="),
@@ -503,7 +511,7 @@ func TestSetDefaultProfile(t *testing.T) {
 			),
 			givenProfileKey: "0002",
 			wantGlobalCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					GlobalConKeyDefaultProfileRef:  *config.NewQuotedValue("0002"),
 					"GlobalSettings.setNamePrefix": *config.NewQuotedValue("=DOG="),
@@ -572,7 +580,7 @@ func TestGetEncryptedLogin(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
 			bytes := []byte(fmt.Sprintf("%s \"mister249\"\r\n%s \"some-encrypted-password\"\r\n", ProfileConKeyGamespyNick, ProfileConKeyPassword))
-			profileCon := config.FromBytes("C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\Profile.con", bytes)
+			profileCon := config.FromBytes(filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/Profile.con"), bytes)
 			tt.prepareProfileConMap(profileCon)
 
 			// WHEN
@@ -605,14 +613,14 @@ func TestPurgeServerHistory(t *testing.T) {
 		{
 			name: "removes single server history from General.con",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					"GeneralSettings.addServerHistory":   *config.NewValue("\"135.125.56.26\" 29940 \"=DOG= No Explosives (Infantry)\" 1025"),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
@@ -621,14 +629,14 @@ func TestPurgeServerHistory(t *testing.T) {
 		{
 			name: "removes multiple server history items from General.con",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					"GeneralSettings.addServerHistory":   *config.NewValue("\"135.125.56.26\" 29940 \"=DOG= No Explosives (Infantry)\" 1025;\"37.230.210.130\" 29900 \"PlayBF2! T~GAMER #1 Allmaps\" 360"),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
@@ -637,13 +645,13 @@ func TestPurgeServerHistory(t *testing.T) {
 		{
 			name: "does nothing if General.con does not contain any server history items",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
@@ -676,14 +684,14 @@ func TestPurgeServerFavorites(t *testing.T) {
 		{
 			name: "removes single favorite server from General.con",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					"GeneralSettings.addFavouriteServer": *config.NewValue("\"135.125.56.26\" 29940 \"=DOG= No Explosives (Infantry)\""),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
@@ -692,14 +700,14 @@ func TestPurgeServerFavorites(t *testing.T) {
 		{
 			name: "removes multiple favorite server items from General.con",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					"GeneralSettings.addFavouriteServer": *config.NewValue("\"135.125.56.26\" 29940 \"=DOG= No Explosives (Infantry)\";\"37.230.210.130\" 29900 \"PlayBF2! T~GAMER #1 Allmaps\""),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
@@ -708,13 +716,13 @@ func TestPurgeServerFavorites(t *testing.T) {
 		{
 			name: "does nothing if General.con does not contain any server history items",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
@@ -750,7 +758,7 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 		{
 			name: "only removes bookmarks older than max age",
 			givenDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						buildDemoBookmark("some-server", "strike_at_karkand", reference.Add(-time.Hour*24*7-1)),
@@ -762,7 +770,7 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 			givenReference: reference,
 			givenMaxAge:    time.Hour * 24 * 7,
 			expectedDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						buildDemoBookmark("some-other-server", "dragon_valley", reference.Add(-time.Hour*24*7+1)),
@@ -774,7 +782,7 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 		{
 			name: "deletes bookmarks key if no entries are kept",
 			givenDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						buildDemoBookmark("some-server", "strike_at_karkand", reference.Add(-time.Hour*24*7-1)),
@@ -784,14 +792,14 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 			givenReference: reference,
 			givenMaxAge:    time.Hour * 24 * 7,
 			expectedDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{},
 			),
 		},
 		{
 			name: "handles unquoted single-word values",
 			givenDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						fmt.Sprintf("first-server \"strike_at_karkand\" \"http://unused/first-server/strike_at_karkand/some-demo.bf2demo\" \"%s\"", formatDemoBookmarkTimestamp(reference)),
@@ -803,7 +811,7 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 			givenReference: reference,
 			givenMaxAge:    time.Hour * 24 * 7,
 			expectedDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						fmt.Sprintf("first-server \"strike_at_karkand\" \"http://unused/first-server/strike_at_karkand/some-demo.bf2demo\" \"%s\"", formatDemoBookmarkTimestamp(reference)),
@@ -816,7 +824,7 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 		{
 			name: "removes bookmarks with invalid number of elements",
 			givenDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						fmt.Sprintf("\"strike_at_karkand\" \"http://unused/some-server/strike_at_karkand/some-demo.bf2demo\" \"%s\"", formatDemoBookmarkTimestamp(reference)),
@@ -827,14 +835,14 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 			givenReference: reference,
 			givenMaxAge:    time.Hour * 24 * 7,
 			expectedDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{},
 			),
 		},
 		{
 			name: "removes bookmarks with invalid timestamps",
 			givenDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					DemoBookmarksConKeyDemoBookmark: *config.NewValueFromSlice([]string{
 						"\"some-server\" \"strike_at_karkand\" \"http://unused/some-server/strike_at_karkand/some-demo.bf2demo\" \"not-a-valid-bookmark-timestamp\"",
@@ -844,14 +852,14 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 			givenReference: reference,
 			givenMaxAge:    time.Hour * 24 * 7,
 			expectedDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{},
 			),
 		},
 		{
 			name: "does nothing if bookmark key is missing",
 			givenDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"some-other-key": *config.NewValue("some-value"),
 				},
@@ -859,7 +867,7 @@ func TestPurgeOldDemoBookmarks(t *testing.T) {
 			givenReference: reference,
 			givenMaxAge:    time.Hour * 24 * 7,
 			expectedDemoBookmarksCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"some-other-key": *config.NewValue("some-value"),
 				},
@@ -897,13 +905,13 @@ func TestMarkAllVoiceOverHelpAsPlayed(t *testing.T) {
 		{
 			name: "marks all voice over help lines as played in General.con",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					GeneralConKeyVoiceOverHelpPlayed:     *config.NewValue(strings.Join(quoted, ";")),
@@ -913,14 +921,14 @@ func TestMarkAllVoiceOverHelpAsPlayed(t *testing.T) {
 		{
 			name: "overwrites existing voice over help lines which are marked as played in General.con",
 			givenGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					GeneralConKeyVoiceOverHelpPlayed:     *config.NewValue("GeneralSettings.setPlayedVOHelp \"HUD_HELP_COMMANDER_commanderApply\";GeneralSettings.setPlayedVOHelp \"HUD_HELP_KIT_SUPPORT_inVehicle\""),
 				},
 			),
 			expectedGeneralCon: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\0001\\General.con",
+				filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/0001/General.con"),
 				map[string]config.Value{
 					"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
 					GeneralConKeyVoiceOverHelpPlayed:     *config.NewValue(strings.Join(quoted, ";")),
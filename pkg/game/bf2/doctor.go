@@ -0,0 +1,348 @@
+package bf2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	globalConFileName = "Global.con"
+)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+type ProblemKind string
+
+const (
+	// Profile folder without a Profile.con, which the game (and GetProfiles) ignores
+	ProblemKindMissingProfileCon ProblemKind = "missing-profile-con"
+	// Profile.con without a profile name, which makes GetProfiles fail
+	ProblemKindMissingProfileName ProblemKind = "missing-profile-name"
+	// Profile folder whose name is not a number with up to four digits
+	ProblemKindInvalidProfileKey ProblemKind = "invalid-profile-key"
+	// Config file without any content
+	ProblemKindEmptyFile ProblemKind = "empty-file"
+	// Global.con does not exist
+	ProblemKindMissingGlobalCon ProblemKind = "missing-global-con"
+	// Global.con does not reference a valid profile key as the default profile
+	ProblemKindInvalidDefaultProfile ProblemKind = "invalid-default-profile"
+	// Global.con references a profile which does not exist (anymore)
+	ProblemKindMissingDefaultProfile ProblemKind = "missing-default-profile"
+)
+
+// DoctorHandler Handler used to diagnose and repair the profile tree
+type DoctorHandler interface {
+	game.DirHandler
+	Begin() *handler.Transaction
+	LockDir(dirPath string) (*handler.Lock, error)
+}
+
+// Problem Issue found in the Battlefield 2 profile tree
+type Problem struct {
+	Kind       ProblemKind
+	Severity   Severity
+	Path       string
+	ProfileKey string
+	Message    string
+	// Whether Repair can fix the problem without losing any data
+	Fixable bool
+	// Profile to use as the default profile when repairing Global.con
+	defaultProfileKey string
+}
+
+// Check the Battlefield 2 profile tree for problems, sorted by path
+func Diagnose(h DoctorHandler) ([]Problem, error) {
+	profilesPath, err := h.BuildProfilesFolderPath(handler.GameBf2)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := h.ReadDir(profilesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	var profileKeys []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		profileKey := entry.Name()
+		profileProblems, valid, err := diagnoseProfile(h, filepath.Join(profilesPath, profileKey), profileKey)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, profileProblems...)
+		if !valid || profileKey == DefaultProfileKey {
+			continue
+		}
+		profileKeys = append(profileKeys, profileKey)
+	}
+
+	// Replace the default profile the same way DeleteProfile does, should it need to be replaced
+	fallbackKey, err := findFallbackProfileKey(h, "")
+	if err != nil {
+		return nil, err
+	}
+
+	globalConProblem, err := diagnoseGlobalCon(h, filepath.Join(profilesPath, globalConFileName), profileKeys, fallbackKey)
+	if err != nil {
+		return nil, err
+	}
+	if globalConProblem != nil {
+		problems = append(problems, *globalConProblem)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Path < problems[j].Path
+	})
+
+	return problems, nil
+}
+
+// Fix all fixable problems in a single transaction, returning the problems which were fixed (along with
+// handler.ErrCleanupFailed if removed files could not be deleted afterwards)
+func Repair(h DoctorHandler, problems []Problem) ([]Problem, error) {
+	profilesPath, err := h.BuildProfilesFolderPath(handler.GameBf2)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := h.LockDir(profilesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = lock.Release()
+	}()
+
+	// Empty files are removed from profile folders, which need to be locked in addition to the profiles folder
+	profileLocks := map[string]*handler.Lock{}
+	defer func() {
+		for _, profileLock := range profileLocks {
+			_ = profileLock.Release()
+		}
+	}()
+
+	tx := h.Begin()
+
+	var fixed []Problem
+	for _, problem := range problems {
+		if !problem.Fixable {
+			continue
+		}
+
+		switch problem.Kind {
+		case ProblemKindEmptyFile:
+			profilePath := filepath.Dir(problem.Path)
+			if _, ok := profileLocks[profilePath]; !ok {
+				profileLock, err := h.LockDir(profilePath)
+				if err != nil {
+					return nil, err
+				}
+				profileLocks[profilePath] = profileLock
+			}
+
+			// Problem may be outdated, so make sure the file is still empty before removing it
+			data, err := tx.ReadFile(problem.Path)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			if len(data) > 0 {
+				continue
+			}
+
+			// The game recreates missing config files with default values, so removing an empty one loses nothing
+			if err := tx.Remove(problem.Path); err != nil {
+				return nil, err
+			}
+		case ProblemKindMissingGlobalCon, ProblemKindInvalidDefaultProfile, ProblemKindMissingDefaultProfile:
+			globalCon, err := tx.ReadConfigFile(problem.Path)
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+				globalCon = config.FromBytes(problem.Path, nil)
+			}
+			SetDefaultProfile(globalCon, problem.defaultProfileKey)
+			if err = tx.WriteConfigFile(globalCon); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		fixed = append(fixed, problem)
+	}
+
+	if len(fixed) == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	// Problems are fixed even if removed files cannot be cleaned up afterwards
+	err = tx.Commit()
+	var cleanupErr *handler.ErrCleanupFailed
+	if err != nil && !errors.As(err, &cleanupErr) {
+		return nil, err
+	}
+
+	return fixed, err
+}
+
+// diagnoseProfile Check a single profile folder, also returning whether the game considers it a valid profile
+func diagnoseProfile(h DoctorHandler, profilePath string, profileKey string) ([]Problem, bool, error) {
+	var problems []Problem
+	if profileKey != DefaultProfileKey && !refractorv2.IsWellFormedProfileKey(profileKey) {
+		problems = append(problems, Problem{
+			Kind:       ProblemKindInvalidProfileKey,
+			Severity:   SeverityWarning,
+			Path:       profilePath,
+			ProfileKey: profileKey,
+			Message:    fmt.Sprintf("profile key is not a number with up to four digits: %s", profileKey),
+		})
+	}
+
+	entries, err := h.ReadDir(profilePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var hasProfileCon, profileConEmpty bool
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".con") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, false, err
+		}
+
+		isProfileCon := strings.EqualFold(entry.Name(), string(ProfileConfigFileProfileCon))
+		hasProfileCon = hasProfileCon || isProfileCon
+		if info.Size() > 0 {
+			continue
+		}
+
+		problem := Problem{
+			Kind:       ProblemKindEmptyFile,
+			Severity:   SeverityWarning,
+			Path:       filepath.Join(profilePath, entry.Name()),
+			ProfileKey: profileKey,
+			Message:    fmt.Sprintf("config file is empty: %s", entry.Name()),
+			Fixable:    true,
+		}
+		// Without a Profile.con, the profile itself is lost, so this cannot simply be removed
+		if isProfileCon {
+			profileConEmpty = true
+			problem.Severity = SeverityError
+			problem.Fixable = false
+		}
+		problems = append(problems, problem)
+	}
+
+	// Default profile only contains default settings, but no Profile.con
+	if profileKey == DefaultProfileKey {
+		return problems, false, nil
+	}
+
+	if !hasProfileCon {
+		problems = append(problems, Problem{
+			Kind:       ProblemKindMissingProfileCon,
+			Severity:   SeverityWarning,
+			Path:       profilePath,
+			ProfileKey: profileKey,
+			Message:    fmt.Sprintf("profile folder does not contain %s and is ignored", ProfileConfigFileProfileCon),
+		})
+		return problems, false, nil
+	}
+
+	if profileConEmpty {
+		return problems, true, nil
+	}
+
+	profileCon, err := h.ReadConfigFile(filepath.Join(profilePath, string(ProfileConfigFileProfileCon)))
+	if err != nil {
+		return nil, false, err
+	}
+	if !profileCon.HasKey(ProfileConKeyName) {
+		problems = append(problems, Problem{
+			Kind:       ProblemKindMissingProfileName,
+			Severity:   SeverityError,
+			Path:       profileCon.Path,
+			ProfileKey: profileKey,
+			Message:    fmt.Sprintf("%s does not contain a profile name", ProfileConfigFileProfileCon),
+		})
+	}
+
+	return problems, true, nil
+}
+
+// diagnoseGlobalCon Check whether Global.con references an existing profile as the default profile
+func diagnoseGlobalCon(h DoctorHandler, globalConPath string, profileKeys []string, fallbackKey string) (*Problem, error) {
+	problem := Problem{
+		Path:              globalConPath,
+		Severity:          SeverityError,
+		Fixable:           fallbackKey != "",
+		defaultProfileKey: fallbackKey,
+	}
+
+	globalCon, err := h.ReadConfigFile(globalConPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		problem.Kind = ProblemKindMissingGlobalCon
+		problem.Message = fmt.Sprintf("%s does not exist", globalConFileName)
+		return &problem, nil
+	}
+
+	ref, err := globalCon.GetValue(GlobalConKeyDefaultProfileRef)
+	if err != nil || !refractorv2.IsWellFormedProfileKey(ref.String()) {
+		problem.Kind = ProblemKindInvalidDefaultProfile
+		problem.Message = fmt.Sprintf("%s does not reference a valid profile key as the default profile", globalConFileName)
+		return &problem, nil
+	}
+
+	for _, profileKey := range profileKeys {
+		if profileKey == ref.String() {
+			return nil, nil
+		}
+	}
+
+	problem.Kind = ProblemKindMissingDefaultProfile
+	problem.ProfileKey = ref.String()
+	problem.Message = fmt.Sprintf("default profile referenced in %s does not exist: %s", globalConFileName, ref.String())
+	return &problem, nil
+}
@@ -0,0 +1,321 @@
+//go:build unit
+
+package bf2
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/handler"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestDiagnose(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+	globalConPath := filepath.Join(profilesPath, "Global.con")
+
+	type test struct {
+		name            string
+		givenFiles      fstest.MapFS
+		wantProblems    []Problem
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "reports no problems for healthy profile tree",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":         {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/Default/Video.con":  {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+				"Profiles/0001/Profile.con":   {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0001/General.con":   {Data: []byte("GeneralSettings.setHUDTransparency 100\r\n")},
+				"Profiles/0001/Demos/a.bf2cl": {Data: []byte{}},
+			},
+		},
+		{
+			name: "reports profile folder without Profile.con",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0002/General.con": {Data: []byte("GeneralSettings.setHUDTransparency 100\r\n")},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:       ProblemKindMissingProfileCon,
+					Severity:   SeverityWarning,
+					Path:       filepath.Join(profilesPath, "0002"),
+					ProfileKey: "0002",
+					Message:    "profile folder does not contain Profile.con and is ignored",
+				},
+			},
+		},
+		{
+			name: "reports empty files",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0001/General.con": {Data: []byte{}},
+				"Profiles/0002/Profile.con": {Data: []byte{}},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:       ProblemKindEmptyFile,
+					Severity:   SeverityWarning,
+					Path:       filepath.Join(profilesPath, "0001", "General.con"),
+					ProfileKey: "0001",
+					Message:    "config file is empty: General.con",
+					Fixable:    true,
+				},
+				{
+					Kind:       ProblemKindEmptyFile,
+					Severity:   SeverityError,
+					Path:       filepath.Join(profilesPath, "0002", "Profile.con"),
+					ProfileKey: "0002",
+					Message:    "config file is empty: Profile.con",
+				},
+			},
+		},
+		{
+			name: "reports profile without name and invalid profile key",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":        {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con":  {Data: []byte("LocalProfile.setNick \"mister249\"\r\n")},
+				"Profiles/00001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:       ProblemKindInvalidProfileKey,
+					Severity:   SeverityWarning,
+					Path:       filepath.Join(profilesPath, "00001"),
+					ProfileKey: "00001",
+					Message:    "profile key is not a number with up to four digits: 00001",
+				},
+				{
+					Kind:       ProblemKindMissingProfileName,
+					Severity:   SeverityError,
+					Path:       filepath.Join(profilesPath, "0001", "Profile.con"),
+					ProfileKey: "0001",
+					Message:    "Profile.con does not contain a profile name",
+				},
+			},
+		},
+		{
+			name: "reports default profile which does not exist",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0003\"\r\n")},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte{}},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:       ProblemKindEmptyFile,
+					Severity:   SeverityError,
+					Path:       filepath.Join(profilesPath, "0001", "Profile.con"),
+					ProfileKey: "0001",
+					Message:    "config file is empty: Profile.con",
+				},
+				{
+					Kind:              ProblemKindMissingDefaultProfile,
+					Severity:          SeverityError,
+					Path:              globalConPath,
+					ProfileKey:        "0003",
+					Message:           "default profile referenced in Global.con does not exist: 0003",
+					Fixable:           true,
+					defaultProfileKey: "0002",
+				},
+			},
+		},
+		{
+			name: "falls back to most recently modified profile like DeleteProfile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0003\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"first\"\r\n"), ModTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"second\"\r\n"), ModTime: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:              ProblemKindMissingDefaultProfile,
+					Severity:          SeverityError,
+					Path:              globalConPath,
+					ProfileKey:        "0003",
+					Message:           "default profile referenced in Global.con does not exist: 0003",
+					Fixable:           true,
+					defaultProfileKey: "0002",
+				},
+			},
+		},
+		{
+			name: "reports invalid default profile reference",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"Default\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:              ProblemKindInvalidDefaultProfile,
+					Severity:          SeverityError,
+					Path:              globalConPath,
+					Message:           "Global.con does not reference a valid profile key as the default profile",
+					Fixable:           true,
+					defaultProfileKey: "0001",
+				},
+			},
+		},
+		{
+			name: "reports missing Global.con which cannot be fixed without any profiles",
+			givenFiles: fstest.MapFS{
+				"Profiles/Default/Video.con": {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+			},
+			wantProblems: []Problem{
+				{
+					Kind:     ProblemKindMissingGlobalCon,
+					Severity: SeverityError,
+					Path:     globalConPath,
+					Message:  "Global.con does not exist",
+				},
+			},
+		},
+		{
+			name:            "error if profiles folder does not exist",
+			givenFiles:      fstest.MapFS{},
+			wantErrContains: "file does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			problems, err := Diagnose(h)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantProblems, problems)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+
+	type test struct {
+		name          string
+		givenFiles    fstest.MapFS
+		wantFixed     []ProblemKind
+		wantGlobalCon string
+		wantRemoved   []string
+	}
+
+	tests := []test{
+		{
+			name: "replaces default profile which does not exist and removes empty files",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0003\"\r\n")},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0002/General.con": {Data: []byte{}},
+			},
+			wantFixed:     []ProblemKind{ProblemKindEmptyFile, ProblemKindMissingDefaultProfile},
+			wantGlobalCon: "GlobalSettings.setDefaultUser \"0002\"\r\n",
+			wantRemoved:   []string{filepath.Join(profilesPath, "0002", "General.con")},
+		},
+		{
+			name: "creates missing Global.con",
+			givenFiles: fstest.MapFS{
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			},
+			wantFixed:     []ProblemKind{ProblemKindMissingGlobalCon},
+			wantGlobalCon: "GlobalSettings.setDefaultUser \"0001\"\r\n",
+		},
+		{
+			name: "does not change anything without fixable problems",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte{}},
+			},
+			wantGlobalCon: "GlobalSettings.setDefaultUser \"0001\"\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+			problems, err := Diagnose(h)
+			require.NoError(t, err)
+
+			// WHEN
+			fixed, err := Repair(h, problems)
+
+			// THEN
+			require.NoError(t, err)
+			var fixedKinds []ProblemKind
+			for _, problem := range fixed {
+				fixedKinds = append(fixedKinds, problem.Kind)
+			}
+			assert.Equal(t, tt.wantFixed, fixedKinds)
+			data, err := repo.ReadFile(filepath.Join(profilesPath, "Global.con"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGlobalCon, string(data))
+			for _, path := range tt.wantRemoved {
+				exists, err := repo.FileExists(path)
+				require.NoError(t, err)
+				assert.False(t, exists)
+			}
+			for _, lockPath := range []string{filepath.Join(profilesPath, "conman.lock"), filepath.Join(profilesPath, "0002", "conman.lock")} {
+				exists, err := repo.FileExists(lockPath)
+				require.NoError(t, err)
+				assert.False(t, exists)
+			}
+
+			remaining, err := Diagnose(h)
+			require.NoError(t, err)
+			for _, problem := range remaining {
+				assert.False(t, problem.Fixable)
+			}
+		})
+	}
+}
+
+func TestRepair_OutdatedProblem(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	generalConPath := filepath.Join(basePath, "Profiles", "0001", "General.con")
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+		"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+		"Profiles/0001/General.con": {Data: []byte{}},
+	}, basePath))
+	h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+	problems, err := Diagnose(h)
+	require.NoError(t, err)
+	// File is written to (e.g. by the game) after the problems were found
+	require.NoError(t, repo.WriteFile(generalConPath, []byte("GeneralSettings.setPlayedVOHelp \"HUD_HELP_KIT_SUPPORT\"\r\n"), 0666))
+
+	// WHEN
+	fixed, err := Repair(h, problems)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, fixed)
+	exists, err := repo.FileExists(generalConPath)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
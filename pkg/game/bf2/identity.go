@@ -0,0 +1,181 @@
+package bf2
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+)
+
+const (
+	// Limits of the in-game profile dialog (GameSpy's nick and email buffers hold 31 and 51 bytes including the
+	// terminating NUL character)
+	profileNameMaxLength = 30
+	nickMaxLength        = 30
+	gamespyNickMinLength = 3
+	emailMaxLength       = 50
+)
+
+type ErrInvalidProfileValue struct {
+	key    string
+	reason string
+}
+
+func (e *ErrInvalidProfileValue) Error() string {
+	return fmt.Sprintf("invalid value for %s: %s", e.key, e.reason)
+}
+
+// Identity Identity fields stored in a Battlefield 2 Profile.con (GameSpy nick and email are empty for singleplayer profiles)
+type Identity struct {
+	Name        string
+	Nick        string
+	GamespyNick string
+	Email       string
+}
+
+// Read the identity fields from the given Battlefield 2 profile's Profile.con
+func ReadProfileIdentity(h game.Handler, profileKey string) (Identity, error) {
+	profileCon, err := ReadProfileConfigFile(h, profileKey, ProfileConfigFileProfileCon)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return GetProfileIdentity(profileCon), nil
+}
+
+// Extract the identity fields from a parsed Battlefield 2 Profile.con file (missing fields are left empty)
+func GetProfileIdentity(profileCon *config.Config) Identity {
+	return Identity{
+		Name:        getStringValue(profileCon, ProfileConKeyName),
+		Nick:        getStringValue(profileCon, ProfileConKeyNick),
+		GamespyNick: getStringValue(profileCon, ProfileConKeyGamespyNick),
+		Email:       getStringValue(profileCon, ProfileConKeyEmail),
+	}
+}
+
+// Set the profile name (as shown in the profile list) in the given Profile.con
+func SetProfileName(profileCon *config.Config, name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+
+	profileCon.SetValue(ProfileConKeyName, *config.NewQuotedValue(name))
+	return nil
+}
+
+// Set the nick (as shown in game) in the given Profile.con
+func SetProfileNick(profileCon *config.Config, nick string) error {
+	if err := validateNick(nick); err != nil {
+		return err
+	}
+
+	profileCon.SetValue(ProfileConKeyNick, *config.NewQuotedValue(nick))
+	return nil
+}
+
+// Set the GameSpy account's nick in the given Profile.con [multiplayer profiles only]
+func SetProfileGamespyNick(profileCon *config.Config, gamespyNick string) error {
+	if err := validateGamespyNick(gamespyNick); err != nil {
+		return err
+	}
+	if err := requireMultiplayerProfile(profileCon, ProfileConKeyGamespyNick); err != nil {
+		return err
+	}
+
+	profileCon.SetValue(ProfileConKeyGamespyNick, *config.NewQuotedValue(gamespyNick))
+	return nil
+}
+
+// Set the GameSpy account's email address in the given Profile.con [multiplayer profiles only]
+func SetProfileEmail(profileCon *config.Config, email string) error {
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+	if err := requireMultiplayerProfile(profileCon, ProfileConKeyEmail); err != nil {
+		return err
+	}
+
+	profileCon.SetValue(ProfileConKeyEmail, *config.NewQuotedValue(email))
+	return nil
+}
+
+// getStringValue Get the given key's value, or an empty string if the key does not exist
+func getStringValue(c *config.Config, key string) string {
+	value, err := c.GetValue(key)
+	if err != nil {
+		return ""
+	}
+	return value.String()
+}
+
+// requireMultiplayerProfile Ensure the given Profile.con belongs to a multiplayer profile, since adding GameSpy
+// account details to a singleplayer profile would (silently) turn it into a multiplayer profile
+func requireMultiplayerProfile(profileCon *config.Config, key string) error {
+	if !profileCon.HasKey(ProfileConKeyEmail) {
+		return &ErrInvalidProfileValue{key: key, reason: "singleplayer profiles do not have a GameSpy account"}
+	}
+	return nil
+}
+
+func validateProfileName(name string) error {
+	if err := validateProfileValue(ProfileConKeyName, name, 1, profileNameMaxLength); err != nil {
+		return err
+	}
+	if strings.TrimSpace(name) != name {
+		return &ErrInvalidProfileValue{key: ProfileConKeyName, reason: "must not start or end with a space"}
+	}
+	return nil
+}
+
+func validateNick(nick string) error {
+	if err := validateProfileValue(ProfileConKeyNick, nick, 1, nickMaxLength); err != nil {
+		return err
+	}
+	if strings.TrimSpace(nick) != nick {
+		return &ErrInvalidProfileValue{key: ProfileConKeyNick, reason: "must not start or end with a space"}
+	}
+	return nil
+}
+
+func validateGamespyNick(gamespyNick string) error {
+	if err := validateProfileValue(ProfileConKeyGamespyNick, gamespyNick, gamespyNickMinLength, nickMaxLength); err != nil {
+		return err
+	}
+	if strings.Contains(gamespyNick, " ") {
+		return &ErrInvalidProfileValue{key: ProfileConKeyGamespyNick, reason: "must not contain spaces"}
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	if err := validateProfileValue(ProfileConKeyEmail, email, 1, emailMaxLength); err != nil {
+		return err
+	}
+	// Only accept plain addresses, not ones with a display name ("name <address>")
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return &ErrInvalidProfileValue{key: ProfileConKeyEmail, reason: "must be a valid email address"}
+	}
+	return nil
+}
+
+// validateProfileValue Check the length and characters of a value to be written to Profile.con
+func validateProfileValue(key string, value string, minLength int, maxLength int) error {
+	if len(value) < minLength || len(value) > maxLength {
+		if minLength == 1 {
+			return &ErrInvalidProfileValue{key: key, reason: fmt.Sprintf("must be between 1 and %d characters long", maxLength)}
+		}
+		return &ErrInvalidProfileValue{key: key, reason: fmt.Sprintf("must be between %d and %d characters long", minLength, maxLength)}
+	}
+
+	for _, r := range value {
+		// The game reads config files as ANSI text and values are written as quoted strings,
+		// so only printable ASCII characters other than quotes can be stored safely
+		if r < 0x20 || r > 0x7e || r == '"' {
+			return &ErrInvalidProfileValue{key: key, reason: fmt.Sprintf("must only contain printable ASCII characters other than quotes, got %q", r)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+//go:build unit
+
+package bf2
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/handler"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+const (
+	multiplayerProfileCon  = "LocalProfile.setName \"mister249\"\r\nLocalProfile.setNick \"mister249\"\r\nLocalProfile.setGamespyNick \"mister249\"\r\nLocalProfile.setEmail \"mister249@example.com\"\r\n"
+	singleplayerProfileCon = "LocalProfile.setName \"offline\"\r\nLocalProfile.setNick \"offline\"\r\n"
+)
+
+func TestReadProfileIdentity(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+
+	type test struct {
+		name            string
+		givenProfileKey string
+		wantIdentity    Identity
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:            "reads multiplayer profile identity",
+			givenProfileKey: "0001",
+			wantIdentity: Identity{
+				Name:        "mister249",
+				Nick:        "mister249",
+				GamespyNick: "mister249",
+				Email:       "mister249@example.com",
+			},
+		},
+		{
+			name:            "reads singleplayer profile identity",
+			givenProfileKey: "0002",
+			wantIdentity: Identity{
+				Name: "offline",
+				Nick: "offline",
+			},
+		},
+		{
+			name:            "error if profile does not exist",
+			givenProfileKey: "0003",
+			wantErrContains: "file does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"Profiles/0001/Profile.con": {Data: []byte(multiplayerProfileCon)},
+				"Profiles/0002/Profile.con": {Data: []byte(singleplayerProfileCon)},
+			}, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			identity, err := ReadProfileIdentity(h, tt.givenProfileKey)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantIdentity, identity)
+			}
+		})
+	}
+}
+
+func TestSetProfileIdentityFields(t *testing.T) {
+	type test struct {
+		name            string
+		givenProfileCon string
+		set             func(profileCon *config.Config) error
+		wantIdentity    Identity
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:            "sets name",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileName(profileCon, "mister249 (clan war)")
+			},
+			wantIdentity: Identity{Name: "mister249 (clan war)", Nick: "mister249", GamespyNick: "mister249", Email: "mister249@example.com"},
+		},
+		{
+			name:            "sets nick",
+			givenProfileCon: singleplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileNick(profileCon, "=CW= offline")
+			},
+			wantIdentity: Identity{Name: "offline", Nick: "=CW= offline"},
+		},
+		{
+			name:            "sets GameSpy nick",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileGamespyNick(profileCon, "mister250")
+			},
+			wantIdentity: Identity{Name: "mister249", Nick: "mister249", GamespyNick: "mister250", Email: "mister249@example.com"},
+		},
+		{
+			name:            "sets email",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileEmail(profileCon, "mister250@example.com")
+			},
+			wantIdentity: Identity{Name: "mister249", Nick: "mister249", GamespyNick: "mister249", Email: "mister250@example.com"},
+		},
+		{
+			name:            "error for empty name",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileName(profileCon, "")
+			},
+			wantErrContains: "invalid value for LocalProfile.setName: must be between 1 and 30 characters long",
+		},
+		{
+			name:            "error for name with leading space",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileName(profileCon, " mister249")
+			},
+			wantErrContains: "invalid value for LocalProfile.setName: must not start or end with a space",
+		},
+		{
+			name:            "error for too long nick",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileNick(profileCon, strings.Repeat("a", 31))
+			},
+			wantErrContains: "invalid value for LocalProfile.setNick: must be between 1 and 30 characters long",
+		},
+		{
+			name:            "error for nick with non-ASCII characters",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileNick(profileCon, "mistér249")
+			},
+			wantErrContains: "invalid value for LocalProfile.setNick: must only contain printable ASCII characters other than quotes, got 'é'",
+		},
+		{
+			name:            "error for too short GameSpy nick",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileGamespyNick(profileCon, "ab")
+			},
+			wantErrContains: "invalid value for LocalProfile.setGamespyNick: must be between 3 and 30 characters long",
+		},
+		{
+			name:            "error for GameSpy nick with spaces",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileGamespyNick(profileCon, "mister 249")
+			},
+			wantErrContains: "invalid value for LocalProfile.setGamespyNick: must not contain spaces",
+		},
+		{
+			name:            "error for GameSpy nick of singleplayer profile",
+			givenProfileCon: singleplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileGamespyNick(profileCon, "mister249")
+			},
+			wantErrContains: "invalid value for LocalProfile.setGamespyNick: singleplayer profiles do not have a GameSpy account",
+		},
+		{
+			name:            "error for invalid email",
+			givenProfileCon: multiplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileEmail(profileCon, "mister249 <mister249@example.com>")
+			},
+			wantErrContains: "invalid value for LocalProfile.setEmail: must be a valid email address",
+		},
+		{
+			name:            "error for email of singleplayer profile",
+			givenProfileCon: singleplayerProfileCon,
+			set: func(profileCon *config.Config) error {
+				return SetProfileEmail(profileCon, "offline@example.com")
+			},
+			wantErrContains: "invalid value for LocalProfile.setEmail: singleplayer profiles do not have a GameSpy account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			profileCon := config.FromBytes("Profile.con", []byte(tt.givenProfileCon))
+
+			// WHEN
+			err := tt.set(profileCon)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				var invalidErr *ErrInvalidProfileValue
+				require.ErrorAs(t, err, &invalidErr)
+				require.ErrorContains(t, err, tt.wantErrContains)
+				// Profile.con should not have been changed
+				assert.Equal(t, config.FromBytes("Profile.con", []byte(tt.givenProfileCon)).ToBytes(), profileCon.ToBytes())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantIdentity, GetProfileIdentity(profileCon))
+			}
+		})
+	}
+}
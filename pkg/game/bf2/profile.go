@@ -0,0 +1,736 @@
+package bf2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	profileKeyFormat = "%04d"
+	maxProfileKey    = 9999
+
+	recycledProfileTimeFormat = "20060102-150405"
+)
+
+var (
+	// profileConfigFiles All config files stored in a profile folder
+	profileConfigFiles = []ProfileConfigFile{
+		ProfileConfigFileAudioCon,
+		ProfileConfigFileControlsCon,
+		ProfileConfigFileDemoBookmarksCon,
+		ProfileConfigFileGeneralCon,
+		ProfileConfigFileHapticCon,
+		ProfileConfigFileMapListCon,
+		ProfileConfigFileProfileCon,
+		ProfileConfigFileServerSettingsCon,
+		ProfileConfigFileVideoCon,
+	}
+	// defaultProfileConfigFiles Config files the game ships in the Default profile, which new profiles are seeded from
+	// (all other config files are only created once the corresponding feature is first used)
+	defaultProfileConfigFiles = []ProfileConfigFile{
+		ProfileConfigFileAudioCon,
+		ProfileConfigFileControlsCon,
+		ProfileConfigFileGeneralCon,
+		ProfileConfigFileHapticCon,
+		ProfileConfigFileVideoCon,
+	}
+)
+
+// ErrIncompleteDefaultProfile Returned if the Default profile is missing config files new profiles are seeded from
+type ErrIncompleteDefaultProfile struct {
+	missing []string
+}
+
+func (e *ErrIncompleteDefaultProfile) Error() string {
+	return fmt.Sprintf("default profile is missing config files: %s", strings.Join(e.missing, ", "))
+}
+
+// ErrProfileNotFound Returned if a profile key does not refer to a profile folder containing a Profile.con
+type ErrProfileNotFound struct {
+	profileKey string
+}
+
+func (e *ErrProfileNotFound) Error() string {
+	return fmt.Sprintf("profile not found: %s", e.profileKey)
+}
+
+// ProfileHandler Handler used to create and modify entire profiles
+type ProfileHandler interface {
+	game.DirHandler
+	Begin() *handler.Transaction
+	LockDir(dirPath string) (*handler.Lock, error)
+}
+
+type profileOptions struct {
+	nick        string
+	email       string
+	makeDefault bool
+}
+
+type ProfileOption func(o *profileOptions)
+
+// Use the given nick instead of the profile's name
+func WithNick(nick string) ProfileOption {
+	return func(o *profileOptions) {
+		o.nick = nick
+	}
+}
+
+// Store the given email address in the profile [multiplayer profiles only]
+func WithEmail(email string) ProfileOption {
+	return func(o *profileOptions) {
+		o.email = email
+	}
+}
+
+// Make the profile the default profile in Global.con
+func AsDefault() ProfileOption {
+	return func(o *profileOptions) {
+		o.makeDefault = true
+	}
+}
+
+type deleteOptions struct {
+	recyclePath string
+}
+
+type DeleteOption func(o *deleteOptions)
+
+// Move the profile folder into the given folder (as <key>-<timestamp>) instead of removing it
+func DeleteToRecycleFolder(dirPath string) DeleteOption {
+	return func(o *deleteOptions) {
+		o.recyclePath = dirPath
+	}
+}
+
+// Create a new profile using the lowest free profile key, seeding its config files from the Default profile
+// (returns ErrIncompleteDefaultProfile if the Default profile does not contain all config files the game ships in it)
+func CreateProfile(h ProfileHandler, name string, profileType game.ProfileType, options ...ProfileOption) (game.Profile, error) {
+	opts := newProfileOptions(options)
+	if opts.nick == "" {
+		opts.nick = name
+	}
+
+	if err := validateProfileValues(name, opts); err != nil {
+		return game.Profile{}, err
+	}
+	if profileType == game.ProfileTypeMultiplayer {
+		if opts.email == "" {
+			return game.Profile{}, &ErrInvalidProfileValue{key: ProfileConKeyEmail, reason: "required for multiplayer profiles"}
+		}
+		// Nick is also used as the GameSpy nick
+		if err := validateGamespyNick(opts.nick); err != nil {
+			return game.Profile{}, err
+		}
+	} else if opts.email != "" {
+		return game.Profile{}, &ErrInvalidProfileValue{key: ProfileConKeyEmail, reason: "singleplayer profiles do not have a GameSpy account"}
+	}
+
+	profilesPath, err := h.BuildProfilesFolderPath(handler.GameBf2)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	profileKey, release, err := reserveProfileKey(h, profilesPath)
+	if err != nil {
+		return game.Profile{}, err
+	}
+	defer release()
+
+	profilePath := filepath.Join(profilesPath, profileKey)
+	tx := h.Begin()
+
+	profile := game.Profile{
+		Key:  profileKey,
+		Name: name,
+		Type: profileType,
+		Nick: opts.nick,
+	}
+
+	profileCon := config.FromBytes(filepath.Join(profilePath, string(ProfileConfigFileProfileCon)), nil)
+	profileCon.SetValue(ProfileConKeyName, *config.NewQuotedValue(name))
+	profileCon.SetValue(ProfileConKeyNick, *config.NewQuotedValue(opts.nick))
+	if profileType == game.ProfileTypeMultiplayer {
+		profileCon.SetValue(ProfileConKeyGamespyNick, *config.NewQuotedValue(opts.nick))
+		profileCon.SetValue(ProfileConKeyEmail, *config.NewQuotedValue(opts.email))
+		profile.GamespyNick = opts.nick
+		profile.HasEmail = true
+	}
+
+	var missing []string
+	for _, configFile := range profileConfigFiles {
+		if configFile == ProfileConfigFileProfileCon {
+			if err = tx.WriteConfigFile(profileCon); err != nil {
+				return game.Profile{}, err
+			}
+			profile.ConfigFiles = append(profile.ConfigFiles, string(configFile))
+			continue
+		}
+
+		// Copy the raw content, since re-serializing would reorder lines which depend on each other (e.g. in Controls.con)
+		data, err := tx.ReadFile(filepath.Join(profilesPath, DefaultProfileKey, string(configFile)))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				if slices.Contains(defaultProfileConfigFiles, configFile) {
+					missing = append(missing, string(configFile))
+				}
+				continue
+			}
+			return game.Profile{}, err
+		}
+
+		if err = tx.WriteFile(filepath.Join(profilePath, string(configFile)), data); err != nil {
+			return game.Profile{}, err
+		}
+		profile.ConfigFiles = append(profile.ConfigFiles, string(configFile))
+	}
+
+	if len(missing) > 0 {
+		tx.Rollback()
+		return game.Profile{}, &ErrIncompleteDefaultProfile{missing: missing}
+	}
+
+	if opts.makeDefault {
+		if err = stageDefaultProfile(tx, filepath.Join(profilesPath, globalConFileName), profileKey); err != nil {
+			return game.Profile{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return game.Profile{}, err
+	}
+
+	return profile, nil
+}
+
+// Copy all config files of the given profile into a new profile using the lowest free profile key, changing only
+// the name (as well as nick and email, if given) in the copy's Profile.con
+func CloneProfile(h ProfileHandler, sourceKey string, name string, options ...ProfileOption) (game.Profile, error) {
+	opts := newProfileOptions(options)
+	if err := validateProfileValues(name, opts); err != nil {
+		return game.Profile{}, err
+	}
+
+	profilesPath, err := h.BuildProfilesFolderPath(handler.GameBf2)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	// Read (and thereby validate) the source's Profile.con before allocating a key
+	sourceProfileConPath, err := buildExistingProfileConPath(h, sourceKey)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	profileCon, err := h.ReadConfigFile(sourceProfileConPath)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	sourcePath := filepath.Dir(sourceProfileConPath)
+	entries, err := h.ReadDir(sourcePath)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	profileKey, release, err := reserveProfileKey(h, profilesPath)
+	if err != nil {
+		return game.Profile{}, err
+	}
+	defer release()
+
+	profilePath := filepath.Join(profilesPath, profileKey)
+	tx := h.Begin()
+
+	var configFiles []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".con") {
+			continue
+		}
+
+		targetPath := filepath.Join(profilePath, entry.Name())
+		if strings.EqualFold(entry.Name(), string(ProfileConfigFileProfileCon)) {
+			profileCon.Path = targetPath
+			profileCon.SetValue(ProfileConKeyName, *config.NewQuotedValue(name))
+			// GameSpy nick is kept, since the copy still uses the same GameSpy account
+			if opts.nick != "" {
+				profileCon.SetValue(ProfileConKeyNick, *config.NewQuotedValue(opts.nick))
+			}
+			if opts.email != "" {
+				profileCon.SetValue(ProfileConKeyEmail, *config.NewQuotedValue(opts.email))
+			}
+			if err = tx.WriteConfigFile(profileCon); err != nil {
+				return game.Profile{}, err
+			}
+			configFiles = append(configFiles, entry.Name())
+			continue
+		}
+
+		// Copy all other files as is, since re-serializing would reorder lines which depend on each other
+		data, err := tx.ReadFile(filepath.Join(sourcePath, entry.Name()))
+		if err != nil {
+			return game.Profile{}, err
+		}
+		if err = tx.WriteFile(targetPath, data); err != nil {
+			return game.Profile{}, err
+		}
+		configFiles = append(configFiles, entry.Name())
+	}
+
+	if opts.makeDefault {
+		if err = stageDefaultProfile(tx, filepath.Join(profilesPath, globalConFileName), profileKey); err != nil {
+			return game.Profile{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return game.Profile{}, err
+	}
+
+	return newProfile(profileKey, profileCon, configFiles), nil
+}
+
+// Copy the given config files (defaults to all config files other than Profile.con) from one existing profile
+// to another, returning the files which were copied; Profile.con cannot be copied since it holds the identity data
+func CopyProfileConfigFiles(h ProfileHandler, sourceKey string, targetKey string, configFiles ...ProfileConfigFile) ([]ProfileConfigFile, error) {
+	if sourceKey == targetKey {
+		return nil, fmt.Errorf("source and target profile must not be the same: %s", sourceKey)
+	}
+
+	// Only accept known config files (in their canonical spelling), so names cannot point outside the profile folder
+	explicit := len(configFiles) > 0
+	var selected []ProfileConfigFile
+	for _, configFile := range configFiles {
+		if strings.EqualFold(filepath.Base(filepath.Clean(string(configFile))), string(ProfileConfigFileProfileCon)) {
+			return nil, fmt.Errorf("%s cannot be copied between profiles", ProfileConfigFileProfileCon)
+		}
+		i := slices.IndexFunc(profileConfigFiles, func(known ProfileConfigFile) bool {
+			return strings.EqualFold(string(known), string(configFile))
+		})
+		if i == -1 {
+			return nil, fmt.Errorf("unknown profile config file: %s", configFile)
+		}
+		selected = append(selected, profileConfigFiles[i])
+	}
+	if !explicit {
+		for _, configFile := range profileConfigFiles {
+			if configFile != ProfileConfigFileProfileCon {
+				selected = append(selected, configFile)
+			}
+		}
+	}
+
+	if _, err := buildExistingProfileConPath(h, sourceKey); err != nil {
+		return nil, err
+	}
+
+	// Only copy into valid profiles, not into arbitrary (or missing) folders
+	targetProfileConPath, err := buildExistingProfileConPath(h, targetKey)
+	if err != nil {
+		return nil, err
+	}
+
+	targetPath := filepath.Dir(targetProfileConPath)
+	lock, err := h.LockDir(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = lock.Release()
+	}()
+
+	tx := h.Begin()
+
+	var copied []ProfileConfigFile
+	for _, configFile := range selected {
+		sourcePath, err := BuildProfileConfigFilePath(h, sourceKey, configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// Copy the raw content, since re-serializing would reorder lines which depend on each other (e.g. in Controls.con)
+		data, err := tx.ReadFile(sourcePath)
+		if err != nil {
+			// Only skip missing files if they were not explicitly requested
+			if !explicit && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		if err = tx.WriteFile(filepath.Join(targetPath, string(configFile)), data); err != nil {
+			return nil, err
+		}
+		copied = append(copied, configFile)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// Delete the given profile, replacing it as the default profile with the most recently modified remaining profile
+// (if any) and returning the new default profile's key (empty if the default profile did not change or none is left)
+func DeleteProfile(h ProfileHandler, profileKey string, options ...DeleteOption) (string, error) {
+	var opts deleteOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	profilesPath, profilePath, err := buildExistingProfilePath(h, profileKey)
+	if err != nil {
+		return "", err
+	}
+
+	lock, err := h.LockDir(profilesPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = lock.Release()
+	}()
+
+	// Also lock the profile folder itself, since files in it are updated while holding only its lock
+	profileLock, err := h.LockDir(profilePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = profileLock.Release()
+	}()
+
+	tx := h.Begin()
+	var recycledPath string
+	if opts.recyclePath != "" {
+		recycledPath = filepath.Join(opts.recyclePath, fmt.Sprintf("%s-%s", profileKey, time.Now().Format(recycledProfileTimeFormat)))
+		err = tx.Move(profilePath, recycledPath)
+	} else {
+		err = tx.Remove(profilePath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	globalConPath := filepath.Join(profilesPath, globalConFileName)
+	isDefault, err := isDefaultProfile(tx, globalConPath, profileKey)
+	if err != nil {
+		return "", err
+	}
+
+	var defaultProfileKey string
+	if isDefault {
+		if defaultProfileKey, err = findFallbackProfileKey(h, profileKey); err != nil {
+			return "", err
+		}
+		if defaultProfileKey != "" {
+			err = stageDefaultProfile(tx, globalConPath, defaultProfileKey)
+		} else {
+			// Without any profiles left, the game asks to create a new one on start
+			err = stageNoDefaultProfile(tx, globalConPath)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Profile is deleted even if its folder cannot be cleaned up afterwards, so the new default profile is still returned
+	err = tx.Commit()
+	var cleanupErr *handler.ErrCleanupFailed
+	if err != nil && !errors.As(err, &cleanupErr) {
+		return "", err
+	}
+
+	// Lock file was moved along with the profile folder
+	if recycledPath != "" {
+		profileLock.Follow(recycledPath)
+	}
+
+	return defaultProfileKey, err
+}
+
+// Change the given profile's key (moving its folder), also updating Global.con if it is the default profile
+func RekeyProfile(h ProfileHandler, oldKey string, newKey string) error {
+	if !refractorv2.IsWellFormedProfileKey(newKey) {
+		return fmt.Errorf("profile key is not a number with up to four digits: %s", newKey)
+	}
+
+	profilesPath, profilePath, err := buildExistingProfilePath(h, oldKey)
+	if err != nil {
+		return err
+	}
+
+	lock, err := h.LockDir(profilesPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = lock.Release()
+	}()
+
+	// Also lock the profile folder itself, since files in it are updated while holding only its lock
+	profileLock, err := h.LockDir(profilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = profileLock.Release()
+	}()
+
+	entries, err := h.ReadDir(profilesPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		// Folder names are not case-sensitive on Windows
+		if strings.EqualFold(entry.Name(), newKey) {
+			return fmt.Errorf("profile key is already in use: %s", newKey)
+		}
+	}
+
+	newPath := filepath.Join(profilesPath, newKey)
+	tx := h.Begin()
+	if err = tx.Move(profilePath, newPath); err != nil {
+		return err
+	}
+
+	globalConPath := filepath.Join(profilesPath, globalConFileName)
+	isDefault, err := isDefaultProfile(tx, globalConPath, oldKey)
+	if err != nil {
+		return err
+	}
+	if isDefault {
+		if err = stageDefaultProfile(tx, globalConPath, newKey); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	// Lock file was moved along with the profile folder
+	profileLock.Follow(newPath)
+
+	return nil
+}
+
+// buildExistingProfilePath Build the paths to the profiles folder and the given profile's folder, ensuring the latter
+// exists and is not the Default profile (which the game needs to create new profiles)
+func buildExistingProfilePath(h ProfileHandler, profileKey string) (string, string, error) {
+	if !refractorv2.IsWellFormedProfileKey(profileKey) {
+		return "", "", fmt.Errorf("profile key is not a number with up to four digits: %s", profileKey)
+	}
+
+	profilesPath, err := h.BuildProfilesFolderPath(handler.GameBf2)
+	if err != nil {
+		return "", "", err
+	}
+
+	profilePath := filepath.Join(profilesPath, profileKey)
+	if _, err = h.ReadDir(profilePath); err != nil {
+		return "", "", err
+	}
+
+	return profilesPath, profilePath, nil
+}
+
+// buildExistingProfileConPath Build the path to the given profile's Profile.con, ensuring the profile key is
+// well-formed and the file exists (returns ErrProfileNotFound otherwise)
+func buildExistingProfileConPath(h game.Handler, profileKey string) (string, error) {
+	if !refractorv2.IsWellFormedProfileKey(profileKey) {
+		return "", fmt.Errorf("profile key is not a number with up to four digits: %s", profileKey)
+	}
+
+	profileConPath, err := BuildProfileConfigFilePath(h, profileKey, ProfileConfigFileProfileCon)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = h.ReadConfigFile(profileConPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", &ErrProfileNotFound{profileKey: profileKey}
+		}
+		return "", err
+	}
+
+	return profileConPath, nil
+}
+
+// isDefaultProfile Check whether Global.con (if it exists) references the given profile as the default profile
+func isDefaultProfile(tx *handler.Transaction, globalConPath string, profileKey string) (bool, error) {
+	globalCon, err := tx.ReadConfigFile(globalConPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	ref, err := globalCon.GetValue(GlobalConKeyDefaultProfileRef)
+	if err != nil {
+		return false, nil
+	}
+
+	return ref.String() == profileKey, nil
+}
+
+// findFallbackProfileKey Find the most recently modified (readable) profile with a well-formed key other than the
+// excluded one, preferring the lowest profile key among profiles modified at the same time (empty if there is none)
+func findFallbackProfileKey(h game.DirHandler, excludedKey string) (string, error) {
+	profiles, err := GetProfiles(h)
+	if err != nil {
+		var unreadable *game.ErrUnreadableProfiles
+		if !errors.As(err, &unreadable) {
+			return "", err
+		}
+	}
+
+	// Profiles are sorted by key, so a stable sort keeps the lowest key first among equally recent profiles
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].Modified.After(profiles[j].Modified)
+	})
+
+	for _, profile := range profiles {
+		if profile.Key != excludedKey && refractorv2.IsWellFormedProfileKey(profile.Key) {
+			return profile.Key, nil
+		}
+	}
+
+	return "", nil
+}
+
+// newProfile Build the profile details from the profile's (staged) Profile.con and config file names, since
+// a profile written in dry-run mode cannot be read back from disk
+func newProfile(profileKey string, profileCon *config.Config, configFiles []string) game.Profile {
+	identity := GetProfileIdentity(profileCon)
+	profile := game.Profile{
+		Key:         profileKey,
+		Name:        identity.Name,
+		Type:        game.ProfileTypeMultiplayer,
+		Nick:        identity.Nick,
+		GamespyNick: identity.GamespyNick,
+		HasEmail:    profileCon.HasKey(ProfileConKeyEmail),
+		HasPassword: getStringValue(profileCon, ProfileConKeyPassword) != "",
+		ConfigFiles: configFiles,
+	}
+
+	// Singleplayer profiles do not contain an email address
+	if !profile.HasEmail {
+		profile.Type = game.ProfileTypeSingleplayer
+	}
+
+	return profile
+}
+
+func newProfileOptions(options []ProfileOption) profileOptions {
+	var opts profileOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// reserveProfileKey Lock the profiles folder and allocate the key for a new profile, the returned func releases
+// the lock and must be called once the profile has been written
+func reserveProfileKey(h ProfileHandler, profilesPath string) (string, func(), error) {
+	// Lock the profiles folder, so no other process can allocate the same profile key
+	// (unless it does not exist yet, in which case it is created along with the profile)
+	lock, err := h.LockDir(profilesPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+	release := func() {
+		if lock != nil {
+			_ = lock.Release()
+		}
+	}
+
+	profileKey, err := allocateProfileKey(h, profilesPath)
+	if err != nil {
+		release()
+		return "", nil, err
+	}
+
+	return profileKey, release, nil
+}
+
+// allocateProfileKey Find the lowest profile key not used by any file or folder in the profiles folder
+func allocateProfileKey(h ProfileHandler, profilesPath string) (string, error) {
+	entries, err := h.ReadDir(profilesPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		used[strings.ToLower(entry.Name())] = true
+	}
+
+	for i := 1; i <= maxProfileKey; i++ {
+		profileKey := fmt.Sprintf(profileKeyFormat, i)
+		if !used[profileKey] {
+			return profileKey, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free profile key left in %s", profilesPath)
+}
+
+// stageDefaultProfile Stage setting the given profile as the default profile, creating Global.con if it does not exist
+func stageDefaultProfile(tx *handler.Transaction, globalConPath string, profileKey string) error {
+	globalCon, err := tx.ReadConfigFile(globalConPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		globalCon = config.FromBytes(globalConPath, nil)
+	}
+
+	SetDefaultProfile(globalCon, profileKey)
+
+	return tx.WriteConfigFile(globalCon)
+}
+
+// stageNoDefaultProfile Stage removing the default profile reference from Global.con
+func stageNoDefaultProfile(tx *handler.Transaction, globalConPath string) error {
+	globalCon, err := tx.ReadConfigFile(globalConPath)
+	if err != nil {
+		return err
+	}
+
+	globalCon.Delete(GlobalConKeyDefaultProfileRef)
+
+	return tx.WriteConfigFile(globalCon)
+}
+
+// validateProfileValues Check that the name and any given nick and email can be written to Profile.con
+func validateProfileValues(name string, opts profileOptions) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if opts.nick != "" {
+		if err := validateNick(opts.nick); err != nil {
+			return err
+		}
+	}
+	if opts.email != "" {
+		if err := validateEmail(opts.email); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,692 @@
+//go:build unit
+
+package bf2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+const (
+	// Control maps must be created before keys are mapped to them
+	testControlsCon = "ControlMap.create InfantryPlaneControlMap\r\nControlMap.addKeyToTriggerMapping c_PIFire 0 1 0\r\n" +
+		"ControlMap.create DefaultGameControlMap\r\nControlMap.addKeyToTriggerMapping c_GIMenu 0 1 0\r\n"
+	// The map list must be cleared before maps are appended
+	testMapListCon = "mapList.clear\r\nmapList.append strike_at_karkand gpm_cq 16\r\nmapList.append dalian_plant gpm_cq 64\r\n"
+)
+
+// withDefaultProfile Add any config files the game ships in the Default profile which are not given already
+func withDefaultProfile(files fstest.MapFS) fstest.MapFS {
+	for _, configFile := range defaultProfileConfigFiles {
+		path := "Profiles/Default/" + string(configFile)
+		if _, ok := files[path]; !ok {
+			files[path] = &fstest.MapFile{Data: []byte("Default.setValue 1\r\n")}
+		}
+	}
+	return files
+}
+
+func TestCreateProfile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+
+	type test struct {
+		name            string
+		givenFiles      fstest.MapFS
+		givenName       string
+		givenType       game.ProfileType
+		givenOptions    []ProfileOption
+		wantProfile     game.Profile
+		wantFiles       map[string]string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "creates multiplayer profile seeded from Default profile",
+			givenFiles: withDefaultProfile(fstest.MapFS{
+				"Profiles/Global.con":         {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/Default/Video.con":  {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+				"Profiles/Default/Audio.con":  {Data: []byte("AudioSettings.setEffectsVolume 0.5\r\n")},
+				"Profiles/0001/Profile.con":   {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0003/Profile.con":   {Data: []byte("LocalProfile.setName \"mister250\"\r\n")},
+				"Profiles/Default/Custom.con": {Data: []byte("Custom.setValue 1\r\n")},
+			}),
+			givenName:    "lan-player",
+			givenType:    game.ProfileTypeMultiplayer,
+			givenOptions: []ProfileOption{WithNick("lan-nick"), WithEmail("lan@example.com")},
+			wantProfile: game.Profile{
+				Key:         "0002",
+				Name:        "lan-player",
+				Type:        game.ProfileTypeMultiplayer,
+				Nick:        "lan-nick",
+				GamespyNick: "lan-nick",
+				HasEmail:    true,
+				ConfigFiles: []string{"Audio.con", "Controls.con", "General.con", "Haptic.con", "Profile.con", "Video.con"},
+			},
+			wantFiles: map[string]string{
+				"Profiles/0002/Audio.con":   "AudioSettings.setEffectsVolume 0.5\r\n",
+				"Profiles/0002/Profile.con": "LocalProfile.setEmail \"lan@example.com\"\r\nLocalProfile.setGamespyNick \"lan-nick\"\r\nLocalProfile.setName \"lan-player\"\r\nLocalProfile.setNick \"lan-nick\"\r\n",
+				"Profiles/0002/Video.con":   "VideoSettings.setResolution 800x600@60Hz\r\n",
+				"Profiles/Global.con":       "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			},
+		},
+		{
+			name: "seeds order-sensitive config files from Default profile as is",
+			givenFiles: withDefaultProfile(fstest.MapFS{
+				"Profiles/Default/Controls.con": {Data: []byte(testControlsCon)},
+				"Profiles/Default/mapList.con":  {Data: []byte(testMapListCon)},
+			}),
+			givenName: "offline",
+			givenType: game.ProfileTypeSingleplayer,
+			wantProfile: game.Profile{
+				Key:         "0001",
+				Name:        "offline",
+				Type:        game.ProfileTypeSingleplayer,
+				Nick:        "offline",
+				ConfigFiles: []string{"Audio.con", "Controls.con", "General.con", "Haptic.con", "mapList.con", "Profile.con", "Video.con"},
+			},
+			wantFiles: map[string]string{
+				"Profiles/0001/Controls.con": testControlsCon,
+				"Profiles/0001/mapList.con":  testMapListCon,
+			},
+		},
+		{
+			name: "creates singleplayer profile and makes it the default profile",
+			givenFiles: withDefaultProfile(fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			}),
+			givenName:    "offline",
+			givenType:    game.ProfileTypeSingleplayer,
+			givenOptions: []ProfileOption{AsDefault()},
+			wantProfile: game.Profile{
+				Key:         "0002",
+				Name:        "offline",
+				Type:        game.ProfileTypeSingleplayer,
+				Nick:        "offline",
+				ConfigFiles: []string{"Audio.con", "Controls.con", "General.con", "Haptic.con", "Profile.con", "Video.con"},
+			},
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con": "LocalProfile.setName \"offline\"\r\nLocalProfile.setNick \"offline\"\r\n",
+				"Profiles/Global.con":       "GlobalSettings.setDefaultUser \"0002\"\r\n",
+			},
+		},
+		{
+			name:         "creates Global.con if it does not exist",
+			givenFiles:   withDefaultProfile(fstest.MapFS{}),
+			givenName:    "first",
+			givenType:    game.ProfileTypeSingleplayer,
+			givenOptions: []ProfileOption{AsDefault()},
+			wantProfile: game.Profile{
+				Key:         "0001",
+				Name:        "first",
+				Type:        game.ProfileTypeSingleplayer,
+				Nick:        "first",
+				ConfigFiles: []string{"Audio.con", "Controls.con", "General.con", "Haptic.con", "Profile.con", "Video.con"},
+			},
+			wantFiles: map[string]string{
+				"Profiles/0001/Profile.con": "LocalProfile.setName \"first\"\r\nLocalProfile.setNick \"first\"\r\n",
+				"Profiles/Global.con":       "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			},
+		},
+		{
+			name: "error for incomplete Default profile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Default/Video.con": {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+				"Profiles/Default/Audio.con": {Data: []byte("AudioSettings.setEffectsVolume 0.5\r\n")},
+			},
+			givenName:       "offline",
+			givenType:       game.ProfileTypeSingleplayer,
+			wantErrContains: "default profile is missing config files: Controls.con, General.con, Haptic.con",
+		},
+		{
+			name:            "error for missing Default profile",
+			givenFiles:      fstest.MapFS{},
+			givenName:       "offline",
+			givenType:       game.ProfileTypeSingleplayer,
+			wantErrContains: "default profile is missing config files: Audio.con, Controls.con, General.con, Haptic.con, Video.con",
+		},
+		{
+			name:            "error for multiplayer profile without email",
+			givenFiles:      fstest.MapFS{},
+			givenName:       "lan-player",
+			givenType:       game.ProfileTypeMultiplayer,
+			wantErrContains: "invalid value for LocalProfile.setEmail: required for multiplayer profiles",
+		},
+		{
+			name:            "error for singleplayer profile with email",
+			givenFiles:      fstest.MapFS{},
+			givenName:       "offline",
+			givenType:       game.ProfileTypeSingleplayer,
+			givenOptions:    []ProfileOption{WithEmail("lan@example.com")},
+			wantErrContains: "invalid value for LocalProfile.setEmail: singleplayer profiles do not have a GameSpy account",
+		},
+		{
+			name:            "error for name containing quotes",
+			givenFiles:      fstest.MapFS{},
+			givenName:       "\"quoted\"",
+			givenType:       game.ProfileTypeSingleplayer,
+			wantErrContains: "invalid value for LocalProfile.setName: must only contain printable ASCII characters other than quotes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			profile, err := CreateProfile(h, tt.givenName, tt.givenType, tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+				exists, err := repo.DirExists(filepath.Join(profilesPath, "0001"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantProfile, profile)
+				for path, want := range tt.wantFiles {
+					data, err := repo.ReadFile(filepath.Join(basePath, filepath.FromSlash(path)))
+					require.NoError(t, err)
+					assert.Equal(t, want, string(data), path)
+				}
+				exists, err := repo.FileExists(filepath.Join(profilesPath, "conman.lock"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+
+				// Profile should be readable right away
+				profiles, err := GetProfiles(h)
+				require.NoError(t, err)
+				var keys []string
+				for _, p := range profiles {
+					keys = append(keys, p.Key)
+				}
+				assert.Contains(t, keys, profile.Key)
+			}
+		})
+	}
+}
+
+func TestCloneProfile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+
+	type test struct {
+		name            string
+		givenFiles      fstest.MapFS
+		givenSourceKey  string
+		givenName       string
+		givenOptions    []ProfileOption
+		wantKey         string
+		wantFiles       map[string]string
+		wantMissing     []string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "clones all config files into new profile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":         {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con":   {Data: []byte("LocalProfile.setName \"mister249\"\r\nLocalProfile.setNick \"mister249\"\r\nLocalProfile.setGamespyNick \"mister249\"\r\n")},
+				"Profiles/0001/Controls.con":  {Data: []byte(testControlsCon)},
+				"Profiles/0001/mapList.con":   {Data: []byte(testMapListCon)},
+				"Profiles/0001/Custom.con":    {Data: []byte("Custom.setValue 1\r\n")},
+				"Profiles/0001/conman.lock":   {Data: []byte("{}")},
+				"Profiles/0001/Demos/a.bf2cl": {Data: []byte{}},
+			},
+			givenSourceKey: "0001",
+			givenName:      "mister249-cw",
+			givenOptions:   []ProfileOption{WithNick("=CW= mister249")},
+			wantKey:        "0002",
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con":  "LocalProfile.setGamespyNick \"mister249\"\r\nLocalProfile.setName \"mister249-cw\"\r\nLocalProfile.setNick \"=CW= mister249\"\r\n",
+				"Profiles/0002/Controls.con": testControlsCon,
+				"Profiles/0002/mapList.con":  testMapListCon,
+				"Profiles/0002/Custom.con":   "Custom.setValue 1\r\n",
+				"Profiles/0001/Profile.con":  "LocalProfile.setName \"mister249\"\r\nLocalProfile.setNick \"mister249\"\r\nLocalProfile.setGamespyNick \"mister249\"\r\n",
+				"Profiles/Global.con":        "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			},
+			wantMissing: []string{
+				"Profiles/0002/conman.lock",
+				"Profiles/0002/Demos",
+			},
+		},
+		{
+			name: "clones profile and makes it the default profile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister250\"\r\n")},
+			},
+			givenSourceKey: "0002",
+			givenName:      "copy",
+			givenOptions:   []ProfileOption{AsDefault()},
+			wantKey:        "0003",
+			wantFiles: map[string]string{
+				"Profiles/0003/Profile.con": "LocalProfile.setName \"copy\"\r\n",
+				"Profiles/Global.con":       "GlobalSettings.setDefaultUser \"0003\"\r\n",
+			},
+		},
+		{
+			name: "error if source profile does not exist",
+			givenFiles: fstest.MapFS{
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			},
+			givenSourceKey:  "0002",
+			givenName:       "copy",
+			wantErrContains: "profile not found: 0002",
+		},
+		{
+			name: "error if source profile does not contain Profile.con",
+			givenFiles: fstest.MapFS{
+				"Profiles/0001/Video.con": {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+			},
+			givenSourceKey:  "0001",
+			givenName:       "copy",
+			wantErrContains: "profile not found: 0001",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			profile, err := CloneProfile(h, tt.givenSourceKey, tt.givenName, tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+				exists, err := repo.DirExists(filepath.Join(profilesPath, "0002"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantKey, profile.Key)
+				assert.Equal(t, tt.givenName, profile.Name)
+				for path, want := range tt.wantFiles {
+					data, err := repo.ReadFile(filepath.Join(basePath, filepath.FromSlash(path)))
+					require.NoError(t, err)
+					assert.Equal(t, want, string(data), path)
+				}
+				for _, path := range tt.wantMissing {
+					_, err := repo.Stat(filepath.Join(basePath, filepath.FromSlash(path)))
+					assert.ErrorIs(t, err, os.ErrNotExist, path)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyProfileConfigFiles(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+
+	givenFiles := fstest.MapFS{
+		"Profiles/0001/Profile.con":  {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+		"Profiles/0001/Controls.con": {Data: []byte(testControlsCon)},
+		"Profiles/0001/mapList.con":  {Data: []byte(testMapListCon)},
+		"Profiles/0001/Video.con":    {Data: []byte("VideoSettings.setResolution 1024x768@60Hz\r\n")},
+		"Profiles/0002/Profile.con":  {Data: []byte("LocalProfile.setName \"mister249-cw\"\r\n")},
+		"Profiles/0002/Video.con":    {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+		"Profiles/0002/Audio.con":    {Data: []byte("AudioSettings.setEffectsVolume 0.5\r\n")},
+		"Profiles/0003/Video.con":    {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+	}
+
+	type test struct {
+		name             string
+		givenSourceKey   string
+		givenTargetKey   string
+		givenConfigFiles []ProfileConfigFile
+		wantCopied       []ProfileConfigFile
+		wantFiles        map[string]string
+		wantErrContains  string
+	}
+
+	tests := []test{
+		{
+			name:             "copies selected config files",
+			givenSourceKey:   "0001",
+			givenTargetKey:   "0002",
+			givenConfigFiles: []ProfileConfigFile{ProfileConfigFileControlsCon},
+			wantCopied:       []ProfileConfigFile{ProfileConfigFileControlsCon},
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con":  "LocalProfile.setName \"mister249-cw\"\r\n",
+				"Profiles/0002/Controls.con": testControlsCon,
+				"Profiles/0002/Video.con":    "VideoSettings.setResolution 800x600@60Hz\r\n",
+			},
+		},
+		{
+			name:           "copies all existing config files other than Profile.con by default",
+			givenSourceKey: "0001",
+			givenTargetKey: "0002",
+			wantCopied:     []ProfileConfigFile{ProfileConfigFileControlsCon, ProfileConfigFileMapListCon, ProfileConfigFileVideoCon},
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con":  "LocalProfile.setName \"mister249-cw\"\r\n",
+				"Profiles/0002/Controls.con": testControlsCon,
+				"Profiles/0002/mapList.con":  testMapListCon,
+				"Profiles/0002/Video.con":    "VideoSettings.setResolution 1024x768@60Hz\r\n",
+				"Profiles/0002/Audio.con":    "AudioSettings.setEffectsVolume 0.5\r\n",
+			},
+		},
+		{
+			name:             "error if selected config file does not exist in source profile",
+			givenSourceKey:   "0001",
+			givenTargetKey:   "0002",
+			givenConfigFiles: []ProfileConfigFile{ProfileConfigFileVideoCon, ProfileConfigFileAudioCon},
+			wantFiles: map[string]string{
+				"Profiles/0002/Video.con": "VideoSettings.setResolution 800x600@60Hz\r\n",
+			},
+			wantErrContains: "file does not exist",
+		},
+		{
+			name:             "error if Profile.con is selected",
+			givenSourceKey:   "0001",
+			givenTargetKey:   "0002",
+			givenConfigFiles: []ProfileConfigFile{ProfileConfigFileProfileCon},
+			wantErrContains:  "Profile.con cannot be copied between profiles",
+		},
+		{
+			name:            "error if target profile does not contain Profile.con",
+			givenSourceKey:  "0001",
+			givenTargetKey:  "0003",
+			wantErrContains: "profile not found: 0003",
+		},
+		{
+			name:             "error if Profile.con is selected using a relative path",
+			givenSourceKey:   "0001",
+			givenTargetKey:   "0002",
+			givenConfigFiles: []ProfileConfigFile{"./Profile.con"},
+			wantFiles: map[string]string{
+				"Profiles/0002/Profile.con": "LocalProfile.setName \"mister249-cw\"\r\n",
+			},
+			wantErrContains: "Profile.con cannot be copied between profiles",
+		},
+		{
+			name:             "error if selected config file is outside of profile folder",
+			givenSourceKey:   "0001",
+			givenTargetKey:   "0002",
+			givenConfigFiles: []ProfileConfigFile{"../0001/Video.con"},
+			wantFiles: map[string]string{
+				"Profiles/0001/Video.con": "VideoSettings.setResolution 1024x768@60Hz\r\n",
+			},
+			wantErrContains: "unknown profile config file: ../0001/Video.con",
+		},
+		{
+			name:            "error if source profile does not exist",
+			givenSourceKey:  "0999",
+			givenTargetKey:  "0002",
+			wantErrContains: "profile not found: 0999",
+		},
+		{
+			name:            "error if source profile key is not well-formed",
+			givenSourceKey:  "../0001",
+			givenTargetKey:  "0002",
+			wantErrContains: "profile key is not a number with up to four digits: ../0001",
+		},
+		{
+			name:            "error if source and target profile are the same",
+			givenSourceKey:  "0001",
+			givenTargetKey:  "0001",
+			wantErrContains: "source and target profile must not be the same: 0001",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			copied, err := CopyProfileConfigFiles(h, tt.givenSourceKey, tt.givenTargetKey, tt.givenConfigFiles...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantCopied, copied)
+			}
+			for path, want := range tt.wantFiles {
+				data, err := repo.ReadFile(filepath.Join(basePath, filepath.FromSlash(path)))
+				require.NoError(t, err)
+				assert.Equal(t, want, string(data), path)
+			}
+		})
+	}
+}
+
+func TestDeleteProfile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+	recyclePath := filepath.Join("build", "recycle")
+	older := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	type test struct {
+		name                  string
+		givenFiles            fstest.MapFS
+		givenProfileKey       string
+		givenOptions          []DeleteOption
+		wantDefaultProfileKey string
+		wantGlobalCon         string
+		wantRecycled          bool
+		wantErrContains       string
+	}
+
+	tests := []test{
+		{
+			name: "deletes non-default profile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister250\"\r\n")},
+			},
+			givenProfileKey: "0002",
+			wantGlobalCon:   "GlobalSettings.setDefaultUser \"0001\"\r\n",
+		},
+		{
+			name: "deletes default profile and falls back to most recently modified profile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n"), ModTime: newer},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister250\"\r\n"), ModTime: older},
+				"Profiles/0003/Profile.con": {Data: []byte("LocalProfile.setName \"mister251\"\r\n"), ModTime: newer},
+				"Profiles/0004/Profile.con": {Data: []byte("LocalProfile.setName \"mister252\"\r\n"), ModTime: newer},
+			},
+			givenProfileKey:       "0001",
+			wantDefaultProfileKey: "0003",
+			wantGlobalCon:         "GlobalSettings.setDefaultUser \"0003\"\r\n",
+		},
+		{
+			name: "deletes last profile and removes default profile reference",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			},
+			givenProfileKey: "0001",
+			wantGlobalCon:   "",
+		},
+		{
+			name: "moves profile to recycle folder",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0002\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+				"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister250\"\r\n")},
+			},
+			givenProfileKey:       "0002",
+			givenOptions:          []DeleteOption{DeleteToRecycleFolder(recyclePath)},
+			wantDefaultProfileKey: "0001",
+			wantGlobalCon:         "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			wantRecycled:          true,
+		},
+		{
+			name: "error if profile does not exist",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			},
+			givenProfileKey: "0002",
+			wantGlobalCon:   "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			wantErrContains: "file does not exist",
+		},
+		{
+			name: "error for Default profile",
+			givenFiles: fstest.MapFS{
+				"Profiles/Global.con":        {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"Profiles/Default/Video.con": {Data: []byte("VideoSettings.setResolution 800x600@60Hz\r\n")},
+			},
+			givenProfileKey: "Default",
+			wantGlobalCon:   "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			wantErrContains: "profile key is not a number with up to four digits: Default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			defaultProfileKey, err := DeleteProfile(h, tt.givenProfileKey, tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantDefaultProfileKey, defaultProfileKey)
+				exists, err := repo.DirExists(filepath.Join(profilesPath, tt.givenProfileKey))
+				require.NoError(t, err)
+				assert.False(t, exists)
+				trashed, err := repo.Glob(filepath.Join(profilesPath, "*.trash"))
+				require.NoError(t, err)
+				assert.Empty(t, trashed)
+			}
+			data, err := repo.ReadFile(filepath.Join(profilesPath, "Global.con"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGlobalCon, string(data))
+			if tt.wantRecycled {
+				entries, err := repo.ReadDir(recyclePath)
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				assert.Regexp(t, "^"+tt.givenProfileKey+"-\\d{8}-\\d{6}$", entries[0].Name())
+				exists, err := repo.FileExists(filepath.Join(recyclePath, entries[0].Name(), "Profile.con"))
+				require.NoError(t, err)
+				assert.True(t, exists)
+				exists, err = repo.FileExists(filepath.Join(recyclePath, entries[0].Name(), "conman.lock"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+			}
+		})
+	}
+}
+
+func TestRekeyProfile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", "Battlefield 2")
+	profilesPath := filepath.Join(basePath, "Profiles")
+
+	givenFiles := fstest.MapFS{
+		"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+		"Profiles/0001/Profile.con": {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+		"Profiles/0002/Profile.con": {Data: []byte("LocalProfile.setName \"mister250\"\r\n")},
+	}
+
+	type test struct {
+		name            string
+		givenOldKey     string
+		givenNewKey     string
+		wantGlobalCon   string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:          "re-keys default profile",
+			givenOldKey:   "0001",
+			givenNewKey:   "0010",
+			wantGlobalCon: "GlobalSettings.setDefaultUser \"0010\"\r\n",
+		},
+		{
+			name:          "re-keys non-default profile",
+			givenOldKey:   "0002",
+			givenNewKey:   "0010",
+			wantGlobalCon: "GlobalSettings.setDefaultUser \"0001\"\r\n",
+		},
+		{
+			name:            "error if new key is already in use",
+			givenOldKey:     "0001",
+			givenNewKey:     "0002",
+			wantGlobalCon:   "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			wantErrContains: "profile key is already in use: 0002",
+		},
+		{
+			name:            "error if new key is not well-formed",
+			givenOldKey:     "0001",
+			givenNewKey:     "abc",
+			wantGlobalCon:   "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			wantErrContains: "profile key is not a number with up to four digits: abc",
+		},
+		{
+			name:            "error if profile does not exist",
+			givenOldKey:     "0003",
+			givenNewKey:     "0010",
+			wantGlobalCon:   "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			wantErrContains: "file does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(givenFiles, basePath))
+			h := handler.New(repo, handler.WithBasePath(handler.GameBf2, basePath))
+
+			// WHEN
+			err := RekeyProfile(h, tt.givenOldKey, tt.givenNewKey)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				exists, err := repo.DirExists(filepath.Join(profilesPath, tt.givenOldKey))
+				require.NoError(t, err)
+				assert.False(t, exists)
+				profileCon, err := ReadProfileConfigFile(h, tt.givenNewKey, ProfileConfigFileProfileCon)
+				require.NoError(t, err)
+				assert.True(t, profileCon.HasKey(ProfileConKeyName))
+				// Lock file must not be moved along with the profile folder and left behind as a stale lock
+				exists, err = repo.FileExists(filepath.Join(profilesPath, tt.givenNewKey, "conman.lock"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+			}
+			data, err := repo.ReadFile(filepath.Join(profilesPath, "Global.con"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGlobalCon, string(data))
+		})
+	}
+}
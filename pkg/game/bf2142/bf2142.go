@@ -0,0 +1,71 @@
+// Methods for working specifically with Battlefield 2142 configuration files (.con)
+package bf2142
+
+import (
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv2"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+type ProfileConfigFile string
+
+const (
+	ProfileConfigFileAudioCon          ProfileConfigFile = "Audio.con"
+	ProfileConfigFileControlsCon       ProfileConfigFile = "Controls.con"
+	ProfileConfigFileGeneralCon        ProfileConfigFile = "General.con"
+	ProfileConfigFileMapListCon        ProfileConfigFile = "mapList.con"
+	ProfileConfigFileProfileCon        ProfileConfigFile = "Profile.con"
+	ProfileConfigFileServerSettingsCon ProfileConfigFile = "ServerSettings.con"
+	ProfileConfigFileVideoCon          ProfileConfigFile = "Video.con"
+
+	DefaultProfileKey = refractorv2.DefaultProfileKey
+
+	GlobalConKeyDefaultProfileRef = refractorv2.GlobalConKeyDefaultProfileRef
+
+	ProfileConKeyName  = refractorv2.ProfileConKeyName
+	ProfileConKeyEmail = refractorv2.ProfileConKeyEmail
+
+	GeneralConKeyServerHistory  = refractorv2.GeneralConKeyServerHistory
+	GeneralConKeyFavoriteServer = refractorv2.GeneralConKeyFavoriteServer
+)
+
+// Read a config file from the given Battlefield 2142 profile
+func ReadProfileConfigFile(h game.Handler, profileKey string, configFile ProfileConfigFile) (*config.Config, error) {
+	return refractorv2.ReadProfileConfigFile(h, handler.GameBf2142, profileKey, string(configFile))
+}
+
+func GetProfiles(h game.DirHandler) ([]game.Profile, error) {
+	return refractorv2.GetProfiles(h, handler.GameBf2142)
+}
+
+// Read and parse the Battlefield 2142 Profile.con file for the current default profile
+func GetDefaultProfileProfileCon(h game.Handler) (*config.Config, error) {
+	return refractorv2.GetDefaultProfileProfileCon(h, handler.GameBf2142)
+}
+
+// Get the default profile's key by reading and parsing the Battlefield 2142 Global.con file
+func GetDefaultProfileKey(h game.Handler) (string, error) {
+	return refractorv2.GetDefaultProfileKey(h, handler.GameBf2142)
+}
+
+func PurgeShaderCache(h game.Handler) error {
+	return h.PurgeShaderCache(handler.GameBf2142)
+}
+
+func PurgeLogoCache(h game.Handler) error {
+	return h.PurgeLogoCache(handler.GameBf2142)
+}
+
+func SetDefaultProfile(globalCon *config.Config, profileKey string) {
+	refractorv2.SetDefaultProfile(globalCon, profileKey)
+}
+
+// Remove all server history entries (GeneralSettings.addServerHistory) from given General.con config
+func PurgeServerHistory(generalCon *config.Config) {
+	refractorv2.PurgeServerHistory(generalCon)
+}
+
+func PurgeServerFavorites(generalCon *config.Config) {
+	refractorv2.PurgeServerFavorites(generalCon)
+}
@@ -0,0 +1,138 @@
+//go:build unit
+
+package bf2142
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+func TestReadProfileConfigFile(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	h := NewMockHandler(ctrl)
+	profilesPath := filepath.Join("Documents", "Battlefield 2142", "Profiles")
+	generalConPath := filepath.Join(profilesPath, "0001", "General.con")
+
+	// EXPECT
+	h.EXPECT().BuildProfilesFolderPath(handler.GameBf2142).Return(profilesPath, nil)
+	h.EXPECT().ReadConfigFile(generalConPath).Return(config.New(generalConPath, map[string]config.Value{}), nil)
+
+	// WHEN
+	generalCon, err := ReadProfileConfigFile(h, "0001", ProfileConfigFileGeneralCon)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, generalConPath, generalCon.Path)
+}
+
+func TestGetProfiles(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	h := NewMockDirHandler(ctrl)
+
+	// EXPECT
+	profilesPath := filepath.Join("Documents", "Battlefield 2142", "Profiles")
+	h.EXPECT().GetProfileKeys(handler.GameBf2142).Return([]string{"0001", DefaultProfileKey}, nil)
+	h.EXPECT().BuildProfilesFolderPath(handler.GameBf2142).Return(profilesPath, nil)
+	h.EXPECT().ReadProfileConfig(handler.GameBf2142, "0001").Return(config.New(
+		filepath.Join("Profiles", "0001", "Profile.con"),
+		map[string]config.Value{
+			ProfileConKeyName:  *config.NewQuotedValue("mister249"),
+			ProfileConKeyEmail: *config.NewQuotedValue("some-address@some-domain.some-tld"),
+		},
+	), nil)
+	h.EXPECT().ReadDir(filepath.Join(profilesPath, "0001")).Return(nil, nil)
+
+	// WHEN
+	profiles, err := GetProfiles(h)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []game.Profile{{Key: "0001", Name: "mister249", Type: game.ProfileTypeMultiplayer, HasEmail: true}}, profiles)
+}
+
+func TestGetDefaultProfileKey(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	h := NewMockHandler(ctrl)
+
+	// EXPECT
+	h.EXPECT().ReadGlobalConfig(handler.GameBf2142).Return(config.New(
+		filepath.Join("Profiles", "Global.con"),
+		map[string]config.Value{
+			GlobalConKeyDefaultProfileRef: *config.NewQuotedValue("0002"),
+		},
+	), nil)
+
+	// WHEN
+	profileKey, err := GetDefaultProfileKey(h)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "0002", profileKey)
+}
+
+func TestPurgeShaderCache_PurgeLogoCache(t *testing.T) {
+	type test struct {
+		name            string
+		givenPurge      func(h game.Handler) error
+		expect          func(h *MockHandler)
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:       "successfully purges shader cache",
+			givenPurge: PurgeShaderCache,
+			expect: func(h *MockHandler) {
+				h.EXPECT().PurgeShaderCache(handler.GameBf2142)
+			},
+		},
+		{
+			name:       "error purging shader cache",
+			givenPurge: PurgeShaderCache,
+			expect: func(h *MockHandler) {
+				h.EXPECT().PurgeShaderCache(handler.GameBf2142).Return(fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name:       "successfully purges logo cache",
+			givenPurge: PurgeLogoCache,
+			expect: func(h *MockHandler) {
+				h.EXPECT().PurgeLogoCache(handler.GameBf2142)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			h := NewMockHandler(ctrl)
+
+			// EXPECT
+			tt.expect(h)
+
+			// WHEN
+			err := tt.givenPurge(h)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+//go:build ignore
+
+package bf2142
+
+//go:generate mockgen -source=../common.go -destination=bf2142_mock_test.go -package=$GOPACKAGE -write_package_comment=false
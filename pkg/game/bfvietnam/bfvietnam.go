@@ -0,0 +1,41 @@
+// Methods for working specifically with Battlefield Vietnam configuration files (.con)
+package bfvietnam
+
+import (
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/game/refractorv1"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+type ProfileConfigFile string
+
+const (
+	ProfileConfigFileAudioCon    ProfileConfigFile = "Audio.con"
+	ProfileConfigFileControlsCon ProfileConfigFile = "Controls.con"
+	ProfileConfigFileGameCon     ProfileConfigFile = "Game.con"
+	ProfileConfigFileNetworkCon  ProfileConfigFile = "Network.con"
+	ProfileConfigFileVideoCon    ProfileConfigFile = "Video.con"
+
+	DefaultProfileKey = refractorv1.DefaultProfileKey
+
+	GlobalConKeyDefaultProfileRef = refractorv1.GlobalConKeyDefaultProfileRef
+)
+
+// Read a config file from the given Battlefield Vietnam profile
+func ReadProfileConfigFile(h game.Handler, profileKey string, configFile ProfileConfigFile) (*config.Config, error) {
+	return refractorv1.ReadProfileConfigFile(h, handler.GameBfVietnam, profileKey, string(configFile))
+}
+
+func GetProfiles(h game.Handler) ([]game.Profile, error) {
+	return refractorv1.GetProfiles(h, handler.GameBfVietnam)
+}
+
+// Get the default profile's key by reading and parsing the Battlefield Vietnam Settings/Profile.con file
+func GetDefaultProfileKey(h game.Handler) (string, error) {
+	return refractorv1.GetDefaultProfileKey(h, handler.GameBfVietnam)
+}
+
+func SetDefaultProfile(globalCon *config.Config, profileKey string) {
+	refractorv1.SetDefaultProfile(globalCon, profileKey)
+}
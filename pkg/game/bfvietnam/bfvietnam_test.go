@@ -0,0 +1,45 @@
+//go:build unit
+
+package bfvietnam
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+func TestDescriptor(t *testing.T) {
+	// WHEN
+	descriptor, ok := handler.LookupGame(handler.GameBfVietnam)
+
+	// THEN
+	require.True(t, ok)
+	assert.Equal(t, handler.EngineRefractorV1, descriptor.Engine)
+	assert.Equal(t, "BfVietnam", descriptor.DefaultMod)
+	assert.Equal(t, string(ProfileConfigFileGameCon), descriptor.ProfileConFileName)
+}
+
+func TestReadProfileConfigFile(t *testing.T) {
+	// GIVEN
+	ctrl := gomock.NewController(t)
+	h := NewMockHandler(ctrl)
+	profilesPath := filepath.Join("Battlefield Vietnam", "Mods", "BfVietnam", "Settings", "Profiles")
+	videoConPath := filepath.Join(profilesPath, "mister249", "Video.con")
+
+	// EXPECT
+	h.EXPECT().BuildProfilesFolderPath(handler.GameBfVietnam).Return(profilesPath, nil)
+	h.EXPECT().ReadConfigFile(videoConPath).Return(config.New(videoConPath, map[string]config.Value{}), nil)
+
+	// WHEN
+	videoCon, err := ReadProfileConfigFile(h, "mister249", ProfileConfigFileVideoCon)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, videoConPath, videoCon.Path)
+}
@@ -0,0 +1,5 @@
+//go:build ignore
+
+package bfvietnam
+
+//go:generate mockgen -source=../common.go -destination=bfvietnam_mock_test.go -package=$GOPACKAGE -write_package_comment=false
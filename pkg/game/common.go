@@ -1,6 +1,11 @@
 package game
 
 import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/cetteup/conman/pkg/config"
 	"github.com/cetteup/conman/pkg/handler"
 )
@@ -13,9 +18,63 @@ const (
 )
 
 type Profile struct {
-	Key  string
-	Name string
-	Type ProfileType
+	Key         string
+	Name        string
+	Type        ProfileType
+	Nick        string
+	GamespyNick string
+	// Whether the profile contains an email address/a (non-empty) stored password
+	HasEmail    bool
+	HasPassword bool
+	// Most recent modification of the profile folder's files and folders
+	Modified time.Time
+	// Names of all config files in the profile folder
+	ConfigFiles []string
+}
+
+// ProfileError Error encountered while reading a single profile
+type ProfileError struct {
+	Key string
+	Err error
+}
+
+func (e *ProfileError) Error() string {
+	return fmt.Sprintf("failed to read profile %s: %s", e.Key, e.Err)
+}
+
+func (e *ProfileError) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnreadableProfiles Returned alongside all readable profiles if one or more profiles could not be read
+type ErrUnreadableProfiles struct {
+	errs []*ProfileError
+}
+
+// Create an error for the given per-profile errors
+func NewErrUnreadableProfiles(errs []*ProfileError) *ErrUnreadableProfiles {
+	return &ErrUnreadableProfiles{errs: errs}
+}
+
+func (e *ErrUnreadableProfiles) Error() string {
+	messages := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Errors for each profile which could not be read
+func (e *ErrUnreadableProfiles) Errors() []*ProfileError {
+	return e.errs
+}
+
+func (e *ErrUnreadableProfiles) Unwrap() []error {
+	errs := make([]error, 0, len(e.errs))
+	for _, err := range e.errs {
+		errs = append(errs, err)
+	}
+	return errs
 }
 
 type Handler interface {
@@ -27,3 +86,9 @@ type Handler interface {
 	PurgeLogoCache(game handler.Game) error
 	BuildProfilesFolderPath(game handler.Game) (string, error)
 }
+
+// DirHandler Handler which can also list folder contents, as required to read profile details
+type DirHandler interface {
+	Handler
+	ReadDir(path string) ([]os.DirEntry, error)
+}
@@ -0,0 +1,5 @@
+//go:build ignore
+
+package refractorv1
+
+//go:generate mockgen -source=../common.go -destination=refractorv1_mock_test.go -package=$GOPACKAGE -write_package_comment=false
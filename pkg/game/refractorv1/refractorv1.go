@@ -0,0 +1,75 @@
+// Methods shared by all Refractor v1 engine games (Battlefield 1942, Battlefield Vietnam), which keep profiles inside the install folder
+package refractorv1
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	DefaultProfileKey = "Default"
+
+	// GlobalConKeyDefaultProfileRef Key in the mod's Settings/Profile.con referencing the current profile (by name)
+	GlobalConKeyDefaultProfileRef = "game.setProfile"
+)
+
+// Read a config file from the given profile
+func ReadProfileConfigFile(h game.Handler, g handler.Game, profileKey string, fileName string) (*config.Config, error) {
+	basePath, err := h.BuildProfilesFolderPath(g)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.ReadConfigFile(filepath.Join(basePath, profileKey, fileName))
+}
+
+func GetProfiles(h game.Handler, g handler.Game) ([]game.Profile, error) {
+	profileKeys, err := h.GetProfileKeys(g)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []game.Profile
+	for _, profileKey := range profileKeys {
+		// Ignore the default profile
+		if profileKey == DefaultProfileKey {
+			continue
+		}
+
+		// Refractor v1 profile folders are named after the profile, and any profile can be used online
+		profiles = append(profiles, game.Profile{
+			Key:  profileKey,
+			Name: profileKey,
+			Type: game.ProfileTypeMultiplayer,
+		})
+	}
+
+	return profiles, nil
+}
+
+// Get the default profile's key by reading and parsing the mod's Settings/Profile.con file
+func GetDefaultProfileKey(h game.Handler, g handler.Game) (string, error) {
+	globalCon, err := h.ReadGlobalConfig(g)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Profile.con: %s", err)
+	}
+
+	profileRef, err := globalCon.GetValue(GlobalConKeyDefaultProfileRef)
+	if err != nil {
+		return "", fmt.Errorf("reference to default profile is missing from Profile.con")
+	}
+	// Profile keys are folder names, so they can neither be empty nor contain any path elements
+	if profileRef.String() == "" || filepath.Base(profileRef.String()) != profileRef.String() {
+		return "", fmt.Errorf("reference to default profile in Profile.con is not a valid profile key: %s", profileRef.String())
+	}
+
+	return profileRef.String(), nil
+}
+
+func SetDefaultProfile(globalCon *config.Config, profileKey string) {
+	globalCon.SetValue(GlobalConKeyDefaultProfileRef, *config.NewQuotedValue(profileKey))
+}
@@ -0,0 +1,177 @@
+//go:build unit
+
+package refractorv1
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+var (
+	games = []handler.Game{handler.GameBf1942, handler.GameBfVietnam}
+)
+
+func TestReadProfileConfigFile(t *testing.T) {
+	for _, g := range games {
+		t.Run(string(g), func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			h := NewMockHandler(ctrl)
+			profilesPath := filepath.Join("Battlefield 1942", "Mods", "bf1942", "Settings", "Profiles")
+			gameConPath := filepath.Join(profilesPath, "mister249", "Game.con")
+
+			// EXPECT
+			h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+			h.EXPECT().ReadConfigFile(gameConPath).Return(config.New(gameConPath, map[string]config.Value{}), nil)
+
+			// WHEN
+			gameCon, err := ReadProfileConfigFile(h, g, "mister249", "Game.con")
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, gameConPath, gameCon.Path)
+		})
+	}
+}
+
+func TestGetProfiles(t *testing.T) {
+	type test struct {
+		name            string
+		expect          func(h *MockHandler, g handler.Game)
+		wantProfiles    []game.Profile
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "successfully gets profiles named after their folder",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return([]string{DefaultProfileKey, "mister249"}, nil)
+			},
+			wantProfiles: []game.Profile{
+				{
+					Key:  "mister249",
+					Name: "mister249",
+					Type: game.ProfileTypeMultiplayer,
+				},
+			},
+		},
+		{
+			name: "error getting profile keys",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockHandler(ctrl)
+
+				// EXPECT
+				tt.expect(h, g)
+
+				// WHEN
+				profiles, err := GetProfiles(h, g)
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.wantProfiles, profiles)
+				}
+			})
+		}
+	}
+}
+
+func TestGetDefaultProfileKey(t *testing.T) {
+	type test struct {
+		name               string
+		givenContent       map[string]config.Value
+		expectedProfileKey string
+		wantErrContains    string
+	}
+
+	tests := []test{
+		{
+			name: "successfully retrieves default profile key",
+			givenContent: map[string]config.Value{
+				GlobalConKeyDefaultProfileRef: *config.NewQuotedValue("mister249"),
+			},
+			expectedProfileKey: "mister249",
+		},
+		{
+			name:            "error if default profile reference is missing",
+			givenContent:    map[string]config.Value{},
+			wantErrContains: "reference to default profile is missing from Profile.con",
+		},
+		{
+			name: "error if default profile reference is empty",
+			givenContent: map[string]config.Value{
+				GlobalConKeyDefaultProfileRef: *config.NewQuotedValue(""),
+			},
+			wantErrContains: "reference to default profile in Profile.con is not a valid profile key",
+		},
+		{
+			name: "error if default profile reference is a path",
+			givenContent: map[string]config.Value{
+				GlobalConKeyDefaultProfileRef: *config.NewQuotedValue(filepath.Join("..", "mister249")),
+			},
+			wantErrContains: "reference to default profile in Profile.con is not a valid profile key",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockHandler(ctrl)
+
+				// EXPECT
+				h.EXPECT().ReadGlobalConfig(g).Return(config.New(filepath.Join("Settings", "Profile.con"), tt.givenContent), nil)
+
+				// WHEN
+				profileKey, err := GetDefaultProfileKey(h, g)
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.expectedProfileKey, profileKey)
+				}
+			})
+		}
+	}
+}
+
+func TestSetDefaultProfile(t *testing.T) {
+	// GIVEN
+	globalCon := config.New(filepath.Join("Settings", "Profile.con"), map[string]config.Value{
+		GlobalConKeyDefaultProfileRef: *config.NewQuotedValue("Default"),
+	})
+
+	// WHEN
+	SetDefaultProfile(globalCon, "mister249")
+
+	// THEN
+	assert.Equal(t, config.New(filepath.Join("Settings", "Profile.con"), map[string]config.Value{
+		GlobalConKeyDefaultProfileRef: *config.NewQuotedValue("mister249"),
+	}), globalCon)
+}
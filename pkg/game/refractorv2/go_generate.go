@@ -0,0 +1,5 @@
+//go:build ignore
+
+package refractorv2
+
+//go:generate mockgen -source=../common.go -destination=refractorv2_mock_test.go -package=$GOPACKAGE -write_package_comment=false
@@ -0,0 +1,212 @@
+// Methods shared by all Refractor v2 engine games (Battlefield 2, Battlefield 2142), which use the same profile layout
+package refractorv2
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+const (
+	DefaultProfileKey = "Default"
+	// profileKeyMaxLength Refractor v2 games only use 4 digit profile keys
+	profileKeyMaxLength = 4
+
+	conFileExt = ".con"
+
+	GlobalConKeyDefaultProfileRef = "GlobalSettings.setDefaultUser"
+
+	ProfileConKeyName        = "LocalProfile.setName"
+	ProfileConKeyNick        = "LocalProfile.setNick"
+	ProfileConKeyGamespyNick = "LocalProfile.setGamespyNick"
+	ProfileConKeyEmail       = "LocalProfile.setEmail"
+	ProfileConKeyPassword    = "LocalProfile.setPassword"
+
+	GeneralConKeyServerHistory  = "GeneralSettings.addServerHistory"
+	GeneralConKeyFavoriteServer = "GeneralSettings.addFavouriteServer"
+)
+
+// Read a config file from the given profile
+func ReadProfileConfigFile(h game.Handler, g handler.Game, profileKey string, fileName string) (*config.Config, error) {
+	filePath, err := BuildProfileConfigFilePath(h, g, profileKey, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	conFile, err := h.ReadConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return conFile, nil
+}
+
+// Build the path to a config file in the given profile
+func BuildProfileConfigFilePath(h game.Handler, g handler.Game, profileKey string, fileName string) (string, error) {
+	basePath, err := h.BuildProfilesFolderPath(g)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(basePath, profileKey, fileName), nil
+}
+
+// Get all profiles (except the default profile), if any profiles cannot be read, all readable profiles are returned
+// alongside a *game.ErrUnreadableProfiles
+func GetProfiles(h game.DirHandler, g handler.Game) ([]game.Profile, error) {
+	profileKeys, err := h.GetProfileKeys(g)
+	if err != nil {
+		return nil, err
+	}
+
+	profilesPath, err := h.BuildProfilesFolderPath(g)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []game.Profile
+	var errs []*game.ProfileError
+	for _, profileKey := range profileKeys {
+		// Ignore the default profile
+		if profileKey == DefaultProfileKey {
+			continue
+		}
+
+		profile, err := getProfile(h, g, filepath.Join(profilesPath, profileKey), profileKey)
+		if err != nil {
+			// Skip any profile which cannot be read, so a single broken profile does not hide all others
+			errs = append(errs, &game.ProfileError{Key: profileKey, Err: err})
+			continue
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	if len(errs) > 0 {
+		return profiles, game.NewErrUnreadableProfiles(errs)
+	}
+
+	return profiles, nil
+}
+
+func getProfile(h game.DirHandler, g handler.Game, profilePath string, profileKey string) (game.Profile, error) {
+	profileCon, err := h.ReadProfileConfig(g, profileKey)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	profileName, err := profileCon.GetValue(ProfileConKeyName)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	profile := game.Profile{
+		Key:         profileKey,
+		Name:        profileName.String(),
+		Type:        game.ProfileTypeMultiplayer,
+		Nick:        getStringValue(profileCon, ProfileConKeyNick),
+		GamespyNick: getStringValue(profileCon, ProfileConKeyGamespyNick),
+		HasEmail:    profileCon.HasKey(ProfileConKeyEmail),
+		HasPassword: getStringValue(profileCon, ProfileConKeyPassword) != "",
+	}
+
+	// Singleplayer profiles do not contain an email address
+	if !profile.HasEmail {
+		profile.Type = game.ProfileTypeSingleplayer
+	}
+
+	entries, err := h.ReadDir(profilePath)
+	if err != nil {
+		return game.Profile{}, err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return game.Profile{}, err
+		}
+		if info.ModTime().After(profile.Modified) {
+			profile.Modified = info.ModTime()
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), conFileExt) {
+			profile.ConfigFiles = append(profile.ConfigFiles, entry.Name())
+		}
+	}
+
+	return profile, nil
+}
+
+// getStringValue Get the given key's value, or an empty string if the key does not exist
+func getStringValue(c *config.Config, key string) string {
+	value, err := c.GetValue(key)
+	if err != nil {
+		return ""
+	}
+	return value.String()
+}
+
+// Read and parse the Profile.con file for the current default profile
+func GetDefaultProfileProfileCon(h game.Handler, g handler.Game) (*config.Config, error) {
+	profileKey, err := GetDefaultProfileKey(h, g)
+	if err != nil {
+		return nil, err
+	}
+
+	profileCon, err := h.ReadProfileConfig(g, profileKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Profile.con for current default profile (%s): %s", profileKey, err)
+	}
+
+	return profileCon, nil
+}
+
+// Get the default profile's key by reading and parsing the Global.con file
+func GetDefaultProfileKey(h game.Handler, g handler.Game) (string, error) {
+	globalCon, err := h.ReadGlobalConfig(g)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Global.con: %s", err)
+	}
+
+	defaultUserRef, err := globalCon.GetValue(GlobalConKeyDefaultProfileRef)
+	if err != nil {
+		return "", fmt.Errorf("reference to default profile is missing from Global.con")
+	}
+	if !IsWellFormedProfileKey(defaultUserRef.String()) {
+		return "", fmt.Errorf("reference to default profile in Global.con is not a valid profile key: %s", defaultUserRef.String())
+	}
+
+	return defaultUserRef.String(), nil
+}
+
+// Checks whether the given profile key has the format used by the game (a number with up to four digits)
+func IsWellFormedProfileKey(profileKey string) bool {
+	if profileKey == "" || len(profileKey) > profileKeyMaxLength {
+		return false
+	}
+
+	// Only plain digits, since signs (e.g. "-1" or "+12") would still be accepted when parsing the key as a number
+	for _, r := range profileKey {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func SetDefaultProfile(globalCon *config.Config, profileKey string) {
+	globalCon.SetValue(GlobalConKeyDefaultProfileRef, *config.NewQuotedValue(profileKey))
+}
+
+// Remove all server history entries (GeneralSettings.addServerHistory) from given General.con config
+func PurgeServerHistory(generalCon *config.Config) {
+	generalCon.Delete(GeneralConKeyServerHistory)
+}
+
+func PurgeServerFavorites(generalCon *config.Config) {
+	generalCon.Delete(GeneralConKeyFavoriteServer)
+}
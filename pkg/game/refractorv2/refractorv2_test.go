@@ -0,0 +1,593 @@
+//go:build unit
+
+package refractorv2
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/game"
+	"github.com/cetteup/conman/pkg/handler"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+var (
+	games = []handler.Game{handler.GameBf2, handler.GameBf2142}
+)
+
+func TestReadProfileConfigFile(t *testing.T) {
+	profilesPath := filepath.Join("Documents", "Battlefield 2142", "Profiles")
+
+	type test struct {
+		name            string
+		givenProfileKey string
+		givenFileName   string
+		expect          func(h *MockHandler, g handler.Game)
+		wantConfig      *config.Config
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:            "successfully reads Profile.con",
+			givenProfileKey: "0001",
+			givenFileName:   "Profile.con",
+			expect: func(h *MockHandler, g handler.Game) {
+				profileConPath := filepath.Join(profilesPath, "0001", "Profile.con")
+				h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+				h.EXPECT().ReadConfigFile(profileConPath).Return(config.New(
+					profileConPath,
+					map[string]config.Value{
+						ProfileConKeyName: *config.NewValue("\"mister249\""),
+					},
+				), nil)
+			},
+			wantConfig: config.New(
+				filepath.Join(profilesPath, "0001", "Profile.con"),
+				map[string]config.Value{
+					ProfileConKeyName: *config.NewValue("\"mister249\""),
+				},
+			),
+		},
+		{
+			name:            "errors if base path cannot be determined",
+			givenProfileKey: "0001",
+			givenFileName:   "Profile.con",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().BuildProfilesFolderPath(g).Return("", fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name:            "errors if config file read errors",
+			givenProfileKey: "0001",
+			givenFileName:   "Profile.con",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+				h.EXPECT().ReadConfigFile(filepath.Join(profilesPath, "0001", "Profile.con")).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockHandler(ctrl)
+
+				// EXPECT
+				tt.expect(h, g)
+
+				// WHEN
+				readConfig, err := ReadProfileConfigFile(h, g, tt.givenProfileKey, tt.givenFileName)
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.wantConfig, readConfig)
+				}
+			})
+		}
+	}
+}
+
+func TestBuildProfileConfigFilePath(t *testing.T) {
+	profilesPath := filepath.Join("Documents", "Battlefield 2142", "Profiles")
+
+	type test struct {
+		name            string
+		expect          func(h *MockHandler, g handler.Game)
+		wantPath        string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "successfully builds path",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+			},
+			wantPath: filepath.Join(profilesPath, "0001", "General.con"),
+		},
+		{
+			name: "errors if base path cannot be determined",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().BuildProfilesFolderPath(g).Return("", fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockHandler(ctrl)
+
+				// EXPECT
+				tt.expect(h, g)
+
+				// WHEN
+				path, err := BuildProfileConfigFilePath(h, g, "0001", "General.con")
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.wantPath, path)
+				}
+			})
+		}
+	}
+}
+
+func TestGetProfiles(t *testing.T) {
+	profilesPath := filepath.Join("Documents", "Battlefield 2", "Profiles")
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	profileEntries := dirEntries(t, fstest.MapFS{
+		"Profile.con": {Data: []byte{}, ModTime: modified.Add(-time.Hour)},
+		"General.con": {Data: []byte{}, ModTime: modified},
+		"Demos":       {Mode: fs.ModeDir, ModTime: modified.Add(-2 * time.Hour)},
+		"notes.txt":   {Data: []byte{}, ModTime: modified.Add(-3 * time.Hour)},
+	})
+
+	type test struct {
+		name            string
+		expect          func(h *MockDirHandler, g handler.Game)
+		wantProfiles    []game.Profile
+		wantUnreadable  []string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "successfully gets profiles",
+			expect: func(h *MockDirHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return([]string{"0001", "0002", DefaultProfileKey}, nil)
+				h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+				h.EXPECT().ReadProfileConfig(g, "0001").Return(config.New(
+					filepath.Join("Profiles", "0001", "Profile.con"),
+					map[string]config.Value{
+						ProfileConKeyName:        *config.NewValue("some-multiplayer-profile"),
+						ProfileConKeyNick:        *config.NewQuotedValue("some-nick"),
+						ProfileConKeyGamespyNick: *config.NewQuotedValue("some-gamespy-nick"),
+						ProfileConKeyEmail:       *config.NewValue("some-address@some-domain.some-tld"),
+						ProfileConKeyPassword:    *config.NewValue("some-encrypted-password"),
+					},
+				), nil)
+				h.EXPECT().ReadDir(filepath.Join(profilesPath, "0001")).Return(profileEntries, nil)
+				h.EXPECT().ReadProfileConfig(g, "0002").Return(config.New(
+					filepath.Join("Profiles", "0002", "Profile.con"),
+					map[string]config.Value{
+						ProfileConKeyName:     *config.NewValue("some-singleplayer-profile"),
+						ProfileConKeyPassword: *config.NewQuotedValue(""),
+					},
+				), nil)
+				h.EXPECT().ReadDir(filepath.Join(profilesPath, "0002")).Return(nil, nil)
+			},
+			wantProfiles: []game.Profile{
+				{
+					Key:         "0001",
+					Name:        "some-multiplayer-profile",
+					Type:        game.ProfileTypeMultiplayer,
+					Nick:        "some-nick",
+					GamespyNick: "some-gamespy-nick",
+					HasEmail:    true,
+					HasPassword: true,
+					Modified:    modified,
+					ConfigFiles: []string{"General.con", "Profile.con"},
+				},
+				{
+					Key:  "0002",
+					Name: "some-singleplayer-profile",
+					Type: game.ProfileTypeSingleplayer,
+				},
+			},
+		},
+		{
+			name: "error getting profile keys",
+			expect: func(h *MockDirHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return([]string{}, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name: "error if profiles folder path cannot be determined",
+			expect: func(h *MockDirHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return([]string{"0001"}, nil)
+				h.EXPECT().BuildProfilesFolderPath(g).Return("", fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name: "error for Profile.con not containing profile name",
+			expect: func(h *MockDirHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return([]string{"0001"}, nil)
+				h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+				h.EXPECT().ReadProfileConfig(g, "0001").Return(config.New(
+					filepath.Join("Profiles", "0001", "Profile.con"),
+					map[string]config.Value{},
+				), nil)
+			},
+			wantUnreadable:  []string{"0001"},
+			wantErrContains: "no such key",
+		},
+		{
+			name: "returns readable profiles alongside errors for unreadable profiles",
+			expect: func(h *MockDirHandler, g handler.Game) {
+				h.EXPECT().GetProfileKeys(g).Return([]string{"0001", "0002", "0003", "0004"}, nil)
+				h.EXPECT().BuildProfilesFolderPath(g).Return(profilesPath, nil)
+				h.EXPECT().ReadProfileConfig(g, "0001").Return(nil, fmt.Errorf("some-error"))
+				h.EXPECT().ReadProfileConfig(g, "0002").Return(config.New(
+					filepath.Join("Profiles", "0002", "Profile.con"),
+					map[string]config.Value{
+						ProfileConKeyName: *config.NewValue("some-singleplayer-profile"),
+					},
+				), nil)
+				h.EXPECT().ReadDir(filepath.Join(profilesPath, "0002")).Return(nil, nil)
+				h.EXPECT().ReadProfileConfig(g, "0003").Return(config.New(
+					filepath.Join("Profiles", "0003", "Profile.con"),
+					map[string]config.Value{},
+				), nil)
+				h.EXPECT().ReadProfileConfig(g, "0004").Return(config.New(
+					filepath.Join("Profiles", "0004", "Profile.con"),
+					map[string]config.Value{
+						ProfileConKeyName: *config.NewValue("some-other-profile"),
+					},
+				), nil)
+				h.EXPECT().ReadDir(filepath.Join(profilesPath, "0004")).Return(nil, fmt.Errorf("access denied"))
+			},
+			wantProfiles: []game.Profile{
+				{
+					Key:  "0002",
+					Name: "some-singleplayer-profile",
+					Type: game.ProfileTypeSingleplayer,
+				},
+			},
+			wantUnreadable:  []string{"0001", "0003", "0004"},
+			wantErrContains: "failed to read profile 0001: some-error; failed to read profile 0003: no such key",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockDirHandler(ctrl)
+
+				// EXPECT
+				tt.expect(h, g)
+
+				// WHEN
+				profiles, err := GetProfiles(h, g)
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+				}
+				assert.Equal(t, tt.wantProfiles, profiles)
+				var unreadable *game.ErrUnreadableProfiles
+				if tt.wantUnreadable != nil {
+					require.ErrorAs(t, err, &unreadable)
+					var keys []string
+					for _, profileErr := range unreadable.Errors() {
+						keys = append(keys, profileErr.Key)
+					}
+					assert.Equal(t, tt.wantUnreadable, keys)
+				} else {
+					assert.False(t, errors.As(err, &unreadable))
+				}
+			})
+		}
+	}
+}
+
+// dirEntries Read the entries of the given in-memory folder
+func dirEntries(t *testing.T, fsys fstest.MapFS) []os.DirEntry {
+	entries, err := fs.ReadDir(fsys, ".")
+	require.NoError(t, err)
+	return entries
+}
+
+func TestGetDefaultProfileProfileCon(t *testing.T) {
+	profileConPath := filepath.Join("Profiles", "0001", "Profile.con")
+
+	type test struct {
+		name               string
+		expect             func(h *MockHandler, g handler.Game)
+		expectedProfileCon *config.Config
+		wantErrContains    string
+	}
+
+	tests := []test{
+		{
+			name: "successfully retrieves default user's Profile.con",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().ReadGlobalConfig(g).Return(config.New(
+					filepath.Join("Profiles", "Global.con"),
+					map[string]config.Value{
+						GlobalConKeyDefaultProfileRef: *config.NewValue("0001"),
+					},
+				), nil)
+				h.EXPECT().ReadProfileConfig(g, "0001").Return(config.New(
+					profileConPath,
+					map[string]config.Value{
+						ProfileConKeyName: *config.NewValue("some-profile"),
+					},
+				), nil)
+			},
+			expectedProfileCon: config.New(
+				profileConPath,
+				map[string]config.Value{
+					ProfileConKeyName: *config.NewValue("some-profile"),
+				},
+			),
+		},
+		{
+			name: "error if Profile.con read errors",
+			expect: func(h *MockHandler, g handler.Game) {
+				h.EXPECT().ReadGlobalConfig(g).Return(config.New(
+					filepath.Join("Profiles", "Global.con"),
+					map[string]config.Value{
+						GlobalConKeyDefaultProfileRef: *config.NewValue("0001"),
+					},
+				), nil)
+				h.EXPECT().ReadProfileConfig(g, "0001").Return(nil, fmt.Errorf("some-profile-con-read-error"))
+			},
+			wantErrContains: "some-profile-con-read-error",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockHandler(ctrl)
+
+				// EXPECT
+				tt.expect(h, g)
+
+				// WHEN
+				profileCon, err := GetDefaultProfileProfileCon(h, g)
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.expectedProfileCon, profileCon)
+				}
+			})
+		}
+	}
+}
+
+func TestIsWellFormedProfileKey(t *testing.T) {
+	type test struct {
+		name            string
+		givenProfileKey string
+		want            bool
+	}
+
+	tests := []test{
+		{
+			name:            "four digit profile key",
+			givenProfileKey: "0001",
+			want:            true,
+		},
+		{
+			name:            "shorter profile key",
+			givenProfileKey: "12",
+			want:            true,
+		},
+		{
+			name:            "profile key exceeding max length",
+			givenProfileKey: "00001",
+		},
+		{
+			name:            "non-numeric profile key",
+			givenProfileKey: DefaultProfileKey,
+		},
+		{
+			name:            "negative profile key",
+			givenProfileKey: "-1",
+		},
+		{
+			name:            "profile key with plus sign",
+			givenProfileKey: "+12",
+		},
+		{
+			name: "empty profile key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			wellFormed := IsWellFormedProfileKey(tt.givenProfileKey)
+
+			// THEN
+			assert.Equal(t, tt.want, wellFormed)
+		})
+	}
+}
+
+func TestGetDefaultProfileKey(t *testing.T) {
+	type test struct {
+		name               string
+		givenContent       map[string]config.Value
+		expectedProfileKey string
+		wantErrContains    string
+	}
+
+	tests := []test{
+		{
+			name: "successfully retrieves default user profile key",
+			givenContent: map[string]config.Value{
+				GlobalConKeyDefaultProfileRef: *config.NewQuotedValue("0001"),
+			},
+			expectedProfileKey: "0001",
+		},
+		{
+			name:            "error if default user reference is missing from Global.con",
+			givenContent:    map[string]config.Value{},
+			wantErrContains: "reference to default profile is missing from Global.con",
+		},
+		{
+			name: "error if default user reference is non-numeric",
+			givenContent: map[string]config.Value{
+				GlobalConKeyDefaultProfileRef: *config.NewValue("abcd"),
+			},
+			wantErrContains: "reference to default profile in Global.con is not a valid profile key",
+		},
+		{
+			name: "error if default user reference exceeds max length",
+			givenContent: map[string]config.Value{
+				GlobalConKeyDefaultProfileRef: *config.NewValue("00001"),
+			},
+			wantErrContains: "reference to default profile in Global.con is not a valid profile key",
+		},
+	}
+
+	for _, g := range games {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", g, tt.name), func(t *testing.T) {
+				// GIVEN
+				ctrl := gomock.NewController(t)
+				h := NewMockHandler(ctrl)
+
+				// EXPECT
+				h.EXPECT().ReadGlobalConfig(g).Return(config.New(filepath.Join("Profiles", "Global.con"), tt.givenContent), nil)
+
+				// WHEN
+				profileKey, err := GetDefaultProfileKey(h, g)
+
+				// THEN
+				if tt.wantErrContains != "" {
+					require.ErrorContains(t, err, tt.wantErrContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.expectedProfileKey, profileKey)
+				}
+			})
+		}
+	}
+}
+
+func TestSetDefaultProfile(t *testing.T) {
+	// GIVEN
+	globalCon := config.New(filepath.Join("Profiles", "Global.con"), map[string]config.Value{
+		GlobalConKeyDefaultProfileRef:  *config.NewQuotedValue("0001"),
+		"GlobalSettings.setNamePrefix": *config.NewQuotedValue("=DOG="),
+	})
+
+	// WHEN
+	SetDefaultProfile(globalCon, "0002")
+
+	// THEN
+	assert.Equal(t, config.New(filepath.Join("Profiles", "Global.con"), map[string]config.Value{
+		GlobalConKeyDefaultProfileRef:  *config.NewQuotedValue("0002"),
+		"GlobalSettings.setNamePrefix": *config.NewQuotedValue("=DOG="),
+	}), globalCon)
+}
+
+func TestPurgeServerHistory_PurgeServerFavorites(t *testing.T) {
+	// GIVEN
+	generalCon := config.New(filepath.Join("Profiles", "0001", "General.con"), map[string]config.Value{
+		"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
+		GeneralConKeyServerHistory:           *config.NewValue("\"135.125.56.26\" 17567 \"=DOG= Titan\" 1025"),
+		GeneralConKeyFavoriteServer:          *config.NewValue("\"135.125.56.26\" 17567 \"=DOG= Titan\""),
+	})
+
+	// WHEN
+	PurgeServerHistory(generalCon)
+	PurgeServerFavorites(generalCon)
+
+	// THEN
+	assert.Equal(t, config.New(filepath.Join("Profiles", "0001", "General.con"), map[string]config.Value{
+		"GeneralSettings.setHUDTransparency": *config.NewValue("67.7346"),
+	}), generalCon)
+}
+
+func TestStandaloneMod(t *testing.T) {
+	// GIVEN
+	var g handler.Game = "pr"
+	require.NoError(t, handler.RegisterStandaloneMod(g, "Project Reality"))
+	t.Cleanup(func() {
+		_ = handler.UnregisterGame(g)
+	})
+	documentsPath := filepath.Join("build", "documents")
+	basePath := filepath.Join(documentsPath, "Project Reality")
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"Profiles/Global.con":            {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+		"Profiles/0001/Profile.con":      {Data: []byte("LocalProfile.setName \"mister249\"\r\nLocalProfile.setEmail \"some-address@some-domain.some-tld\"\r\n"), ModTime: modified},
+		"Profiles/0002/Profile.con":      {Data: []byte("LocalProfile.setName \"squad-leader\"\r\n"), ModTime: modified},
+		"Profiles/Default/Profile.con":   {Data: []byte("LocalProfile.setName \"Default\"\r\n")},
+		"Profiles/not-a-profile/General": {Data: []byte{}},
+	}, basePath))
+	h := handler.New(repo, handler.WithDocumentsPath(documentsPath))
+
+	// WHEN
+	profiles, err := GetProfiles(h, g)
+	require.NoError(t, err)
+	defaultProfileKey, err := GetDefaultProfileKey(h, g)
+	require.NoError(t, err)
+	globalCon, err := h.ReadGlobalConfig(g)
+	require.NoError(t, err)
+	SetDefaultProfile(globalCon, "0002")
+	err = h.WriteConfigFile(globalCon)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []game.Profile{
+		{Key: "0001", Name: "mister249", Type: game.ProfileTypeMultiplayer, HasEmail: true, Modified: modified, ConfigFiles: []string{"Profile.con"}},
+		{Key: "0002", Name: "squad-leader", Type: game.ProfileTypeSingleplayer, Modified: modified, ConfigFiles: []string{"Profile.con"}},
+	}, profiles)
+	assert.Equal(t, "0001", defaultProfileKey)
+	data, err := repo.ReadFile(filepath.Join(basePath, "Profiles", "Global.con"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0002\"\r\n"), data)
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	backupManifestFileName = "backup.json"
+	// backupIDLayout Timestamp based layout, which ensures that sorting IDs alphabetically also sorts them chronologically
+	backupIDLayout = "20060102-150405.000000000"
+)
+
+var ErrBackupsDisabled = errors.New("backups are not enabled")
+
+type ErrBackupNotFound struct {
+	id string
+}
+
+func (e *ErrBackupNotFound) Error() string {
+	return fmt.Sprintf("no such backup: %s", e.id)
+}
+
+type Backup struct {
+	ID string `json:"id"`
+	// Path of the original file
+	Path    string    `json:"path"`
+	Created time.Time `json:"created"`
+}
+
+// Retrieve all backups, sorted from oldest to newest
+func (h *Handler) ListBackups() ([]Backup, error) {
+	if h.backupDirPath == "" {
+		return nil, ErrBackupsDisabled
+	}
+
+	entries, err := h.repository.ReadDir(h.backupDirPath)
+	if err != nil {
+		// No backup has been created yet
+		if errors.Is(err, os.ErrNotExist) {
+			return []Backup{}, nil
+		}
+		return nil, err
+	}
+
+	backups := make([]Backup, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		backup, err := h.readBackupManifest(entry.Name())
+		if err != nil {
+			// Ignore any folders which are not (valid) backups
+			continue
+		}
+
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID < backups[j].ID
+	})
+
+	return backups, nil
+}
+
+// Restore the backup with the given ID to its original path (backing up the current file first)
+func (h *Handler) RestoreBackup(id string) error {
+	if h.backupDirPath == "" {
+		return ErrBackupsDisabled
+	}
+
+	// Backup IDs are plain folder names, anything else (including "." and "..") cannot be a valid ID
+	if id == "" || id == "." || id == ".." || filepath.Base(id) != id {
+		return &ErrBackupNotFound{id: id}
+	}
+
+	backup, err := h.readBackupManifest(id)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &ErrBackupNotFound{id: id}
+		}
+		return err
+	}
+
+	data, err := h.repository.ReadFile(filepath.Join(h.backupDirPath, id, filepath.Base(backup.Path)))
+	if err != nil {
+		return err
+	}
+
+	return h.writeFile(backup.Path, data)
+}
+
+// backupFile Copy the file at the given path to a new backup (if backups are enabled and the file exists)
+func (h *Handler) backupFile(path string) error {
+	if h.backupDirPath == "" {
+		return nil
+	}
+
+	data, err := h.repository.ReadFile(path)
+	if err != nil {
+		// Nothing to back up for new files
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	created := h.now()
+	id, err := h.newBackupID(created)
+	if err != nil {
+		return err
+	}
+
+	backupPath := filepath.Join(h.backupDirPath, id)
+	if err = h.repository.MkdirAll(backupPath, 0777); err != nil {
+		return err
+	}
+
+	if err = h.repository.WriteFile(filepath.Join(backupPath, filepath.Base(path)), data, 0666); err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(Backup{
+		ID:      id,
+		Path:    path,
+		Created: created,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the manifest last, since only folders with a manifest are considered to be backups
+	if err = h.repository.WriteFile(filepath.Join(backupPath, backupManifestFileName), manifest, 0666); err != nil {
+		return err
+	}
+
+	return h.rotateBackups(path)
+}
+
+func (h *Handler) newBackupID(created time.Time) (string, error) {
+	base := created.UTC().Format(backupIDLayout)
+	id := base
+	// Timer resolution may be too low to guarantee unique timestamps, so add a counter if required
+	for i := 1; ; i++ {
+		exists, err := h.repository.DirExists(filepath.Join(h.backupDirPath, id))
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return id, nil
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (h *Handler) readBackupManifest(id string) (Backup, error) {
+	data, err := h.repository.ReadFile(filepath.Join(h.backupDirPath, id, backupManifestFileName))
+	if err != nil {
+		return Backup{}, err
+	}
+
+	var backup Backup
+	if err = json.Unmarshal(data, &backup); err != nil {
+		return Backup{}, err
+	}
+
+	return backup, nil
+}
+
+// rotateBackups Remove the oldest backups of the file at the given path until no more than the configured number of
+// backups of that file remain (counting per file, so writing many files at once never rotates away their own backups)
+func (h *Handler) rotateBackups(path string) error {
+	if h.backupRetain <= 0 {
+		return nil
+	}
+
+	backups, err := h.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	var ofPath []Backup
+	for _, backup := range backups {
+		if backup.Path == path {
+			ofPath = append(ofPath, backup)
+		}
+	}
+
+	for i := 0; i < len(ofPath)-h.backupRetain; i++ {
+		if err = h.repository.RemoveAll(filepath.Join(h.backupDirPath, ofPath[i].ID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
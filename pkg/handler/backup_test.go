@@ -0,0 +1,346 @@
+//go:build unit
+
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+)
+
+const (
+	testBackupDirPath = "backups"
+)
+
+var (
+	testBackupTime = time.Date(2026, time.October, 18, 15, 4, 5, 0, time.UTC)
+)
+
+func TestHandler_ListBackups(t *testing.T) {
+	type test struct {
+		name            string
+		givenBackupDir  string
+		expect          func(ctrl *gomock.Controller, repository *MockFileRepository)
+		wantBackups     []Backup
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:           "lists valid backups sorted by id",
+			givenBackupDir: testBackupDirPath,
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				newer := NewMockDirEntry(ctrl)
+				newer.EXPECT().IsDir().Return(true)
+				newer.EXPECT().Name().Return("20261018-150405.000000000")
+				older := NewMockDirEntry(ctrl)
+				older.EXPECT().IsDir().Return(true)
+				older.EXPECT().Name().Return("20261017-150405.000000000")
+				invalid := NewMockDirEntry(ctrl)
+				invalid.EXPECT().IsDir().Return(true)
+				invalid.EXPECT().Name().Return("not-a-backup")
+				file := NewMockDirEntry(ctrl)
+				file.EXPECT().IsDir().Return(false)
+				repository.EXPECT().ReadDir(testBackupDirPath).Return([]os.DirEntry{newer, older, invalid, file}, nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, "20261018-150405.000000000", backupManifestFileName)).
+					Return([]byte(`{"id":"20261018-150405.000000000","path":"Profiles/0001/General.con","created":"2026-10-18T15:04:05Z"}`), nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, "20261017-150405.000000000", backupManifestFileName)).
+					Return([]byte(`{"id":"20261017-150405.000000000","path":"Profiles/Global.con","created":"2026-10-17T15:04:05Z"}`), nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, "not-a-backup", backupManifestFileName)).
+					Return(nil, os.ErrNotExist)
+			},
+			wantBackups: []Backup{
+				{
+					ID:      "20261017-150405.000000000",
+					Path:    "Profiles/Global.con",
+					Created: time.Date(2026, time.October, 17, 15, 4, 5, 0, time.UTC),
+				},
+				{
+					ID:      "20261018-150405.000000000",
+					Path:    "Profiles/0001/General.con",
+					Created: testBackupTime,
+				},
+			},
+		},
+		{
+			name:           "returns empty list if backup folder does not exist yet",
+			givenBackupDir: testBackupDirPath,
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadDir(testBackupDirPath).Return(nil, os.ErrNotExist)
+			},
+			wantBackups: []Backup{},
+		},
+		{
+			name:           "error listing backup folder",
+			givenBackupDir: testBackupDirPath,
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadDir(testBackupDirPath).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name:            "error if backups are disabled",
+			expect:          func(ctrl *gomock.Controller, repository *MockFileRepository) {},
+			wantErrContains: "backups are not enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			ctrl, handler, repository := getHandlerWithDependencies(t, WithBackups(tt.givenBackupDir, 0))
+
+			// EXPECT
+			tt.expect(ctrl, repository)
+
+			// WHEN
+			backups, err := handler.ListBackups()
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantBackups, backups)
+			}
+		})
+	}
+}
+
+func TestHandler_WriteConfigFile_WithBackups(t *testing.T) {
+	type test struct {
+		name            string
+		givenRetain     int
+		expect          func(ctrl *gomock.Controller, repository *MockFileRepository)
+		wantErrContains string
+	}
+
+	path := filepath.Join("Profiles", "0001", "General.con")
+	id := "20261018-150405.000000000"
+	backupPath := filepath.Join(testBackupDirPath, id)
+	data := []byte("GeneralSettings.setPlayerName \"mister249\"\r\n")
+	expectWrite := func(repository *MockFileRepository) {
+		repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist)
+		repository.EXPECT().CreateFile(testTmpPath(path), data, os.FileMode(0666)).Return(nil)
+		repository.EXPECT().Sync(testTmpPath(path)).Return(nil)
+		repository.EXPECT().Rename(testTmpPath(path), path).Return(nil)
+		repository.EXPECT().SyncDir(filepath.Dir(path)).Return(nil)
+	}
+
+	tests := []test{
+		{
+			name: "backs up existing file before writing",
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(path).Return([]byte("GeneralSettings.setPlayerName \"old\"\r\n"), nil)
+				repository.EXPECT().DirExists(backupPath).Return(false, nil)
+				repository.EXPECT().MkdirAll(backupPath, os.FileMode(0777)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath, "General.con"), []byte("GeneralSettings.setPlayerName \"old\"\r\n"), os.FileMode(0666)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath, backupManifestFileName), gomock.Any(), os.FileMode(0666)).
+					DoAndReturn(func(_ string, manifest []byte, _ os.FileMode) error {
+						assert.JSONEq(t, fmt.Sprintf(`{"id":%q,"path":%q,"created":"2026-10-18T15:04:05Z"}`, id, path), string(manifest))
+						return nil
+					})
+				expectWrite(repository)
+			},
+		},
+		{
+			name: "adds counter to id of backups created at the same time",
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(path).Return([]byte("GeneralSettings.setPlayerName \"old\"\r\n"), nil)
+				repository.EXPECT().DirExists(backupPath).Return(true, nil)
+				repository.EXPECT().DirExists(backupPath+"-1").Return(false, nil)
+				repository.EXPECT().MkdirAll(backupPath+"-1", os.FileMode(0777)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath+"-1", "General.con"), gomock.Any(), os.FileMode(0666)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath+"-1", backupManifestFileName), gomock.Any(), os.FileMode(0666)).Return(nil)
+				expectWrite(repository)
+			},
+		},
+		{
+			name:        "removes oldest backups exceeding retain count",
+			givenRetain: 1,
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(path).Return([]byte("GeneralSettings.setPlayerName \"old\"\r\n"), nil)
+				repository.EXPECT().DirExists(backupPath).Return(false, nil)
+				repository.EXPECT().MkdirAll(backupPath, os.FileMode(0777)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath, "General.con"), gomock.Any(), os.FileMode(0666)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath, backupManifestFileName), gomock.Any(), os.FileMode(0666)).Return(nil)
+				older := NewMockDirEntry(ctrl)
+				older.EXPECT().IsDir().Return(true)
+				older.EXPECT().Name().Return("20261017-150405.000000000")
+				newer := NewMockDirEntry(ctrl)
+				newer.EXPECT().IsDir().Return(true)
+				newer.EXPECT().Name().Return(id)
+				repository.EXPECT().ReadDir(testBackupDirPath).Return([]os.DirEntry{older, newer}, nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, "20261017-150405.000000000", backupManifestFileName)).
+					Return([]byte(fmt.Sprintf(`{"id":"20261017-150405.000000000","path":%q}`, path)), nil)
+				repository.EXPECT().ReadFile(filepath.Join(backupPath, backupManifestFileName)).
+					Return([]byte(fmt.Sprintf(`{"id":%q,"path":%q}`, id, path)), nil)
+				repository.EXPECT().RemoveAll(filepath.Join(testBackupDirPath, "20261017-150405.000000000")).Return(nil)
+				expectWrite(repository)
+			},
+		},
+		{
+			name:        "keeps backups of other files when removing oldest backups",
+			givenRetain: 1,
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(path).Return([]byte("GeneralSettings.setPlayerName \"old\"\r\n"), nil)
+				repository.EXPECT().DirExists(backupPath).Return(false, nil)
+				repository.EXPECT().MkdirAll(backupPath, os.FileMode(0777)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath, "General.con"), gomock.Any(), os.FileMode(0666)).Return(nil)
+				repository.EXPECT().WriteFile(filepath.Join(backupPath, backupManifestFileName), gomock.Any(), os.FileMode(0666)).Return(nil)
+				other := NewMockDirEntry(ctrl)
+				other.EXPECT().IsDir().Return(true)
+				other.EXPECT().Name().Return("20261018-150404.000000000")
+				newer := NewMockDirEntry(ctrl)
+				newer.EXPECT().IsDir().Return(true)
+				newer.EXPECT().Name().Return(id)
+				repository.EXPECT().ReadDir(testBackupDirPath).Return([]os.DirEntry{other, newer}, nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, "20261018-150404.000000000", backupManifestFileName)).
+					Return([]byte(fmt.Sprintf(`{"id":"20261018-150404.000000000","path":%q}`, filepath.Join("Profiles", "0001", "Video.con"))), nil)
+				repository.EXPECT().ReadFile(filepath.Join(backupPath, backupManifestFileName)).
+					Return([]byte(fmt.Sprintf(`{"id":%q,"path":%q}`, id, path)), nil)
+				expectWrite(repository)
+			},
+		},
+		{
+			name: "does not back up new file",
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(path).Return(nil, os.ErrNotExist)
+				expectWrite(repository)
+			},
+		},
+		{
+			name: "does not write file if backup fails",
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(path).Return([]byte("GeneralSettings.setPlayerName \"old\"\r\n"), nil)
+				repository.EXPECT().DirExists(backupPath).Return(false, nil)
+				repository.EXPECT().MkdirAll(backupPath, os.FileMode(0777)).Return(fmt.Errorf("some-error"))
+			},
+			wantErrContains: "failed to back up",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			ctrl, handler, repository := getHandlerWithDependencies(t, WithBackups(testBackupDirPath, tt.givenRetain))
+			handler.now = func() time.Time {
+				return testBackupTime
+			}
+
+			// EXPECT
+			tt.expect(ctrl, repository)
+
+			// WHEN
+			err := handler.WriteConfigFile(config.FromBytes(path, data))
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHandler_RestoreBackup(t *testing.T) {
+	type test struct {
+		name            string
+		givenID         string
+		expect          func(repository *MockFileRepository)
+		wantErrContains string
+	}
+
+	path := filepath.Join("Profiles", "0001", "General.con")
+	id := "20261017-150405.000000000"
+	data := []byte("GeneralSettings.setPlayerName \"old\"\r\n")
+
+	tests := []test{
+		{
+			name:    "restores backup to original path",
+			givenID: id,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, id, backupManifestFileName)).
+					Return([]byte(fmt.Sprintf(`{"id":%q,"path":%q}`, id, path)), nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, id, "General.con")).Return(data, nil)
+				// Current file is gone, so there is nothing to back up before restoring
+				repository.EXPECT().ReadFile(path).Return(nil, os.ErrNotExist)
+				repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist)
+				repository.EXPECT().CreateFile(testTmpPath(path), data, os.FileMode(0666)).Return(nil)
+				repository.EXPECT().Sync(testTmpPath(path)).Return(nil)
+				repository.EXPECT().Rename(testTmpPath(path), path).Return(nil)
+				repository.EXPECT().SyncDir(filepath.Dir(path)).Return(nil)
+			},
+		},
+		{
+			name:    "error for unknown backup",
+			givenID: id,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, id, backupManifestFileName)).Return(nil, os.ErrNotExist)
+			},
+			wantErrContains: "no such backup",
+		},
+		{
+			name:            "error for id containing path elements",
+			givenID:         filepath.Join("..", id),
+			expect:          func(repository *MockFileRepository) {},
+			wantErrContains: "no such backup",
+		},
+		{
+			name:            "error for parent folder id",
+			givenID:         "..",
+			expect:          func(repository *MockFileRepository) {},
+			wantErrContains: "no such backup",
+		},
+		{
+			name:            "error for current folder id",
+			givenID:         ".",
+			expect:          func(repository *MockFileRepository) {},
+			wantErrContains: "no such backup",
+		},
+		{
+			name:            "error for empty id",
+			expect:          func(repository *MockFileRepository) {},
+			wantErrContains: "no such backup",
+		},
+		{
+			name:    "error reading backed up file",
+			givenID: id,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, id, backupManifestFileName)).
+					Return([]byte(fmt.Sprintf(`{"id":%q,"path":%q}`, id, path)), nil)
+				repository.EXPECT().ReadFile(filepath.Join(testBackupDirPath, id, "General.con")).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			_, handler, repository := getHandlerWithDependencies(t, WithBackups(testBackupDirPath, 0))
+
+			// EXPECT
+			tt.expect(repository)
+
+			// WHEN
+			err := handler.RestoreBackup(tt.givenID)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+//go:build unit
+
+package handler
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+const (
+	testTmpSuffix = "0123456789abcdef"
+)
+
+func getHandlerWithDependencies(t *testing.T, options ...Option) (*gomock.Controller, *Handler, *MockFileRepository) {
+	ctrl := gomock.NewController(t)
+	mockRepository := NewMockFileRepository(ctrl)
+	h := New(mockRepository, options...)
+	h.tmpSuffix = func() string {
+		return testTmpSuffix
+	}
+	return ctrl, h, mockRepository
+}
+
+// testTmpPath Build the temporary file path used when writing the given path atomically
+func testTmpPath(path string) string {
+	return path + "." + testTmpSuffix + tmpFileSuffix
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type Engine int
+
+const (
+	EngineRefractorV1 Engine = iota + 1
+	EngineRefractorV2
+)
+
+type Action string
+
+const (
+	ActionPurgeShaderCache Action = "PurgeShaderCache"
+	ActionPurgeLogoCache   Action = "PurgeLogoCache"
+	ActionGetMods          Action = "GetMods"
+)
+
+type ErrGameAlreadyRegistered struct {
+	game string
+}
+
+func (e *ErrGameAlreadyRegistered) Error() string {
+	return fmt.Sprintf("game already registered: %s", e.game)
+}
+
+type ErrInvalidGameDescriptor struct {
+	game   string
+	reason string
+}
+
+func (e *ErrInvalidGameDescriptor) Error() string {
+	return fmt.Sprintf("invalid descriptor for game %q: %s", e.game, e.reason)
+}
+
+// GameDescriptor Everything the handler needs to know about a game in order to work with its configuration files
+type GameDescriptor struct {
+	Game   Game
+	Engine Engine
+	// Name of the game's folder inside the user's Documents folder [Refractor v2 games only]
+	DirName string
+	// Game whose configuration file formats the game shares (e.g. Battlefield 2 for its standalone mods, optional)
+	BaseGame Game
+	// Registry key (relative to HKEY_LOCAL_MACHINE\SOFTWARE) and value containing the game's install folder (optional for Refractor v2 games)
+	InstallKeyPath   string
+	InstallValueName string
+	// Mod whose profiles are used unless another mod is configured [Refractor v1 games only]
+	DefaultMod string
+	// Names of the game's executables, used to detect whether the game is running (optional)
+	Executables []string
+	// Name of the file referencing the current default profile
+	GlobalConFileName string
+	// Name of the file which needs to exist inside a folder for it to be considered a profile
+	ProfileConFileName string
+	// Name of the folder (inside the install and base path) containing a folder per mod, mods can only be listed if it is set
+	ModsDirName string
+	// Glob patterns (relative to the base path) matching cache folders, actions purging a cache are only supported if its pattern is set
+	ShaderCachePattern string
+	LogoCachePattern   string
+}
+
+// Whether the game's configuration files use the given game's formats (either because it is that game or based on it)
+func (d GameDescriptor) SharesConfigFormat(game Game) bool {
+	return d.Game == game || d.BaseGame == game
+}
+
+// Whether the given action is supported for the game
+func (d GameDescriptor) Supports(action Action) bool {
+	switch action {
+	case ActionPurgeShaderCache:
+		return d.ShaderCachePattern != ""
+	case ActionPurgeLogoCache:
+		return d.LogoCachePattern != ""
+	case ActionGetMods:
+		return d.ModsDirName != ""
+	default:
+		return false
+	}
+}
+
+func (d GameDescriptor) validate() error {
+	if d.Game == "" {
+		return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "game must not be empty"}
+	}
+	if d.GlobalConFileName == "" || d.ProfileConFileName == "" {
+		return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "global and profile config file names must not be empty"}
+	}
+	if d.BaseGame == d.Game {
+		return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "game must not be based on itself"}
+	}
+	if d.InstallKeyPath != "" && d.InstallValueName == "" {
+		return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "install value name must not be empty if an install key path is set"}
+	}
+
+	switch d.Engine {
+	case EngineRefractorV1:
+		if d.DefaultMod == "" {
+			return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "default mod must not be empty for Refractor v1 games"}
+		}
+		// Without a configured base path, Refractor v1 paths can only be determined via the install folder
+		if d.InstallKeyPath == "" {
+			return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "install key path must not be empty for Refractor v1 games"}
+		}
+	case EngineRefractorV2:
+		if d.DirName == "" {
+			return &ErrInvalidGameDescriptor{game: string(d.Game), reason: "folder name must not be empty for Refractor v2 games"}
+		}
+	default:
+		return &ErrInvalidGameDescriptor{game: string(d.Game), reason: fmt.Sprintf("unknown engine: %d", d.Engine)}
+	}
+
+	return nil
+}
+
+var (
+	gamesMu sync.RWMutex
+	games   = map[Game]GameDescriptor{}
+)
+
+func init() {
+	builtin := []GameDescriptor{
+		{
+			Game:               GameBf2,
+			Engine:             EngineRefractorV2,
+			DirName:            bf2GameDirName,
+			InstallKeyPath:     bf2InstallKeyPath,
+			InstallValueName:   v2InstallDirValueName,
+			Executables:        []string{bf2Executable},
+			GlobalConFileName:  globalConFileName,
+			ProfileConFileName: profileConFileName,
+			ModsDirName:        modsDirName,
+			ShaderCachePattern: v2ShaderCachePattern,
+			LogoCachePattern:   v2LogoCachePattern,
+		},
+		{
+			Game:               GameBf2142,
+			Engine:             EngineRefractorV2,
+			DirName:            bf2142GameDirName,
+			InstallKeyPath:     bf2142InstallKeyPath,
+			InstallValueName:   v2InstallDirValueName,
+			Executables:        []string{bf2142Executable},
+			GlobalConFileName:  globalConFileName,
+			ProfileConFileName: profileConFileName,
+			ModsDirName:        modsDirName,
+			ShaderCachePattern: v2ShaderCachePattern,
+			LogoCachePattern:   v2LogoCachePattern,
+		},
+		{
+			Game:               GameBf1942,
+			Engine:             EngineRefractorV1,
+			InstallKeyPath:     bf1942InstallKeyPath,
+			InstallValueName:   v1InstallDirValueName,
+			DefaultMod:         bf1942DefaultMod,
+			Executables:        []string{bf1942Executable},
+			GlobalConFileName:  v1GlobalConFileName,
+			ProfileConFileName: v1ProfileConFileName,
+		},
+		{
+			Game:               GameBfVietnam,
+			Engine:             EngineRefractorV1,
+			InstallKeyPath:     bfVietnamInstallKeyPath,
+			InstallValueName:   v1InstallDirValueName,
+			DefaultMod:         bfVietnamDefaultMod,
+			Executables:        []string{bfVietnamExecutable},
+			GlobalConFileName:  v1GlobalConFileName,
+			ProfileConFileName: v1ProfileConFileName,
+		},
+	}
+
+	for _, descriptor := range builtin {
+		if err := RegisterGame(descriptor); err != nil {
+			panic(err)
+		}
+	}
+}
+
+var (
+	/*
+		Looking from the base path, the shader cache files are stored in:
+		mods/
+		├──[mod]/
+		   ├──cache/
+			  ├──[cache dir with uuid-looking name]/
+				 ├──[cache file].cfx
+		Best practise is to delete all folder inside each mod's /cache directory
+	*/
+	v2ShaderCachePattern = filepath.Join(modsDirName, "*", cacheDirName, "*")
+	/*
+		Looking from the base path, the logo cache files are stored in:
+		LogoCache/
+		├──[server hosting banner image]/
+		   ├──[...path to file on server]/
+		Simply delete all folders in LogoCache/
+	*/
+	v2LogoCachePattern = filepath.Join(logoCacheDirName, "*")
+)
+
+// Make a game known to all handlers (games can only be registered once)
+func RegisterGame(descriptor GameDescriptor) error {
+	if err := descriptor.validate(); err != nil {
+		return err
+	}
+
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	if _, exists := games[descriptor.Game]; exists {
+		return &ErrGameAlreadyRegistered{game: string(descriptor.Game)}
+	}
+
+	games[descriptor.Game] = descriptor
+	return nil
+}
+
+// Remove a registered game, making it unknown to all handlers (e.g. to register it again with another descriptor)
+func UnregisterGame(game Game) error {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	if _, exists := games[game]; !exists {
+		return &ErrGameNotSupported{game: string(game)}
+	}
+
+	delete(games, game)
+	return nil
+}
+
+// Retrieve the descriptor of a registered game
+func LookupGame(game Game) (GameDescriptor, bool) {
+	gamesMu.RLock()
+	defer gamesMu.RUnlock()
+
+	descriptor, ok := games[game]
+	return descriptor, ok
+}
+
+// Retrieve all registered games, sorted by name
+func RegisteredGames() []Game {
+	gamesMu.RLock()
+	defer gamesMu.RUnlock()
+
+	registered := make([]Game, 0, len(games))
+	for game := range games {
+		registered = append(registered, game)
+	}
+
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i] < registered[j]
+	})
+
+	return registered
+}
+
+func getGame(game Game) (GameDescriptor, error) {
+	descriptor, ok := LookupGame(game)
+	if !ok {
+		return GameDescriptor{}, &ErrGameNotSupported{game: string(game)}
+	}
+	return descriptor, nil
+}
+
+// Build the descriptor of a standalone Battlefield 2 mod, which uses the same layout as Battlefield 2 but keeps its files in its own folder inside the user's Documents folder
+func StandaloneModDescriptor(game Game, dirName string) GameDescriptor {
+	return GameDescriptor{
+		Game:               game,
+		Engine:             EngineRefractorV2,
+		DirName:            dirName,
+		BaseGame:           GameBf2,
+		GlobalConFileName:  globalConFileName,
+		ProfileConFileName: profileConFileName,
+		ModsDirName:        modsDirName,
+		ShaderCachePattern: v2ShaderCachePattern,
+		LogoCachePattern:   v2LogoCachePattern,
+	}
+}
+
+// Register a standalone Battlefield 2 mod by the name of its folder inside the user's Documents folder
+func RegisterStandaloneMod(game Game, dirName string) error {
+	return RegisterGame(StandaloneModDescriptor(game, dirName))
+}
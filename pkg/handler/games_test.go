@@ -0,0 +1,379 @@
+//go:build unit
+
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+// unregisterGame Remove a game registered during a test from the global registry
+func unregisterGame(t *testing.T, game Game) {
+	t.Cleanup(func() {
+		_ = UnregisterGame(game)
+	})
+}
+
+func TestRegisterGame(t *testing.T) {
+	type test struct {
+		name            string
+		givenDescriptor GameDescriptor
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "successfully registers Refractor v2 game",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v2",
+				Engine:             EngineRefractorV2,
+				DirName:            "Test V2",
+				GlobalConFileName:  globalConFileName,
+				ProfileConFileName: profileConFileName,
+			},
+		},
+		{
+			name: "successfully registers Refractor v1 game",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v1",
+				Engine:             EngineRefractorV1,
+				InstallKeyPath:     "Test\\V1",
+				InstallValueName:   v1InstallDirValueName,
+				DefaultMod:         "test",
+				GlobalConFileName:  v1GlobalConFileName,
+				ProfileConFileName: v1ProfileConFileName,
+			},
+		},
+		{
+			name: "error for already registered game",
+			givenDescriptor: GameDescriptor{
+				Game:               GameBf2,
+				Engine:             EngineRefractorV2,
+				DirName:            bf2GameDirName,
+				GlobalConFileName:  globalConFileName,
+				ProfileConFileName: profileConFileName,
+			},
+			wantErrContains: "game already registered: bf2",
+		},
+		{
+			name: "error for empty game",
+			givenDescriptor: GameDescriptor{
+				Engine:             EngineRefractorV2,
+				DirName:            "Test V2",
+				GlobalConFileName:  globalConFileName,
+				ProfileConFileName: profileConFileName,
+			},
+			wantErrContains: "game must not be empty",
+		},
+		{
+			name: "error for missing config file names",
+			givenDescriptor: GameDescriptor{
+				Game:    "test-v2",
+				Engine:  EngineRefractorV2,
+				DirName: "Test V2",
+			},
+			wantErrContains: "config file names must not be empty",
+		},
+		{
+			name: "error for install key path without value name",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v2",
+				Engine:             EngineRefractorV2,
+				DirName:            "Test V2",
+				InstallKeyPath:     "Test\\V2",
+				GlobalConFileName:  globalConFileName,
+				ProfileConFileName: profileConFileName,
+			},
+			wantErrContains: "install value name must not be empty",
+		},
+		{
+			name: "error for Refractor v2 game without folder name",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v2",
+				Engine:             EngineRefractorV2,
+				GlobalConFileName:  globalConFileName,
+				ProfileConFileName: profileConFileName,
+			},
+			wantErrContains: "folder name must not be empty",
+		},
+		{
+			name: "error for Refractor v1 game without default mod",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v1",
+				Engine:             EngineRefractorV1,
+				InstallKeyPath:     "Test\\V1",
+				InstallValueName:   v1InstallDirValueName,
+				GlobalConFileName:  v1GlobalConFileName,
+				ProfileConFileName: v1ProfileConFileName,
+			},
+			wantErrContains: "default mod must not be empty",
+		},
+		{
+			name: "error for Refractor v1 game without install key path",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v1",
+				Engine:             EngineRefractorV1,
+				DefaultMod:         "test",
+				GlobalConFileName:  v1GlobalConFileName,
+				ProfileConFileName: v1ProfileConFileName,
+			},
+			wantErrContains: "install key path must not be empty",
+		},
+		{
+			name: "error for unknown engine",
+			givenDescriptor: GameDescriptor{
+				Game:               "test-v3",
+				DirName:            "Test V3",
+				GlobalConFileName:  globalConFileName,
+				ProfileConFileName: profileConFileName,
+			},
+			wantErrContains: "unknown engine: 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			_, registered := LookupGame(tt.givenDescriptor.Game)
+			if !registered {
+				unregisterGame(t, tt.givenDescriptor.Game)
+			}
+
+			// WHEN
+			err := RegisterGame(tt.givenDescriptor)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				descriptor, ok := LookupGame(tt.givenDescriptor.Game)
+				assert.True(t, ok)
+				assert.Equal(t, tt.givenDescriptor, descriptor)
+			}
+		})
+	}
+}
+
+func TestUnregisterGame(t *testing.T) {
+	t.Run("unregisters registered game", func(t *testing.T) {
+		// GIVEN
+		var game Game = "pr"
+		unregisterGame(t, game)
+		require.NoError(t, RegisterStandaloneMod(game, "Project Reality"))
+
+		// WHEN
+		err := UnregisterGame(game)
+
+		// THEN
+		require.NoError(t, err)
+		_, ok := LookupGame(game)
+		assert.False(t, ok)
+		// Game can be registered again once it has been unregistered
+		require.NoError(t, RegisterStandaloneMod(game, "Project Reality"))
+	})
+
+	t.Run("error for game which is not registered", func(t *testing.T) {
+		// WHEN
+		err := UnregisterGame("not-a-registered-game")
+
+		// THEN
+		require.ErrorContains(t, err, "game not supported: not-a-registered-game")
+	})
+}
+
+func TestRegisteredGames(t *testing.T) {
+	// WHEN
+	registered := RegisteredGames()
+
+	// THEN
+	assert.Equal(t, []Game{GameBf1942, GameBf2, GameBf2142, GameBfVietnam}, registered)
+}
+
+func TestGameDescriptor_Supports(t *testing.T) {
+	type test struct {
+		name        string
+		givenGame   Game
+		givenAction Action
+		want        bool
+	}
+
+	tests := []test{
+		{
+			name:        "Refractor v2 game supports purging shader cache",
+			givenGame:   GameBf2,
+			givenAction: ActionPurgeShaderCache,
+			want:        true,
+		},
+		{
+			name:        "Refractor v2 game supports purging logo cache",
+			givenGame:   GameBf2142,
+			givenAction: ActionPurgeLogoCache,
+			want:        true,
+		},
+		{
+			name:        "Refractor v1 game does not support purging shader cache",
+			givenGame:   GameBf1942,
+			givenAction: ActionPurgeShaderCache,
+			want:        false,
+		},
+		{
+			name:        "Refractor v1 game does not support purging logo cache",
+			givenGame:   GameBfVietnam,
+			givenAction: ActionPurgeLogoCache,
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			descriptor, ok := LookupGame(tt.givenGame)
+			require.True(t, ok)
+
+			// WHEN
+			supported := descriptor.Supports(tt.givenAction)
+
+			// THEN
+			assert.Equal(t, tt.want, supported)
+		})
+	}
+}
+
+func TestGameDescriptor_SharesConfigFormat(t *testing.T) {
+	type test struct {
+		name            string
+		givenDescriptor GameDescriptor
+		givenGame       Game
+		want            bool
+	}
+
+	tests := []test{
+		{
+			name:            "game shares its own format",
+			givenDescriptor: GameDescriptor{Game: GameBf2},
+			givenGame:       GameBf2,
+			want:            true,
+		},
+		{
+			name:            "standalone mod shares format of base game",
+			givenDescriptor: StandaloneModDescriptor("pr", "Project Reality"),
+			givenGame:       GameBf2,
+			want:            true,
+		},
+		{
+			name:            "game does not share format of other game",
+			givenDescriptor: GameDescriptor{Game: GameBf2142},
+			givenGame:       GameBf2,
+			want:            false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			shares := tt.givenDescriptor.SharesConfigFormat(tt.givenGame)
+
+			// THEN
+			assert.Equal(t, tt.want, shares)
+		})
+	}
+}
+
+func TestHandler_RegisteredGame(t *testing.T) {
+	t.Run("works with profiles of a registered Refractor v2 game", func(t *testing.T) {
+		// GIVEN
+		var game Game = "test-v2"
+		unregisterGame(t, game)
+		require.NoError(t, RegisterGame(GameDescriptor{
+			Game:               game,
+			Engine:             EngineRefractorV2,
+			DirName:            "Test V2",
+			GlobalConFileName:  globalConFileName,
+			ProfileConFileName: profileConFileName,
+			LogoCachePattern:   v2LogoCachePattern,
+		}))
+		documentsPath := filepath.Join("build", "documents")
+		basePath := filepath.Join(documentsPath, "Test V2")
+		repo := repository.NewMemory()
+		require.NoError(t, repo.Load(fstest.MapFS{
+			"Profiles/Global.con":            {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+			"Profiles/0001/Profile.con":      {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			"LogoCache/some-host/banner.png": {Data: []byte{}},
+			"mods/bf2/cache/some-cache/a":    {Data: []byte{}},
+		}, basePath))
+		handler := New(repo, WithDocumentsPath(documentsPath))
+
+		// WHEN
+		profileKeys, err := handler.GetProfileKeys(game)
+		require.NoError(t, err)
+		globalCon, err := handler.ReadGlobalConfig(game)
+		require.NoError(t, err)
+		logoCacheErr := handler.PurgeLogoCache(game)
+		shaderCacheErr := handler.PurgeShaderCache(game)
+		_, installPathErr := handler.BuildInstallPath(game)
+
+		// THEN
+		assert.Equal(t, []string{"0001"}, profileKeys)
+		assert.Equal(t, filepath.Join(basePath, profilesDirName, globalConFileName), globalCon.Path)
+		require.NoError(t, logoCacheErr)
+		exists, err := repo.DirExists(filepath.Join(basePath, logoCacheDirName, "some-host"))
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.ErrorContains(t, shaderCacheErr, "PurgeShaderCache")
+		assert.ErrorContains(t, installPathErr, "BuildInstallPath")
+	})
+}
+
+func TestRegisterStandaloneMod(t *testing.T) {
+	t.Run("purges caches of a standalone mod", func(t *testing.T) {
+		// GIVEN
+		var game Game = "pr"
+		unregisterGame(t, game)
+		documentsPath := filepath.Join("build", "documents")
+		basePath := filepath.Join(documentsPath, "Project Reality")
+		repo := repository.NewMemory()
+		require.NoError(t, repo.Load(fstest.MapFS{
+			"Profiles/Global.con":            {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+			"Profiles/0001/Profile.con":      {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+			"LogoCache/some-host/banner.png": {Data: []byte{}},
+			"mods/pr/cache/some-cache/a.cfx": {Data: []byte{}},
+		}, basePath))
+		handler := New(repo, WithDocumentsPath(documentsPath))
+
+		// WHEN
+		err := RegisterStandaloneMod(game, "Project Reality")
+		require.NoError(t, err)
+		shaderCacheErr := handler.PurgeShaderCache(game)
+		logoCacheErr := handler.PurgeLogoCache(game)
+
+		// THEN
+		require.NoError(t, shaderCacheErr)
+		require.NoError(t, logoCacheErr)
+		for _, path := range []string{
+			filepath.Join(basePath, modsDirName, "pr", cacheDirName, "some-cache"),
+			filepath.Join(basePath, logoCacheDirName, "some-host"),
+		} {
+			exists, err := repo.DirExists(path)
+			require.NoError(t, err)
+			assert.False(t, exists, path)
+		}
+		exists, err := repo.FileExists(filepath.Join(basePath, profilesDirName, "0001", profileConFileName))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("error for mod without folder name", func(t *testing.T) {
+		// WHEN
+		err := RegisterStandaloneMod("pr", "")
+
+		// THEN
+		require.ErrorContains(t, err, "folder name must not be empty")
+	})
+}
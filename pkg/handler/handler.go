@@ -2,27 +2,61 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"golang.org/x/sys/windows"
+	"sync"
+	"time"
 
 	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/process"
 )
 
 type Game string
 
 const (
-	GameBf2 Game = "bf2"
+	GameBf2    Game = "bf2"
+	GameBf2142 Game = "bf2142"
+	// Refractor v1 games
+	GameBf1942    Game = "bf1942"
+	GameBfVietnam Game = "bfvietnam"
 
 	bf2GameDirName     = "Battlefield 2"
+	bf2142GameDirName  = "Battlefield 2142"
 	modsDirName        = "mods"
 	cacheDirName       = "cache"
 	logoCacheDirName   = "LogoCache"
 	profilesDirName    = "Profiles"
 	globalConFileName  = "Global.con"
 	profileConFileName = "Profile.con"
+	tmpFileSuffix      = ".tmp"
+	maxTmpFileAttempts = 10
+
+	// Refractor v1 games keep all configuration inside the install folder (Mods/[mod]/Settings/Profiles/[profile])
+	v1ModsDirName        = "Mods"
+	v1SettingsDirName    = "Settings"
+	v1GlobalConFileName  = "Profile.con"
+	v1ProfileConFileName = "Game.con"
+	bf1942DefaultMod     = "bf1942"
+	bfVietnamDefaultMod  = "BfVietnam"
+
+	// Install registry key paths (relative to HKEY_LOCAL_MACHINE\SOFTWARE)
+	bf2InstallKeyPath       = "Electronic Arts\\EA Games\\Battlefield 2"
+	bf2142InstallKeyPath    = "Electronic Arts\\EA Games\\Battlefield 2142"
+	bf1942InstallKeyPath    = "EA GAMES\\Battlefield 1942"
+	bfVietnamInstallKeyPath = "EA GAMES\\Battlefield Vietnam"
+
+	// Executables of running games
+	bf2Executable       = "BF2.exe"
+	bf2142Executable    = "BF2142.exe"
+	bf1942Executable    = "BF1942.exe"
+	bfVietnamExecutable = "BfVietnam.exe"
+
+	v2InstallDirValueName = "InstallDir"
+	v1InstallDirValueName = "GAMEDIR"
 )
 
 type FileRepository interface {
@@ -33,6 +67,12 @@ type FileRepository interface {
 	ReadDir(path string) ([]os.DirEntry, error)
 	Glob(pattern string) ([]string, error)
 	RemoveAll(path string) error
+	Stat(path string) (os.FileInfo, error)
+	Rename(oldpath string, newpath string) error
+	Sync(path string) error
+	SyncDir(path string) error
+	MkdirAll(path string, perm os.FileMode) error
+	CreateFile(path string, data []byte, perm os.FileMode) error
 }
 
 type ErrGameNotSupported struct {
@@ -53,13 +93,47 @@ func (e *ErrActionNotSupportedForGame) Error() string {
 }
 
 type Handler struct {
-	repository FileRepository
+	repository       FileRepository
+	documentsDirPath string
+	basePaths        map[Game]string
+	profilesPaths    map[Game]string
+	installPaths     map[Game]string
+	mods             map[Game]string
+	backupDirPath    string
+	backupRetain     int
+	plan             *Plan
+	detector         process.Detector
+	ignoreRunning    bool
+	lockTimeout      time.Duration
+	isAlive          func(pid int) bool
+	now              func() time.Time
+	sleep            func(d time.Duration)
+	tmpSuffix        func() string
+	// Folders owned by each game, resolved on first use (see gameRoots)
+	rootsMu sync.Mutex
+	roots   map[Game][]string
 }
 
-func New(repository FileRepository) *Handler {
-	return &Handler{
-		repository: repository,
+func New(repository FileRepository, options ...Option) *Handler {
+	h := &Handler{
+		repository:    repository,
+		basePaths:     map[Game]string{},
+		profilesPaths: map[Game]string{},
+		installPaths:  map[Game]string{},
+		mods:          map[Game]string{},
+		lockTimeout:   defaultLockTimeout,
+		isAlive:       process.IsAlive,
+		now:           time.Now,
+		sleep:         time.Sleep,
+		tmpSuffix:     randomTmpSuffix,
+		roots:         map[Game][]string{},
+	}
+
+	for _, option := range options {
+		option(h)
 	}
+
+	return h
 }
 
 // Read central profile configuration file (primarily contains reference to current default profile)
@@ -78,7 +152,7 @@ func (h *Handler) GetProfileKeys(game Game) ([]string, error) {
 		return nil, err
 	}
 
-	entries, err := h.repository.ReadDir(path)
+	entries, err := h.readDir(path)
 	if err != nil {
 		return nil, err
 	}
@@ -100,17 +174,14 @@ func (h *Handler) GetProfileKeys(game Game) ([]string, error) {
 }
 
 func (h *Handler) isValidProfileDir(game Game, basePath string, profileKey string) (bool, error) {
-	var conFileName string
-	switch game {
-	case GameBf2:
-		conFileName = profileConFileName
-	default:
-		return false, &ErrGameNotSupported{game: string(game)}
+	descriptor, err := getGame(game)
+	if err != nil {
+		return false, err
 	}
 
-	conFilePath := filepath.Join(basePath, profileKey, conFileName)
+	conFilePath := filepath.Join(basePath, profileKey, descriptor.ProfileConFileName)
 
-	return h.repository.FileExists(conFilePath)
+	return h.fileExists(conFilePath)
 }
 
 // Checks whether a given profile key is valid (a profile with the given key exists)
@@ -140,7 +211,7 @@ func (h *Handler) ReadProfileConfig(game Game, profileKey string) (*config.Confi
 
 // Read the config file at given path
 func (h *Handler) ReadConfigFile(path string) (*config.Config, error) {
-	data, err := h.repository.ReadFile(path)
+	data, err := h.readFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -148,37 +219,122 @@ func (h *Handler) ReadConfigFile(path string) (*config.Config, error) {
 	return config.FromBytes(path, data), nil
 }
 
-// Write the given config file to disk
+// Read the raw content of the file at given path (for files which must be copied as is rather than parsed)
+func (h *Handler) ReadFile(path string) ([]byte, error) {
+	return h.readFile(path)
+}
+
+// List the entries of the folder at the given path (taking planned changes into account in dry-run mode)
+func (h *Handler) ReadDir(path string) ([]os.DirEntry, error) {
+	return h.readDir(path)
+}
+
+// Write the given config file to disk (backing up the current file first, if backups are enabled; only planned in dry-run mode)
 func (h *Handler) WriteConfigFile(c *config.Config) error {
-	return h.repository.WriteFile(c.Path, c.ToBytes(), 0666)
+	return h.writeFile(c.Path, c.ToBytes())
 }
 
-// Delete all shader cache (.cfx) files [Refractor v2 games only]
+func (h *Handler) writeFile(path string, data []byte) error {
+	if h.plan != nil {
+		return h.planWrite(path, data)
+	}
+
+	if err := h.checkNotRunning(path); err != nil {
+		return err
+	}
+
+	if err := h.backupFile(path); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	return h.writeFileAtomic(path, data)
+}
+
+// writeFileAtomic Write data to a temporary file in the same folder and replace the original file only after the data
+// has been committed to disk, so a crash mid-write can never leave a truncated file behind
+func (h *Handler) writeFileAtomic(path string, data []byte) error {
+	// Preserve the original file's permissions (if there is an original file)
+	perm := os.FileMode(0666)
+	info, err := h.repository.Stat(path)
+	if err == nil {
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	tmpPath, err := h.createTmpFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+
+	if err = h.repository.Sync(tmpPath); err != nil {
+		_ = h.repository.RemoveAll(tmpPath)
+		return err
+	}
+
+	if err = h.repository.Rename(tmpPath, path); err != nil {
+		_ = h.repository.RemoveAll(tmpPath)
+		return err
+	}
+
+	// The rename itself is only durable once the folder has been committed to disk as well
+	return h.repository.SyncDir(filepath.Dir(path))
+}
+
+// createTmpFile Create a new temporary file next to the given path, using a unique name so concurrent writers never
+// share a temporary file
+func (h *Handler) createTmpFile(path string, data []byte, perm os.FileMode) (string, error) {
+	for i := 0; ; i++ {
+		tmpPath := fmt.Sprintf("%s.%s%s", path, h.tmpSuffix(), tmpFileSuffix)
+		err := h.repository.CreateFile(tmpPath, data, perm)
+		if err == nil {
+			return tmpPath, nil
+		}
+		if !errors.Is(err, os.ErrExist) || i >= maxTmpFileAttempts-1 {
+			return "", err
+		}
+	}
+}
+
+func randomTmpSuffix() string {
+	b := make([]byte, 8)
+	// Never returns an error (see crypto/rand.Read)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Delete all shader cache (.cfx) files
 func (h *Handler) PurgeShaderCache(game Game) error {
-	if !isSupportedGame(game) {
-		return &ErrGameNotSupported{game: string(game)}
+	return h.purgeCache(game, ActionPurgeShaderCache)
+}
+
+// Delete all cached server banner images
+func (h *Handler) PurgeLogoCache(game Game) error {
+	return h.purgeCache(game, ActionPurgeLogoCache)
+}
+
+func (h *Handler) purgeCache(game Game, action Action) error {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return err
 	}
-	if !isRefractorV2Game(game) {
+	if !descriptor.Supports(action) {
 		return &ErrActionNotSupportedForGame{
-			action: "PurgeShaderCache",
+			action: string(action),
 			game:   string(game),
 		}
 	}
 
-	/*
-		Looking from the base path, the shader cache files are stored in:
-		mods/
-		├──[mod]/
-		   ├──cache/
-			  ├──[cache dir with uuid-looking name]/
-				 ├──[cache file].cfx
-		Best practise is to delete all folder inside each mod's /cache directory
-	*/
+	pattern := descriptor.ShaderCachePattern
+	if action == ActionPurgeLogoCache {
+		pattern = descriptor.LogoCachePattern
+	}
+
 	basePath, err := h.BuildBasePath(game)
 	if err != nil {
 		return err
 	}
-	return h.globRemoveAll(filepath.Join(basePath, modsDirName, "*", cacheDirName, "*"))
+	return h.globRemoveAll(filepath.Join(basePath, pattern))
 }
 
 func (h *Handler) globRemoveAll(pattern string) error {
@@ -188,8 +344,7 @@ func (h *Handler) globRemoveAll(pattern string) error {
 	}
 
 	for _, match := range matches {
-		err = h.repository.RemoveAll(match)
-		if err != nil {
+		if err = h.removeAll(match); err != nil {
 			return err
 		}
 	}
@@ -197,44 +352,77 @@ func (h *Handler) globRemoveAll(pattern string) error {
 	return nil
 }
 
-// Delete all cached server banner images [Refractor v2 games only]
-func (h *Handler) PurgeLogoCache(game Game) error {
-	if !isSupportedGame(game) {
-		return &ErrGameNotSupported{game: string(game)}
+// removeAll Remove the file or folder at the given path (only recording the removal in dry-run mode)
+func (h *Handler) removeAll(path string) error {
+	if h.plan != nil {
+		return h.planRemoval(path)
 	}
-	if !isRefractorV2Game(game) {
-		return &ErrActionNotSupportedForGame{
-			action: "PurgeShaderCache",
-			game:   string(game),
-		}
-	}
-
-	/*
-		Looking from the base path, the logo cache files are stored in:
-		LogoCache/
-		├──[server hosting banner image]/
-		   ├──[...path to file on server]/
-		Simply delete all folders in LogoCache/
-	*/
-	basePath, err := h.BuildBasePath(game)
-	if err != nil {
+	if err := h.checkNotRunning(path); err != nil {
 		return err
 	}
-	return h.globRemoveAll(filepath.Join(basePath, logoCacheDirName, "*"))
+	return h.repository.RemoveAll(path)
 }
 
 // Build path to the root folder for given game's configuration
 func (h *Handler) BuildBasePath(game Game) (string, error) {
-	switch game {
-	case GameBf2:
-		return buildV2BasePath(bf2GameDirName)
-	default:
-		return "", &ErrGameNotSupported{game: string(game)}
+	descriptor, err := getGame(game)
+	if err != nil {
+		return "", err
 	}
+
+	// Explicitly configured base paths take precedence over the default location
+	if basePath, ok := h.basePaths[game]; ok {
+		return basePath, nil
+	}
+
+	if descriptor.Engine == EngineRefractorV1 {
+		// Refractor v1 games do not store any configuration outside the install folder
+		return h.BuildInstallPath(game)
+	}
+
+	return h.buildV2BasePath(descriptor.DirName)
+}
+
+// Build path to the given game's install folder (as configured or recorded in the registry)
+func (h *Handler) BuildInstallPath(game Game) (string, error) {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return "", err
+	}
+
+	if installPath, ok := h.installPaths[game]; ok {
+		return installPath, nil
+	}
+
+	if descriptor.InstallKeyPath == "" {
+		return "", &ErrActionNotSupportedForGame{
+			action: "BuildInstallPath",
+			game:   string(game),
+		}
+	}
+
+	return h.getInstallDirPath(descriptor.InstallKeyPath, descriptor.InstallValueName)
 }
 
 // Build path to the folder containing given game's profile configuration
 func (h *Handler) BuildProfilesFolderPath(game Game) (string, error) {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return "", err
+	}
+
+	if profilesPath, ok := h.profilesPaths[game]; ok {
+		return profilesPath, nil
+	}
+
+	if descriptor.Engine == EngineRefractorV1 {
+		settingsPath, err := h.buildV1SettingsPath(game)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(settingsPath, profilesDirName), nil
+	}
+
 	basePath, err := h.BuildBasePath(game)
 	if err != nil {
 		return "", err
@@ -242,63 +430,85 @@ func (h *Handler) BuildProfilesFolderPath(game Game) (string, error) {
 	return filepath.Join(basePath, profilesDirName), nil
 }
 
-func (h *Handler) buildGlobalConfigPath(game Game) (string, error) {
-	switch game {
-	case GameBf2:
-		return h.buildV2GlobalConfigPath(game)
-	default:
-		return "", &ErrGameNotSupported{game: string(game)}
+// getMod Get the mod whose profiles are used for the given game [Refractor v1 games only]
+func (h *Handler) getMod(game Game) (string, error) {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return "", err
 	}
+
+	if mod, ok := h.mods[game]; ok {
+		return mod, nil
+	}
+
+	return descriptor.DefaultMod, nil
 }
 
-func (h *Handler) buildV2GlobalConfigPath(game Game) (string, error) {
-	profilesPath, err := h.BuildProfilesFolderPath(game)
+func (h *Handler) buildGlobalConfigPath(game Game) (string, error) {
+	descriptor, err := getGame(game)
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(profilesPath, globalConFileName), nil
+
+	if descriptor.Engine == EngineRefractorV1 {
+		return h.buildV1GlobalConfigPath(descriptor)
+	}
+	return h.buildV2GlobalConfigPath(descriptor)
 }
 
-func (h *Handler) buildProfileConfigPath(game Game, profileKey string) (string, error) {
-	switch game {
-	case GameBf2:
-		return h.buildV2ProfileConfigPath(game, profileKey)
-	default:
-		return "", &ErrGameNotSupported{game: string(game)}
+func (h *Handler) buildV2GlobalConfigPath(descriptor GameDescriptor) (string, error) {
+	profilesPath, err := h.BuildProfilesFolderPath(descriptor.Game)
+	if err != nil {
+		return "", err
 	}
+	return filepath.Join(profilesPath, descriptor.GlobalConFileName), nil
 }
 
-func (h *Handler) buildV2ProfileConfigPath(game Game, profileKey string) (string, error) {
-	basePath, err := h.BuildProfilesFolderPath(game)
+func (h *Handler) buildV1GlobalConfigPath(descriptor GameDescriptor) (string, error) {
+	settingsPath, err := h.buildV1SettingsPath(descriptor.Game)
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(basePath, profileKey, profileConFileName), nil
+	return filepath.Join(settingsPath, descriptor.GlobalConFileName), nil
 }
 
-func buildV2BasePath(gameDirName string) (string, error) {
-	documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
+// Profile config files are stored in the profile's folder for all engine versions
+func (h *Handler) buildProfileConfigPath(game Game, profileKey string) (string, error) {
+	descriptor, err := getGame(game)
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(documentsDirPath, gameDirName), nil
+	profilesPath, err := h.BuildProfilesFolderPath(game)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(profilesPath, profileKey, descriptor.ProfileConFileName), nil
 }
 
-func isSupportedGame(game Game) bool {
-	switch game {
-	case GameBf2:
-		return true
-	default:
-		return false
+func (h *Handler) buildV1SettingsPath(game Game) (string, error) {
+	basePath, err := h.BuildBasePath(game)
+	if err != nil {
+		return "", err
+	}
+
+	mod, err := h.getMod(game)
+	if err != nil {
+		return "", err
 	}
+
+	return filepath.Join(basePath, v1ModsDirName, mod, v1SettingsDirName), nil
 }
 
-func isRefractorV2Game(game Game) bool {
-	switch game {
-	case GameBf2:
-		return true
-	default:
-		return false
+func (h *Handler) buildV2BasePath(gameDirName string) (string, error) {
+	if h.documentsDirPath != "" {
+		return filepath.Join(h.documentsDirPath, gameDirName), nil
 	}
+
+	documentsDirPath, err := h.getDocumentsDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(documentsDirPath, gameDirName), nil
 }
@@ -33,6 +33,20 @@ func (m *MockFileRepository) EXPECT() *MockFileRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CreateFile mocks base method.
+func (m *MockFileRepository) CreateFile(path string, data []byte, perm os.FileMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFile", path, data, perm)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateFile indicates an expected call of CreateFile.
+func (mr *MockFileRepositoryMockRecorder) CreateFile(path, data, perm interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*MockFileRepository)(nil).CreateFile), path, data, perm)
+}
+
 // DirExists mocks base method.
 func (m *MockFileRepository) DirExists(path string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -78,6 +92,20 @@ func (mr *MockFileRepositoryMockRecorder) Glob(pattern interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Glob", reflect.TypeOf((*MockFileRepository)(nil).Glob), pattern)
 }
 
+// MkdirAll mocks base method.
+func (m *MockFileRepository) MkdirAll(path string, perm os.FileMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MkdirAll", path, perm)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MkdirAll indicates an expected call of MkdirAll.
+func (mr *MockFileRepositoryMockRecorder) MkdirAll(path, perm interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MkdirAll", reflect.TypeOf((*MockFileRepository)(nil).MkdirAll), path, perm)
+}
+
 // ReadDir mocks base method.
 func (m *MockFileRepository) ReadDir(path string) ([]os.DirEntry, error) {
 	m.ctrl.T.Helper()
@@ -122,6 +150,63 @@ func (mr *MockFileRepositoryMockRecorder) RemoveAll(path interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAll", reflect.TypeOf((*MockFileRepository)(nil).RemoveAll), path)
 }
 
+// Rename mocks base method.
+func (m *MockFileRepository) Rename(oldpath, newpath string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", oldpath, newpath)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockFileRepositoryMockRecorder) Rename(oldpath, newpath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockFileRepository)(nil).Rename), oldpath, newpath)
+}
+
+// Stat mocks base method.
+func (m *MockFileRepository) Stat(path string) (os.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat", path)
+	ret0, _ := ret[0].(os.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockFileRepositoryMockRecorder) Stat(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockFileRepository)(nil).Stat), path)
+}
+
+// Sync mocks base method.
+func (m *MockFileRepository) Sync(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sync", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Sync indicates an expected call of Sync.
+func (mr *MockFileRepositoryMockRecorder) Sync(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockFileRepository)(nil).Sync), path)
+}
+
+// SyncDir mocks base method.
+func (m *MockFileRepository) SyncDir(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncDir", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncDir indicates an expected call of SyncDir.
+func (mr *MockFileRepositoryMockRecorder) SyncDir(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDir", reflect.TypeOf((*MockFileRepository)(nil).SyncDir), path)
+}
+
 // WriteFile mocks base method.
 func (m *MockFileRepository) WriteFile(path string, data []byte, perm os.FileMode) error {
 	m.ctrl.T.Helper()
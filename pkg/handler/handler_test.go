@@ -11,7 +11,6 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"golang.org/x/sys/windows"
 
 	"github.com/cetteup/conman/pkg/config"
 )
@@ -51,9 +50,8 @@ func TestHandler_ReadGlobalConfig(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			_, handler, mockRepository := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			_, handler, mockRepository := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// EXPECT
 			tt.expect(mockRepository, documentsDirPath)
@@ -149,9 +147,8 @@ func TestHandler_GetProfileKeys(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			ctrl, handler, mockRepository := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			ctrl, handler, mockRepository := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// EXPECT
 			tt.expect(ctrl, mockRepository, documentsDirPath)
@@ -233,9 +230,8 @@ func TestHandler_IsValidProfileKey(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			ctrl, handler, mockRepository := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			ctrl, handler, mockRepository := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// EXPECT
 			tt.expect(ctrl, mockRepository, documentsDirPath)
@@ -292,9 +288,8 @@ func TestHandler_ReadProfileConfig(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			_, handler, mockRepository := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			_, handler, mockRepository := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// EXPECT
 			tt.expect(mockRepository, documentsDirPath)
@@ -317,33 +312,145 @@ func TestHandler_WriteConfigFile(t *testing.T) {
 	type test struct {
 		name            string
 		givenConfig     *config.Config
-		expect          func(repository *MockFileRepository)
+		expect          func(ctrl *gomock.Controller, repository *MockFileRepository)
 		wantErrContains string
 	}
 
+	path := filepath.FromSlash("C:/Users/default/Documents/Battlefield 2/Profiles/Global.con")
+	tmpPath := testTmpPath(path)
+	data := []byte("GlobalSettings.setNamePrefix \"=DOG=\"\r\n")
 	tests := []test{
 		{
-			name: "successfully writes config file",
+			name: "successfully writes config file via temporary file",
 			givenConfig: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+				path,
 				map[string]config.Value{
 					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
 				},
 			),
-			expect: func(repository *MockFileRepository) {
-				repository.EXPECT().WriteFile("C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con", []byte("GlobalSettings.setNamePrefix \"=DOG=\"\r\n"), os.FileMode(0666)).Return(nil)
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				fileInfo := NewMockFileInfo(ctrl)
+				fileInfo.EXPECT().Mode().Return(os.FileMode(0640))
+				gomock.InOrder(
+					repository.EXPECT().Stat(path).Return(fileInfo, nil),
+					repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0640)).Return(nil),
+					repository.EXPECT().Sync(tmpPath).Return(nil),
+					repository.EXPECT().Rename(tmpPath, path).Return(nil),
+					repository.EXPECT().SyncDir(filepath.Dir(path)).Return(nil),
+				)
 			},
 		},
 		{
-			name: "error writing config file",
+			name: "successfully writes new config file with default permissions",
 			givenConfig: config.New(
-				"C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con",
+				path,
 				map[string]config.Value{
 					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
 				},
 			),
-			expect: func(repository *MockFileRepository) {
-				repository.EXPECT().WriteFile("C:\\Users\\default\\Documents\\Battlefield 2\\Profiles\\Global.con", []byte("GlobalSettings.setNamePrefix \"=DOG=\"\r\n"), os.FileMode(0666)).Return(fmt.Errorf("some-error"))
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				gomock.InOrder(
+					repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist),
+					repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(nil),
+					repository.EXPECT().Sync(tmpPath).Return(nil),
+					repository.EXPECT().Rename(tmpPath, path).Return(nil),
+					repository.EXPECT().SyncDir(filepath.Dir(path)).Return(nil),
+				)
+			},
+		},
+		{
+			name: "error determining permissions of config file",
+			givenConfig: config.New(
+				path,
+				map[string]config.Value{
+					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
+				},
+			),
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().Stat(path).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name: "error writing temporary file",
+			givenConfig: config.New(
+				path,
+				map[string]config.Value{
+					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
+				},
+			),
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist)
+				repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name: "error syncing temporary file removes temporary file",
+			givenConfig: config.New(
+				path,
+				map[string]config.Value{
+					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
+				},
+			),
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist)
+				repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(nil)
+				repository.EXPECT().Sync(tmpPath).Return(fmt.Errorf("some-error"))
+				repository.EXPECT().RemoveAll(tmpPath).Return(nil)
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name: "error replacing config file removes temporary file",
+			givenConfig: config.New(
+				path,
+				map[string]config.Value{
+					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
+				},
+			),
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist)
+				repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(nil)
+				repository.EXPECT().Sync(tmpPath).Return(nil)
+				repository.EXPECT().Rename(tmpPath, path).Return(fmt.Errorf("some-error"))
+				repository.EXPECT().RemoveAll(tmpPath).Return(nil)
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name: "retries with another temporary file if temporary file already exists",
+			givenConfig: config.New(
+				path,
+				map[string]config.Value{
+					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
+				},
+			),
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				gomock.InOrder(
+					repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist),
+					repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(os.ErrExist),
+					repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(nil),
+					repository.EXPECT().Sync(tmpPath).Return(nil),
+					repository.EXPECT().Rename(tmpPath, path).Return(nil),
+					repository.EXPECT().SyncDir(filepath.Dir(path)).Return(nil),
+				)
+			},
+		},
+		{
+			name: "error syncing folder after replacing config file",
+			givenConfig: config.New(
+				path,
+				map[string]config.Value{
+					"GlobalSettings.setNamePrefix": *config.NewValue("\"=DOG=\""),
+				},
+			),
+			expect: func(ctrl *gomock.Controller, repository *MockFileRepository) {
+				repository.EXPECT().Stat(path).Return(nil, os.ErrNotExist)
+				repository.EXPECT().CreateFile(tmpPath, data, os.FileMode(0666)).Return(nil)
+				repository.EXPECT().Sync(tmpPath).Return(nil)
+				repository.EXPECT().Rename(tmpPath, path).Return(nil)
+				repository.EXPECT().SyncDir(filepath.Dir(path)).Return(fmt.Errorf("some-error"))
 			},
 			wantErrContains: "some-error",
 		},
@@ -352,10 +459,10 @@ func TestHandler_WriteConfigFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			_, handler, mockRepository := getHandlerWithDependencies(t)
+			ctrl, handler, mockRepository := getHandlerWithDependencies(t)
 
 			// EXPECT
-			tt.expect(mockRepository)
+			tt.expect(ctrl, mockRepository)
 
 			// WHEN
 			err := handler.WriteConfigFile(tt.givenConfig)
@@ -440,15 +547,14 @@ func TestHandler_PurgeShaderCache(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			_, handler, mockRepository := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			_, handler, mockRepository := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// EXPECT
 			tt.expect(mockRepository, documentsDirPath)
 
 			// WHEN
-			err = handler.PurgeShaderCache(tt.givenGame)
+			err := handler.PurgeShaderCache(tt.givenGame)
 
 			// THEN
 			if tt.wantErrContains != "" {
@@ -530,15 +636,14 @@ func TestHandler_PurgeLogoCache(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			_, handler, mockRepository := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			_, handler, mockRepository := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// EXPECT
 			tt.expect(mockRepository, documentsDirPath)
 
 			// WHEN
-			err = handler.PurgeLogoCache(tt.givenGame)
+			err := handler.PurgeLogoCache(tt.givenGame)
 
 			// THEN
 			if tt.wantErrContains != "" {
@@ -562,7 +667,7 @@ func TestHandler_BuildProfilesFolderPath(t *testing.T) {
 		{
 			name:                     "builds base path for Battlefield 2",
 			givenGame:                GameBf2,
-			expectedPathFromDocument: "Battlefield 2\\Profiles",
+			expectedPathFromDocument: filepath.Join("Battlefield 2", "Profiles"),
 		},
 		{
 			name:            "error for unsupported game",
@@ -574,9 +679,8 @@ func TestHandler_BuildProfilesFolderPath(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// GIVEN
-			_, handler, _ := getHandlerWithDependencies(t)
-			documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
-			require.NoError(t, err)
+			documentsDirPath := filepath.FromSlash("C:/Users/default/Documents")
+			_, handler, _ := getHandlerWithDependencies(t, WithDocumentsPath(documentsDirPath))
 
 			// WHEN
 			basePath, err := handler.BuildProfilesFolderPath(tt.givenGame)
@@ -591,9 +695,3 @@ func TestHandler_BuildProfilesFolderPath(t *testing.T) {
 		})
 	}
 }
-
-func getHandlerWithDependencies(t *testing.T) (*gomock.Controller, *Handler, *MockFileRepository) {
-	ctrl := gomock.NewController(t)
-	mockRepository := NewMockFileRepository(ctrl)
-	return ctrl, New(mockRepository), mockRepository
-}
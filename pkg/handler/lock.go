@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cetteup/conman/pkg/config"
+)
+
+const (
+	lockFileName       = "conman.lock"
+	defaultLockTimeout = 10 * time.Second
+	lockRetryInterval  = 100 * time.Millisecond
+	// Locks are only held for a single read-modify-write sequence, so any lock this old was left behind by a process
+	// which crashed or hung
+	lockStaleAfter = 10 * time.Minute
+)
+
+type ErrLocked struct {
+	path    string
+	pid     int
+	host    string
+	created time.Time
+}
+
+func (e *ErrLocked) Error() string {
+	return fmt.Sprintf("folder is locked by another process: %s (pid %d on %s since %s)", e.path, e.pid, e.host, e.created.Format(time.RFC3339))
+}
+
+// lockInfo Contents of a lock file
+type lockInfo struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Created time.Time `json:"created"`
+}
+
+// Lock Advisory lock on a folder, preventing other conman processes from modifying files in the folder
+type Lock struct {
+	h    *Handler
+	path string
+	// Lock file content, used to make sure only our own lock file is removed on release
+	data     []byte
+	released bool
+}
+
+// Release the lock (releasing a lock more than once has no effect)
+func (l *Lock) Release() error {
+	if l.h == nil || l.released {
+		return nil
+	}
+	l.released = true
+
+	data, err := l.h.repository.ReadFile(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// Another process considered the lock stale and took it over, so the lock file is no longer ours to remove
+	if !bytes.Equal(data, l.data) {
+		return nil
+	}
+
+	return l.h.repository.RemoveAll(l.path)
+}
+
+// Follow the locked folder to the path it was moved to (along with the lock file), so releasing the lock removes the
+// lock file from the folder's new location instead of leaving it behind as a stale lock
+func (l *Lock) Follow(dirPath string) {
+	l.path = filepath.Join(dirPath, lockFileName)
+}
+
+// Lock the given profile's folder (see LockDir)
+func (h *Handler) LockProfile(game Game, profileKey string) (*Lock, error) {
+	profilesPath, err := h.BuildProfilesFolderPath(game)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.LockDir(filepath.Join(profilesPath, profileKey))
+}
+
+// Acquire an advisory lock on the given folder, waiting for another process to release it for up to the lock timeout
+// (locks are not reentrant; in dry-run mode, no lock file is written and locking always succeeds)
+func (h *Handler) LockDir(dirPath string) (*Lock, error) {
+	if h.plan != nil {
+		return &Lock{}, nil
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.Marshal(lockInfo{
+		PID:     os.Getpid(),
+		Host:    host,
+		Created: h.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dirPath, lockFileName)
+	deadline := h.now().Add(h.lockTimeout)
+	for {
+		err = h.repository.CreateFile(path, data, 0666)
+		if err == nil {
+			return &Lock{h: h, path: path, data: data}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		held, heldData, err := h.readLock(path)
+		if err != nil {
+			// Lock was released in the meantime
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		if h.isStale(held, host) {
+			if err = h.removeStaleLock(path, heldData); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !h.now().Before(deadline) {
+			return nil, &ErrLocked{
+				path:    dirPath,
+				pid:     held.PID,
+				host:    held.Host,
+				created: held.Created,
+			}
+		}
+
+		h.sleep(lockRetryInterval)
+	}
+}
+
+// Read, modify and write the given config file while holding a lock on the folder containing it
+func (h *Handler) UpdateConfigFile(path string, update func(c *config.Config) error) (err error) {
+	lock, err := h.LockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if releaseErr := lock.Release(); err == nil {
+			err = releaseErr
+		}
+	}()
+
+	c, err := h.ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err = update(c); err != nil {
+		return err
+	}
+
+	return h.WriteConfigFile(c)
+}
+
+// Read, modify and write the given game's central profile configuration file while holding a lock on its folder
+func (h *Handler) UpdateGlobalConfig(game Game, update func(c *config.Config) error) error {
+	path, err := h.buildGlobalConfigPath(game)
+	if err != nil {
+		return err
+	}
+
+	return h.UpdateConfigFile(path, update)
+}
+
+// readLock Read the lock file at the given path (lock files which cannot be parsed are considered to be held by an
+// unknown process since their last modification, since they may still be being written)
+func (h *Handler) readLock(path string) (lockInfo, []byte, error) {
+	data, err := h.repository.ReadFile(path)
+	if err != nil {
+		return lockInfo{}, nil, err
+	}
+
+	var info lockInfo
+	if err = json.Unmarshal(data, &info); err != nil {
+		stat, err := h.repository.Stat(path)
+		if err != nil {
+			return lockInfo{}, nil, err
+		}
+		return lockInfo{Created: stat.ModTime()}, data, nil
+	}
+
+	return info, data, nil
+}
+
+// isStale Whether the given lock has been held for too long or by a process (on this host) which no longer exists
+func (h *Handler) isStale(info lockInfo, host string) bool {
+	if h.now().Sub(info.Created) > lockStaleAfter {
+		return true
+	}
+
+	return info.PID != 0 && info.Host == host && !h.isAlive(info.PID)
+}
+
+// removeStaleLock Remove a stale lock file, unless it has been replaced since it was read
+func (h *Handler) removeStaleLock(path string, staleData []byte) error {
+	data, err := h.repository.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if !bytes.Equal(data, staleData) {
+		return nil
+	}
+
+	return h.repository.RemoveAll(path)
+}
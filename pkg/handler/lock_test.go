@@ -0,0 +1,322 @@
+//go:build unit
+
+package handler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestHandler_LockDir(t *testing.T) {
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	profilePath := filepath.Join(basePath, profilesDirName, "0001")
+	lockPath := filepath.Join(profilePath, lockFileName)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	host, _ := os.Hostname()
+	otherPID := os.Getpid() + 1
+
+	lockData := func(t *testing.T, info lockInfo) []byte {
+		data, err := json.Marshal(info)
+		require.NoError(t, err)
+		return data
+	}
+
+	type test struct {
+		name            string
+		givenLock       []byte
+		givenAlive      bool
+		givenTimeout    time.Duration
+		givenOnSleep    func(repo *repository.MemoryRepository)
+		wantSleeps      int
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "acquires unlocked folder",
+		},
+		{
+			name:         "waits for other process to release lock",
+			givenLock:    lockData(t, lockInfo{PID: otherPID, Host: host, Created: now}),
+			givenAlive:   true,
+			givenTimeout: time.Second,
+			givenOnSleep: func(repo *repository.MemoryRepository) {
+				_ = repo.RemoveAll(lockPath)
+			},
+			wantSleeps: 1,
+		},
+		{
+			name:            "error if lock is not released before timeout",
+			givenLock:       lockData(t, lockInfo{PID: otherPID, Host: host, Created: now}),
+			givenAlive:      true,
+			givenTimeout:    time.Second,
+			wantSleeps:      10,
+			wantErrContains: "folder is locked by another process",
+		},
+		{
+			name:            "error without waiting if timeout is zero",
+			givenLock:       lockData(t, lockInfo{PID: otherPID, Host: host, Created: now}),
+			givenAlive:      true,
+			wantErrContains: "folder is locked by another process",
+		},
+		{
+			name:      "takes over lock of process which no longer exists",
+			givenLock: lockData(t, lockInfo{PID: otherPID, Host: host, Created: now}),
+		},
+		{
+			name:      "takes over lock held for too long",
+			givenLock: lockData(t, lockInfo{PID: otherPID, Host: host, Created: now.Add(-lockStaleAfter - time.Second)}),
+			// Process may still exist, but the lock is too old anyway
+			givenAlive: true,
+		},
+		{
+			name:            "does not take over lock of process on other host",
+			givenLock:       lockData(t, lockInfo{PID: otherPID, Host: host + "-other", Created: now}),
+			wantErrContains: "folder is locked by another process",
+		},
+		{
+			name:            "considers lock file which cannot be parsed as held",
+			givenLock:       []byte("{\"pid\":"),
+			wantErrContains: "folder is locked by another process",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.MkdirAll(profilePath, 0777))
+			if tt.givenLock != nil {
+				require.NoError(t, repo.WriteFile(lockPath, tt.givenLock, 0666))
+				require.NoError(t, repo.Chtimes(lockPath, now, now))
+			}
+			handler := New(repo, WithLockTimeout(tt.givenTimeout))
+			clock := now
+			handler.now = func() time.Time {
+				return clock
+			}
+			handler.isAlive = func(pid int) bool {
+				return tt.givenAlive
+			}
+			var sleeps int
+			handler.sleep = func(d time.Duration) {
+				sleeps++
+				clock = clock.Add(d)
+				if tt.givenOnSleep != nil {
+					tt.givenOnSleep(repo)
+				}
+			}
+
+			// WHEN
+			lock, err := handler.LockDir(profilePath)
+
+			// THEN
+			assert.Equal(t, tt.wantSleeps, sleeps)
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+				var lockedErr *ErrLocked
+				assert.ErrorAs(t, err, &lockedErr)
+				data, err := repo.ReadFile(lockPath)
+				require.NoError(t, err)
+				assert.Equal(t, tt.givenLock, data)
+				return
+			}
+
+			require.NoError(t, err)
+			data, err := repo.ReadFile(lockPath)
+			require.NoError(t, err)
+			var info lockInfo
+			require.NoError(t, json.Unmarshal(data, &info))
+			assert.Equal(t, os.Getpid(), info.PID)
+			assert.Equal(t, host, info.Host)
+
+			require.NoError(t, lock.Release())
+			exists, err := repo.FileExists(lockPath)
+			require.NoError(t, err)
+			assert.False(t, exists)
+			require.NoError(t, lock.Release())
+		})
+	}
+}
+
+func TestLock_Release(t *testing.T) {
+	t.Run("does not remove lock taken over by other process", func(t *testing.T) {
+		// GIVEN
+		repo := repository.NewMemory()
+		require.NoError(t, repo.MkdirAll("profile", 0777))
+		handler := New(repo)
+		lock, err := handler.LockDir("profile")
+		require.NoError(t, err)
+		lockPath := filepath.Join("profile", lockFileName)
+		require.NoError(t, repo.WriteFile(lockPath, []byte("{\"pid\":1}"), 0666))
+
+		// WHEN
+		err = lock.Release()
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repo.ReadFile(lockPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("{\"pid\":1}"), data)
+	})
+
+	t.Run("removes lock file from folder's new location after following it", func(t *testing.T) {
+		// GIVEN
+		repo := repository.NewMemory()
+		require.NoError(t, repo.MkdirAll("profile", 0777))
+		handler := New(repo)
+		lock, err := handler.LockDir("profile")
+		require.NoError(t, err)
+		require.NoError(t, repo.Rename("profile", "moved"))
+		lock.Follow("moved")
+
+		// WHEN
+		err = lock.Release()
+
+		// THEN
+		require.NoError(t, err)
+		exists, err := repo.FileExists(filepath.Join("moved", lockFileName))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("does not write lock file in dry-run mode", func(t *testing.T) {
+		// GIVEN
+		repo := repository.NewMemory()
+		require.NoError(t, repo.MkdirAll("profile", 0777))
+		handler := New(repo, WithDryRun())
+
+		// WHEN
+		lock, err := handler.LockDir("profile")
+
+		// THEN
+		require.NoError(t, err)
+		exists, err := repo.FileExists(filepath.Join("profile", lockFileName))
+		require.NoError(t, err)
+		assert.False(t, exists)
+		require.NoError(t, lock.Release())
+	})
+}
+
+func TestHandler_UpdateConfigFile(t *testing.T) {
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	profilesPath := filepath.Join(basePath, profilesDirName)
+	generalConPath := filepath.Join(profilesPath, "0001", "General.con")
+
+	setup := func(t *testing.T) (*Handler, *repository.MemoryRepository) {
+		repo := repository.NewMemory()
+		require.NoError(t, repo.Load(fstest.MapFS{
+			"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+			"Profiles/0001/General.con": {Data: []byte("GeneralSettings.addServerHistory \"1.1.1.1\"\r\n")},
+		}, basePath))
+		return New(repo, WithBasePath(GameBf2, basePath), WithLockTimeout(0)), repo
+	}
+
+	t.Run("updates file while holding lock", func(t *testing.T) {
+		// GIVEN
+		handler, repo := setup(t)
+		var lockedDuringUpdate bool
+
+		// WHEN
+		err := handler.UpdateConfigFile(generalConPath, func(c *config.Config) error {
+			lockedDuringUpdate, _ = repo.FileExists(filepath.Join(profilesPath, "0001", lockFileName))
+			c.Delete("GeneralSettings.addServerHistory")
+			return nil
+		})
+
+		// THEN
+		require.NoError(t, err)
+		assert.True(t, lockedDuringUpdate)
+		data, err := repo.ReadFile(generalConPath)
+		require.NoError(t, err)
+		assert.Empty(t, data)
+		exists, err := repo.FileExists(filepath.Join(profilesPath, "0001", lockFileName))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("updates global config while holding lock on profiles folder", func(t *testing.T) {
+		// GIVEN
+		handler, repo := setup(t)
+		var lockedDuringUpdate bool
+
+		// WHEN
+		err := handler.UpdateGlobalConfig(GameBf2, func(c *config.Config) error {
+			lockedDuringUpdate, _ = repo.FileExists(filepath.Join(profilesPath, lockFileName))
+			c.SetValue("GlobalSettings.setDefaultUser", *config.NewQuotedValue("0002"))
+			return nil
+		})
+
+		// THEN
+		require.NoError(t, err)
+		assert.True(t, lockedDuringUpdate)
+		data, err := repo.ReadFile(filepath.Join(profilesPath, globalConFileName))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0002\"\r\n"), data)
+	})
+
+	t.Run("error if folder is locked", func(t *testing.T) {
+		// GIVEN
+		handler, repo := setup(t)
+		handler.isAlive = func(pid int) bool {
+			return true
+		}
+		other, err := handler.LockProfile(GameBf2, "0001")
+		require.NoError(t, err)
+		var updated bool
+
+		// WHEN
+		err = handler.UpdateConfigFile(generalConPath, func(c *config.Config) error {
+			updated = true
+			return nil
+		})
+
+		// THEN
+		var lockedErr *ErrLocked
+		require.ErrorAs(t, err, &lockedErr)
+		assert.False(t, updated)
+		require.NoError(t, other.Release())
+		data, err := repo.ReadFile(generalConPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("GeneralSettings.addServerHistory \"1.1.1.1\"\r\n"), data)
+	})
+
+	t.Run("releases lock if update fails", func(t *testing.T) {
+		// GIVEN
+		handler, repo := setup(t)
+
+		// WHEN
+		err := handler.UpdateConfigFile(generalConPath, func(c *config.Config) error {
+			return os.ErrInvalid
+		})
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrInvalid)
+		exists, err := repo.FileExists(filepath.Join(profilesPath, "0001", lockFileName))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("error for non existing file", func(t *testing.T) {
+		// GIVEN
+		handler, _ := setup(t)
+
+		// WHEN
+		err := handler.UpdateConfigFile(filepath.Join(profilesPath, "0001", "DemoBookmarks.con"), func(c *config.Config) error {
+			return nil
+		})
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
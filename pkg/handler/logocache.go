@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ErrHostNotCached struct {
+	host string
+}
+
+func (e *ErrHostNotCached) Error() string {
+	return fmt.Sprintf("no images cached for host: %s", e.host)
+}
+
+// LogoCacheHost Server banner images cached for a single host
+type LogoCacheHost struct {
+	Host string
+	Path string
+	// Number and combined size of all cached images
+	Files int
+	Bytes int64
+	// Most recent modification of the host's folder or any cached image
+	Modified time.Time
+}
+
+// List all hosts with cached server banner images, sorted by host
+func (h *Handler) GetLogoCacheHosts(game Game) ([]LogoCacheHost, error) {
+	dirs, err := h.findLogoCacheDirs(game)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]LogoCacheHost, 0, len(dirs))
+	for _, dir := range dirs {
+		hosts = append(hosts, LogoCacheHost{
+			Host:     dir.Host,
+			Path:     dir.Path,
+			Files:    dir.Files,
+			Bytes:    dir.Bytes,
+			Modified: dir.Modified,
+		})
+	}
+
+	return hosts, nil
+}
+
+// Purge the logo cache folders selected by the given options, reporting which folders were removed
+// (selected hosts older than the configured age are purged, as are the least recently modified selected hosts until
+// the entire cache fits the configured quota; without any age or quota, all selected hosts are purged)
+func (h *Handler) PurgeLogoCacheWithReport(game Game, options ...PurgeOption) (*PurgeReport, error) {
+	filter := purgeFilter{}
+	for _, option := range options {
+		option(&filter)
+	}
+
+	dirs, err := h.findLogoCacheDirs(game)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := dirs
+	if len(filter.hosts) > 0 {
+		candidates = nil
+		for _, host := range filter.hosts {
+			dir, ok := findHostDir(dirs, host)
+			if !ok {
+				return nil, &ErrHostNotCached{host: host}
+			}
+			candidates = append(candidates, dir)
+		}
+	}
+
+	if filter.olderThan <= 0 && filter.maxBytes <= 0 {
+		return h.purgeCacheDirs(candidates)
+	}
+
+	var total int64
+	for _, dir := range dirs {
+		total += dir.Bytes
+	}
+
+	// Evict the least recently modified hosts first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Modified.Before(candidates[j].Modified)
+	})
+
+	var selected []PurgedDir
+	for _, dir := range candidates {
+		expired := filter.olderThan > 0 && h.isOlderThan(dir, filter)
+		overQuota := filter.maxBytes > 0 && total > filter.maxBytes
+		if expired || overQuota {
+			selected = append(selected, dir)
+			total -= dir.Bytes
+		}
+	}
+
+	return h.purgeCacheDirs(selected)
+}
+
+// findLogoCacheDirs Find the cache folder of each host, sorted by host
+func (h *Handler) findLogoCacheDirs(game Game) ([]PurgedDir, error) {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return nil, err
+	}
+	if !descriptor.Supports(ActionPurgeLogoCache) {
+		return nil, &ErrActionNotSupportedForGame{
+			action: string(ActionPurgeLogoCache),
+			game:   string(game),
+		}
+	}
+
+	basePath, err := h.BuildBasePath(game)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := h.findCacheDirs(filepath.Join(basePath, descriptor.LogoCachePattern))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range dirs {
+		dirs[i].Host = filepath.Base(dirs[i].Path)
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.ToLower(dirs[i].Host) < strings.ToLower(dirs[j].Host)
+	})
+
+	return dirs, nil
+}
+
+func findHostDir(dirs []PurgedDir, host string) (PurgedDir, bool) {
+	for _, dir := range dirs {
+		if strings.EqualFold(dir.Host, host) {
+			return dir, true
+		}
+	}
+	return PurgedDir{}, false
+}
@@ -0,0 +1,163 @@
+//go:build unit
+
+package handler
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestHandler_GetLogoCacheHosts(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"LogoCache/www.example.com":                    {Mode: fs.ModeDir, ModTime: modified.Add(-time.Hour)},
+		"LogoCache/www.example.com/banners/banner.png": {Data: []byte("abc"), ModTime: modified},
+		"LogoCache/www.example.com/banners/logo.png":   {Data: []byte("de"), ModTime: modified.Add(-time.Hour)},
+		"LogoCache/cdn.example.com":                    {Mode: fs.ModeDir, ModTime: modified},
+	}, basePath))
+	handler := New(repo, WithBasePath(GameBf2, basePath))
+
+	// WHEN
+	hosts, err := handler.GetLogoCacheHosts(GameBf2)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []LogoCacheHost{
+		{
+			Host:     "cdn.example.com",
+			Path:     filepath.Join(basePath, logoCacheDirName, "cdn.example.com"),
+			Modified: modified,
+		},
+		{
+			Host:     "www.example.com",
+			Path:     filepath.Join(basePath, logoCacheDirName, "www.example.com"),
+			Files:    2,
+			Bytes:    5,
+			Modified: modified,
+		},
+	}, hosts)
+}
+
+func TestHandler_PurgeLogoCacheWithReport(t *testing.T) {
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	hostPath := func(host string) string {
+		return filepath.Join(basePath, logoCacheDirName, host)
+	}
+
+	type test struct {
+		name            string
+		givenGame       Game
+		givenOptions    []PurgeOption
+		wantPurged      []string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:       "purges all hosts",
+			givenGame:  GameBf2,
+			wantPurged: []string{"a.example.com", "b.example.com", "c.example.com"},
+		},
+		{
+			name:         "purges given hosts",
+			givenGame:    GameBf2,
+			givenOptions: []PurgeOption{PurgeHosts("C.example.com", "a.example.com")},
+			wantPurged:   []string{"c.example.com", "a.example.com"},
+		},
+		{
+			name:         "purges hosts older than given age",
+			givenGame:    GameBf2,
+			givenOptions: []PurgeOption{PurgeOlderThan(5 * day)},
+			wantPurged:   []string{"b.example.com", "a.example.com"},
+		},
+		{
+			name:         "purges oldest hosts until cache fits quota",
+			givenGame:    GameBf2,
+			givenOptions: []PurgeOption{PurgeToQuota(6)},
+			wantPurged:   []string{"b.example.com"},
+		},
+		{
+			name:         "purges expired hosts before enforcing quota",
+			givenGame:    GameBf2,
+			givenOptions: []PurgeOption{PurgeOlderThan(9 * day), PurgeToQuota(2)},
+			wantPurged:   []string{"b.example.com", "a.example.com", "c.example.com"},
+		},
+		{
+			name:         "does not purge anything if cache fits quota",
+			givenGame:    GameBf2,
+			givenOptions: []PurgeOption{PurgeToQuota(100)},
+		},
+		{
+			name:         "quota only purges selected hosts",
+			givenGame:    GameBf2,
+			givenOptions: []PurgeOption{PurgeHosts("c.example.com"), PurgeToQuota(1)},
+			wantPurged:   []string{"c.example.com"},
+		},
+		{
+			name:            "error for host without cached images",
+			givenGame:       GameBf2,
+			givenOptions:    []PurgeOption{PurgeHosts("d.example.com")},
+			wantErrContains: "no images cached for host: d.example.com",
+		},
+		{
+			name:            "error for game without logo cache",
+			givenGame:       GameBf1942,
+			wantErrContains: "action not supported for game: PurgeLogoCache, bf1942",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"LogoCache/a.example.com":        {Mode: fs.ModeDir, ModTime: now.Add(-10 * day)},
+				"LogoCache/a.example.com/a.png":  {Data: []byte("abc"), ModTime: now.Add(-7 * day)},
+				"LogoCache/b.example.com":        {Mode: fs.ModeDir, ModTime: now.Add(-10 * day)},
+				"LogoCache/b.example.com/b.png":  {Data: []byte("defg"), ModTime: now.Add(-10 * day)},
+				"LogoCache/c.example.com":        {Mode: fs.ModeDir, ModTime: now.Add(-day)},
+				"LogoCache/c.example.com/c1.png": {Data: []byte("hi"), ModTime: now.Add(-day)},
+				"LogoCache/c.example.com/c2.png": {Data: []byte("j"), ModTime: now.Add(-day)},
+			}, basePath))
+			handler := New(repo, WithBasePath(GameBf2, basePath), WithBasePath(GameBf1942, filepath.Join("games", "bf1942")))
+			handler.now = func() time.Time {
+				return now
+			}
+
+			// WHEN
+			report, err := handler.PurgeLogoCacheWithReport(tt.givenGame, tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+				return
+			}
+			require.NoError(t, err)
+			var purged []string
+			for _, dir := range report.Removed {
+				purged = append(purged, dir.Host)
+				assert.Equal(t, hostPath(dir.Host), dir.Path)
+			}
+			assert.Equal(t, tt.wantPurged, purged)
+			for _, host := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+				exists, err := repo.DirExists(hostPath(host))
+				require.NoError(t, err)
+				assert.Equal(t, !slices.Contains(tt.wantPurged, host), exists, host)
+			}
+		})
+	}
+}
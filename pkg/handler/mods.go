@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	modDescFileName = "mod.desc"
+)
+
+// An installed mod as described by its mod.desc file
+type Mod struct {
+	// Name of the mod's folder (as used to launch it via +modPath mods/[name])
+	Name      string
+	Title     string
+	Version   string
+	GameModes []string
+	// Folder containing the mod's mod.desc
+	Path string
+}
+
+type ErrModNotFound struct {
+	game string
+	mod  string
+}
+
+func (e *ErrModNotFound) Error() string {
+	return fmt.Sprintf("mod not found: %s, %s", e.mod, e.game)
+}
+
+type ErrInvalidModDesc struct {
+	path string
+	err  error
+}
+
+func (e *ErrInvalidModDesc) Error() string {
+	return fmt.Sprintf("invalid mod.desc: %s: %s", e.path, e.err)
+}
+
+func (e *ErrInvalidModDesc) Unwrap() error {
+	return e.err
+}
+
+// ErrUnreadableMods Returned alongside all readable mods if the mod.desc of one or more mods could not be read
+type ErrUnreadableMods struct {
+	errs []error
+}
+
+func (e *ErrUnreadableMods) Error() string {
+	messages := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Errors for each mod which could not be read
+func (e *ErrUnreadableMods) Errors() []error {
+	return e.errs
+}
+
+func (e *ErrUnreadableMods) Unwrap() []error {
+	return e.errs
+}
+
+type modDesc struct {
+	XMLName   xml.Name          `xml:"mod"`
+	Title     string            `xml:"title"`
+	Version   string            `xml:"version"`
+	GameModes []modDescGameMode `xml:"gameModes>gameMode"`
+}
+
+// modDescGameMode Game mode entry, which contains the game mode either as a name attribute or as text
+type modDescGameMode struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Enumerate the mods installed for the given game by parsing each mod's mod.desc (mods whose mod.desc cannot be read
+// are skipped and reported in a *ErrUnreadableMods returned alongside all other mods)
+func (h *Handler) GetMods(game Game) ([]Mod, error) {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return nil, err
+	}
+	if !descriptor.Supports(ActionGetMods) {
+		return nil, &ErrActionNotSupportedForGame{
+			action: string(ActionGetMods),
+			game:   string(game),
+		}
+	}
+
+	/*
+		Looking from the install and the base path, each mod is stored in:
+		mods/
+		├──[mod]/
+		   ├──mod.desc
+		If a mod exists in both locations, the mod installed in the install folder is used
+	*/
+	var searchPaths []string
+	// Mods in the base path can still be listed if the install folder is unknown or cannot be determined
+	// (e.g. because the registry key is missing)
+	if installPath, err := h.BuildInstallPath(game); err == nil {
+		searchPaths = append(searchPaths, installPath)
+	}
+
+	basePath, err := h.BuildBasePath(game)
+	if err != nil {
+		return nil, err
+	}
+	searchPaths = append(searchPaths, basePath)
+
+	var mods []Mod
+	var errs []error
+	seen := map[string]bool{}
+	for _, searchPath := range searchPaths {
+		matches, err := h.repository.Glob(filepath.Join(searchPath, descriptor.ModsDirName, "*", modDescFileName))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			modPath := filepath.Dir(match)
+			name := filepath.Base(modPath)
+			if seen[strings.ToLower(name)] {
+				continue
+			}
+
+			mod, err := h.readModDesc(name, modPath)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			mods = append(mods, mod)
+			seen[strings.ToLower(name)] = true
+		}
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return strings.ToLower(mods[i].Name) < strings.ToLower(mods[j].Name)
+	})
+
+	if len(errs) > 0 {
+		return mods, &ErrUnreadableMods{errs: errs}
+	}
+
+	return mods, nil
+}
+
+// Find an installed mod of the given game by (case-insensitive) name
+func (h *Handler) GetMod(game Game, name string) (Mod, error) {
+	mods, err := h.GetMods(game)
+	// Any other mod being unreadable does not affect the mod we are looking for
+	var unreadable *ErrUnreadableMods
+	if err != nil && !errors.As(err, &unreadable) {
+		return Mod{}, err
+	}
+
+	for _, mod := range mods {
+		if strings.EqualFold(mod.Name, name) {
+			return mod, nil
+		}
+	}
+
+	return Mod{}, &ErrModNotFound{game: string(game), mod: name}
+}
+
+// Purge the shader cache of a single installed mod
+func (h *Handler) PurgeModShaderCache(game Game, name string) error {
+	_, err := h.PurgeShaderCacheWithReport(game, PurgeMods(name))
+	return err
+}
+
+func (h *Handler) readModDesc(name string, modPath string) (Mod, error) {
+	path := filepath.Join(modPath, modDescFileName)
+	data, err := h.repository.ReadFile(path)
+	if err != nil {
+		return Mod{}, err
+	}
+
+	var desc modDesc
+	if err = xml.Unmarshal(data, &desc); err != nil {
+		return Mod{}, &ErrInvalidModDesc{path: path, err: err}
+	}
+
+	mod := Mod{
+		Name:    name,
+		Title:   strings.TrimSpace(desc.Title),
+		Version: strings.TrimSpace(desc.Version),
+		Path:    modPath,
+	}
+	for _, gameMode := range desc.GameModes {
+		value := gameMode.Name
+		if value == "" {
+			value = strings.TrimSpace(gameMode.Value)
+		}
+		if value != "" {
+			mod.GameModes = append(mod.GameModes, value)
+		}
+	}
+
+	return mod, nil
+}
@@ -0,0 +1,270 @@
+//go:build unit
+
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+const (
+	bf2ModDesc = `<?xml version="1.0" encoding="utf-8"?>
+<mod>
+	<title>Battlefield 2</title>
+	<version>1.5</version>
+	<gameModes>
+		<gameMode>gpm_cq</gameMode>
+		<gameMode>gpm_coop</gameMode>
+	</gameModes>
+</mod>`
+	xpackModDesc = `<?xml version="1.0" encoding="utf-8"?>
+<mod>
+	<title> Special Forces </title>
+	<version>1.5</version>
+	<gameModes>
+		<gameMode name="gpm_cq" />
+		<gameMode name="gpm_ctf" />
+	</gameModes>
+</mod>`
+)
+
+func TestHandler_GetMods(t *testing.T) {
+	installPath := filepath.Join("games", "bf2")
+	documentsPath := filepath.Join("build", "documents")
+	basePath := filepath.Join(documentsPath, bf2GameDirName)
+
+	type test struct {
+		name            string
+		givenGame       Game
+		givenInstalled  fstest.MapFS
+		givenDocuments  fstest.MapFS
+		wantMods        []Mod
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:      "successfully enumerates mods in install and base path",
+			givenGame: GameBf2,
+			givenInstalled: fstest.MapFS{
+				"mods/xpack/mod.desc":     {Data: []byte(xpackModDesc)},
+				"mods/bf2/mod.desc":       {Data: []byte(bf2ModDesc)},
+				"mods/not-a-mod/Init.con": {Data: []byte{}},
+			},
+			givenDocuments: fstest.MapFS{
+				// Same mod as in install path, only the installed one should be used
+				"mods/BF2/mod.desc":         {Data: []byte("<mod><title>Copy</title></mod>")},
+				"mods/custom/mod.desc":      {Data: []byte("<mod><title>Custom</title><version>0.1</version></mod>")},
+				"mods/bf2/cache/some/a.cfx": {Data: []byte{}},
+			},
+			wantMods: []Mod{
+				{
+					Name:      "bf2",
+					Title:     "Battlefield 2",
+					Version:   "1.5",
+					GameModes: []string{"gpm_cq", "gpm_coop"},
+					Path:      filepath.Join(installPath, modsDirName, "bf2"),
+				},
+				{
+					Name:    "custom",
+					Title:   "Custom",
+					Version: "0.1",
+					Path:    filepath.Join(basePath, modsDirName, "custom"),
+				},
+				{
+					Name:      "xpack",
+					Title:     "Special Forces",
+					Version:   "1.5",
+					GameModes: []string{"gpm_cq", "gpm_ctf"},
+					Path:      filepath.Join(installPath, modsDirName, "xpack"),
+				},
+			},
+		},
+		{
+			name:           "returns no mods if none are installed",
+			givenGame:      GameBf2,
+			givenInstalled: fstest.MapFS{},
+			givenDocuments: fstest.MapFS{},
+		},
+		{
+			name:      "skips and reports mod with invalid mod.desc",
+			givenGame: GameBf2,
+			givenInstalled: fstest.MapFS{
+				"mods/bf2/mod.desc":   {Data: []byte("<mod><title>Battlefield 2</title>")},
+				"mods/xpack/mod.desc": {Data: []byte(xpackModDesc)},
+			},
+			givenDocuments: fstest.MapFS{},
+			wantMods: []Mod{
+				{
+					Name:      "xpack",
+					Title:     "Special Forces",
+					Version:   "1.5",
+					GameModes: []string{"gpm_cq", "gpm_ctf"},
+					Path:      filepath.Join(installPath, modsDirName, "xpack"),
+				},
+			},
+			wantErrContains: "invalid mod.desc: " + filepath.Join(installPath, modsDirName, "bf2", modDescFileName),
+		},
+		{
+			name:            "error for game without mods",
+			givenGame:       GameBf1942,
+			givenInstalled:  fstest.MapFS{},
+			givenDocuments:  fstest.MapFS{},
+			wantErrContains: "action not supported for game: GetMods, bf1942",
+		},
+		{
+			name:            "error for unsupported game",
+			givenGame:       "not-a-supported-game",
+			givenInstalled:  fstest.MapFS{},
+			givenDocuments:  fstest.MapFS{},
+			wantErrContains: "game not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(tt.givenInstalled, installPath))
+			require.NoError(t, repo.Load(tt.givenDocuments, basePath))
+			handler := New(repo, WithDocumentsPath(documentsPath), WithInstallPath(tt.givenGame, installPath))
+
+			// WHEN
+			mods, err := handler.GetMods(tt.givenGame)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMods, mods)
+		})
+	}
+}
+
+func TestHandler_GetMods_WithoutInstallFolder(t *testing.T) {
+	// GIVEN
+	var game Game = "test-mod"
+	unregisterGame(t, game)
+	require.NoError(t, RegisterStandaloneMod(game, "Test Mod"))
+	documentsPath := filepath.Join("build", "documents")
+	basePath := filepath.Join(documentsPath, "Test Mod")
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"mods/test/mod.desc": {Data: []byte("<mod><title>Test</title></mod>")},
+	}, basePath))
+	handler := New(repo, WithDocumentsPath(documentsPath))
+
+	// WHEN
+	mods, err := handler.GetMods(game)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []Mod{{Name: "test", Title: "Test", Path: filepath.Join(basePath, modsDirName, "test")}}, mods)
+}
+
+func TestHandler_GetMods_InstallFolderUnavailable(t *testing.T) {
+	// GIVEN
+	documentsPath := filepath.Join("build", "documents")
+	basePath := filepath.Join(documentsPath, bf2GameDirName)
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"mods/bf2/mod.desc":    {Data: []byte(bf2ModDesc)},
+		"mods/broken/mod.desc": {Data: []byte("<mod>")},
+	}, basePath))
+	// Install folder cannot be determined, since the (Wine) registry is not available
+	handler := New(repo, WithDocumentsPath(documentsPath))
+
+	// WHEN
+	mods, err := handler.GetMods(GameBf2)
+	mod, modErr := handler.GetMod(GameBf2, "BF2")
+
+	// THEN
+	var unreadable *ErrUnreadableMods
+	require.ErrorAs(t, err, &unreadable)
+	require.Len(t, unreadable.Errors(), 1)
+	assert.ErrorContains(t, unreadable.Errors()[0], "invalid mod.desc")
+	require.Len(t, mods, 1)
+	assert.Equal(t, filepath.Join(basePath, modsDirName, "bf2"), mods[0].Path)
+	require.NoError(t, modErr)
+	assert.Equal(t, mods[0], mod)
+}
+
+func TestHandler_PurgeModShaderCache(t *testing.T) {
+	installPath := filepath.Join("games", "bf2")
+	documentsPath := filepath.Join("build", "documents")
+	basePath := filepath.Join(documentsPath, bf2GameDirName)
+
+	type test struct {
+		name            string
+		givenMod        string
+		wantRemoved     []string
+		wantKept        []string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:     "successfully purges shader cache of given mod only",
+			givenMod: "XPACK",
+			wantRemoved: []string{
+				filepath.Join(basePath, modsDirName, "xpack", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_2"),
+			},
+			wantKept: []string{
+				filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1"),
+			},
+		},
+		{
+			name:     "error for mod which is not installed",
+			givenMod: "pr",
+			wantKept: []string{
+				filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1"),
+				filepath.Join(basePath, modsDirName, "xpack", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_2"),
+			},
+			wantErrContains: "mod not found: pr, bf2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"mods/bf2/mod.desc":   {Data: []byte(bf2ModDesc)},
+				"mods/xpack/mod.desc": {Data: []byte(xpackModDesc)},
+			}, installPath))
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"mods/bf2/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1/a.cfx":   {Data: []byte{}},
+				"mods/xpack/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_2/a.cfx": {Data: []byte{}},
+			}, basePath))
+			handler := New(repo, WithDocumentsPath(documentsPath), WithInstallPath(GameBf2, installPath))
+
+			// WHEN
+			err := handler.PurgeModShaderCache(GameBf2, tt.givenMod)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, path := range tt.wantRemoved {
+				exists, err := repo.DirExists(path)
+				require.NoError(t, err)
+				assert.False(t, exists, path)
+			}
+			for _, path := range tt.wantKept {
+				exists, err := repo.DirExists(path)
+				require.NoError(t, err)
+				assert.True(t, exists, path)
+			}
+		})
+	}
+}
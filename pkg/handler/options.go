@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/cetteup/conman/pkg/process"
+)
+
+type Option func(h *Handler)
+
+// Use the given folder instead of the current user's Documents folder when building any game's base path
+func WithDocumentsPath(path string) Option {
+	return func(h *Handler) {
+		h.documentsDirPath = path
+	}
+}
+
+// Use the given folder as the base path for the given game (e.g. a portable install or a copied profile tree)
+func WithBasePath(game Game, path string) Option {
+	return func(h *Handler) {
+		h.basePaths[game] = path
+	}
+}
+
+// Use the given folder as the profiles folder for the given game (independent of the game's base path)
+func WithProfilesPath(game Game, path string) Option {
+	return func(h *Handler) {
+		h.profilesPaths[game] = path
+	}
+}
+
+// Use the given folder as the install folder for the given game instead of looking it up in the registry
+func WithInstallPath(game Game, path string) Option {
+	return func(h *Handler) {
+		h.installPaths[game] = path
+	}
+}
+
+// Use the given mod's profiles for the given game instead of the default mod's [Refractor v1 games only]
+func WithMod(game Game, mod string) Option {
+	return func(h *Handler) {
+		h.mods[game] = mod
+	}
+}
+
+// Back up any file before it is modified or removed, keeping the given number of backups per file (keep all backups
+// if retain is zero); removed folders are not backed up (see Transaction.Remove)
+func WithBackups(dirPath string, retain int) Option {
+	return func(h *Handler) {
+		h.backupDirPath = dirPath
+		h.backupRetain = retain
+	}
+}
+
+// Do not write or remove anything, instead record all changes which would have been made (see Handler.Plan)
+func WithDryRun() Option {
+	return func(h *Handler) {
+		h.plan = &Plan{}
+	}
+}
+
+// Refuse to modify or remove any file belonging to a game while the given detector reports the game as running
+func WithProcessDetector(detector process.Detector) Option {
+	return func(h *Handler) {
+		h.detector = detector
+	}
+}
+
+// Modify and remove files even if the game they belong to is running (changes will likely be lost once the game exits)
+func WithIgnoreRunningGame() Option {
+	return func(h *Handler) {
+		h.ignoreRunning = true
+	}
+}
+
+// Wait for up to the given duration for a folder locked by another process to be unlocked (fail immediately if zero)
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(h *Handler) {
+		h.lockTimeout = timeout
+	}
+}
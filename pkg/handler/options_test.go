@@ -0,0 +1,242 @@
+//go:build unit
+
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestHandler_BuildBasePath_WithOptions(t *testing.T) {
+	type test struct {
+		name            string
+		givenOptions    []Option
+		givenGame       Game
+		wantBasePath    string
+		wantProfilesDir string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:            "uses documents path for all games",
+			givenOptions:    []Option{WithDocumentsPath(filepath.Join("build", "documents"))},
+			givenGame:       GameBf2,
+			wantBasePath:    filepath.Join("build", "documents", bf2GameDirName),
+			wantProfilesDir: filepath.Join("build", "documents", bf2GameDirName, profilesDirName),
+		},
+		{
+			name:            "uses documents path for Battlefield 2142",
+			givenOptions:    []Option{WithDocumentsPath(filepath.Join("build", "documents"))},
+			givenGame:       GameBf2142,
+			wantBasePath:    filepath.Join("build", "documents", bf2142GameDirName),
+			wantProfilesDir: filepath.Join("build", "documents", bf2142GameDirName, profilesDirName),
+		},
+		{
+			name:            "uses base path",
+			givenOptions:    []Option{WithBasePath(GameBf2, filepath.Join("usb", "bf2"))},
+			givenGame:       GameBf2,
+			wantBasePath:    filepath.Join("usb", "bf2"),
+			wantProfilesDir: filepath.Join("usb", "bf2", profilesDirName),
+		},
+		{
+			name: "base path takes precedence over documents path",
+			givenOptions: []Option{
+				WithDocumentsPath(filepath.Join("build", "documents")),
+				WithBasePath(GameBf2, filepath.Join("usb", "bf2")),
+			},
+			givenGame:       GameBf2,
+			wantBasePath:    filepath.Join("usb", "bf2"),
+			wantProfilesDir: filepath.Join("usb", "bf2", profilesDirName),
+		},
+		{
+			name: "uses profiles path independently of base path",
+			givenOptions: []Option{
+				WithBasePath(GameBf2, filepath.Join("usb", "bf2")),
+				WithProfilesPath(GameBf2, filepath.Join("copies", "profiles")),
+			},
+			givenGame:       GameBf2,
+			wantBasePath:    filepath.Join("usb", "bf2"),
+			wantProfilesDir: filepath.Join("copies", "profiles"),
+		},
+		{
+			name:            "uses base path as Battlefield 1942 install folder",
+			givenOptions:    []Option{WithBasePath(GameBf1942, filepath.Join("games", "bf1942"))},
+			givenGame:       GameBf1942,
+			wantBasePath:    filepath.Join("games", "bf1942"),
+			wantProfilesDir: filepath.Join("games", "bf1942", v1ModsDirName, bf1942DefaultMod, v1SettingsDirName, profilesDirName),
+		},
+		{
+			name:            "uses install path as Battlefield 1942 base path",
+			givenOptions:    []Option{WithInstallPath(GameBf1942, filepath.Join("games", "bf1942"))},
+			givenGame:       GameBf1942,
+			wantBasePath:    filepath.Join("games", "bf1942"),
+			wantProfilesDir: filepath.Join("games", "bf1942", v1ModsDirName, bf1942DefaultMod, v1SettingsDirName, profilesDirName),
+		},
+		{
+			name: "install path does not affect Battlefield 2 base path",
+			givenOptions: []Option{
+				WithDocumentsPath(filepath.Join("build", "documents")),
+				WithInstallPath(GameBf2, filepath.Join("games", "bf2")),
+			},
+			givenGame:       GameBf2,
+			wantBasePath:    filepath.Join("build", "documents", bf2GameDirName),
+			wantProfilesDir: filepath.Join("build", "documents", bf2GameDirName, profilesDirName),
+		},
+		{
+			name: "uses configured mod for Battlefield Vietnam",
+			givenOptions: []Option{
+				WithBasePath(GameBfVietnam, filepath.Join("games", "bfvietnam")),
+				WithMod(GameBfVietnam, "RedOrchestra"),
+			},
+			givenGame:       GameBfVietnam,
+			wantBasePath:    filepath.Join("games", "bfvietnam"),
+			wantProfilesDir: filepath.Join("games", "bfvietnam", v1ModsDirName, "RedOrchestra", v1SettingsDirName, profilesDirName),
+		},
+		{
+			name:            "error for unsupported game",
+			givenOptions:    []Option{WithBasePath("not-a-supported-game", filepath.Join("usb", "bf2"))},
+			givenGame:       "not-a-supported-game",
+			wantErrContains: "game not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			ctrl := gomock.NewController(t)
+			handler := New(NewMockFileRepository(ctrl), tt.givenOptions...)
+
+			// WHEN
+			basePath, err := handler.BuildBasePath(tt.givenGame)
+			profilesDir, profilesErr := handler.BuildProfilesFolderPath(tt.givenGame)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+				require.ErrorContains(t, profilesErr, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, profilesErr)
+				assert.Equal(t, tt.wantBasePath, basePath)
+				assert.Equal(t, tt.wantProfilesDir, profilesDir)
+			}
+		})
+	}
+}
+
+func TestHandler_WithOptions(t *testing.T) {
+	basePath := filepath.Join("usb", "bf2")
+	profilesPath := filepath.Join("copies", "profiles")
+
+	t.Run("GetProfileKeys reads configured profiles path", func(t *testing.T) {
+		// GIVEN
+		ctrl := gomock.NewController(t)
+		repository := NewMockFileRepository(ctrl)
+		handler := New(repository, WithBasePath(GameBf2, basePath), WithProfilesPath(GameBf2, profilesPath))
+
+		// EXPECT
+		dirEntry := NewMockDirEntry(ctrl)
+		dirEntry.EXPECT().IsDir().Return(true)
+		dirEntry.EXPECT().Name().Return("0001").Times(2)
+		repository.EXPECT().ReadDir(profilesPath).Return([]os.DirEntry{dirEntry}, nil)
+		repository.EXPECT().FileExists(filepath.Join(profilesPath, "0001", profileConFileName)).Return(true, nil)
+
+		// WHEN
+		profileKeys, err := handler.GetProfileKeys(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0001"}, profileKeys)
+	})
+
+	t.Run("ReadGlobalConfig reads from configured profiles path", func(t *testing.T) {
+		// GIVEN
+		ctrl := gomock.NewController(t)
+		repository := NewMockFileRepository(ctrl)
+		handler := New(repository, WithProfilesPath(GameBf2, profilesPath))
+
+		// EXPECT
+		repository.EXPECT().ReadFile(filepath.Join(profilesPath, globalConFileName)).Return([]byte{}, nil)
+
+		// WHEN
+		_, err := handler.ReadGlobalConfig(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+	})
+
+	t.Run("PurgeShaderCache purges caches in configured base path", func(t *testing.T) {
+		// GIVEN
+		ctrl := gomock.NewController(t)
+		repository := NewMockFileRepository(ctrl)
+		handler := New(repository, WithBasePath(GameBf2, basePath), WithProfilesPath(GameBf2, profilesPath))
+
+		// EXPECT
+		cachePath := filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1")
+		repository.EXPECT().Glob(filepath.Join(basePath, modsDirName, "*", cacheDirName, "*")).Return([]string{cachePath}, nil)
+		repository.EXPECT().RemoveAll(cachePath).Return(nil)
+
+		// WHEN
+		err := handler.PurgeShaderCache(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+	})
+
+	t.Run("reads and writes Refractor v1 profiles in configured install folder", func(t *testing.T) {
+		// GIVEN
+		installPath := filepath.Join("games", "bf1942")
+		settingsPath := filepath.Join(installPath, v1ModsDirName, bf1942DefaultMod, v1SettingsDirName)
+		repo := repository.NewMemory()
+		require.NoError(t, repo.Load(fstest.MapFS{
+			"Mods/bf1942/Settings/Profile.con":                  {Data: []byte("game.setProfile \"Default\"\r\n")},
+			"Mods/bf1942/Settings/Profiles/Default/Game.con":    {Data: []byte{}},
+			"Mods/bf1942/Settings/Profiles/mister249/Game.con":  {Data: []byte{}},
+			"Mods/bf1942/Settings/Profiles/not-a-profile/.keep": {Data: []byte{}},
+		}, installPath))
+		handler := New(repo, WithBasePath(GameBf1942, installPath))
+
+		// WHEN
+		profileKeys, err := handler.GetProfileKeys(GameBf1942)
+		require.NoError(t, err)
+		globalCon, err := handler.ReadGlobalConfig(GameBf1942)
+		require.NoError(t, err)
+		globalCon.SetValue("game.setProfile", *config.NewQuotedValue("mister249"))
+		err = handler.WriteConfigFile(globalCon)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Default", "mister249"}, profileKeys)
+		data, err := repo.ReadFile(filepath.Join(settingsPath, v1GlobalConFileName))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("game.setProfile \"mister249\"\r\n"), data)
+	})
+
+	t.Run("PurgeLogoCache purges cache in configured base path", func(t *testing.T) {
+		// GIVEN
+		ctrl := gomock.NewController(t)
+		repository := NewMockFileRepository(ctrl)
+		handler := New(repository, WithBasePath(GameBf2, basePath))
+
+		// EXPECT
+		cachePath := filepath.Join(basePath, logoCacheDirName, "www.dogclan.net")
+		repository.EXPECT().Glob(filepath.Join(basePath, logoCacheDirName, "*")).Return([]string{cachePath}, nil)
+		repository.EXPECT().RemoveAll(cachePath).Return(nil)
+
+		// WHEN
+		err := handler.PurgeLogoCache(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package handler
+
+import (
+	"path/filepath"
+
+	"github.com/cetteup/conman/pkg/wine"
+)
+
+// Outside of Windows, games are run via Wine, so any paths need to be resolved using the Wine prefix's registry files
+
+func (h *Handler) getDocumentsDirPath() (string, error) {
+	prefix, err := wine.GetPrefixPath()
+	if err != nil {
+		return "", err
+	}
+
+	userReg, err := h.readWineRegistry(prefix, wine.UserRegistryFileName)
+	if err != nil {
+		return "", err
+	}
+
+	personalFolderPath, err := wine.GetPersonalFolderPath(userReg)
+	if err != nil {
+		return "", err
+	}
+
+	return wine.ToUnixPath(prefix, personalFolderPath)
+}
+
+func (h *Handler) getInstallDirPath(keyPath string, valueName string) (string, error) {
+	prefix, err := wine.GetPrefixPath()
+	if err != nil {
+		return "", err
+	}
+
+	systemReg, err := h.readWineRegistry(prefix, wine.SystemRegistryFileName)
+	if err != nil {
+		return "", err
+	}
+
+	installDirPath, err := wine.GetInstallPathFromValue(systemReg, keyPath, valueName)
+	if err != nil {
+		return "", err
+	}
+
+	return wine.ToUnixPath(prefix, installDirPath)
+}
+
+func (h *Handler) readWineRegistry(prefix string, fileName string) (*wine.Registry, error) {
+	data, err := h.repository.ReadFile(filepath.Join(prefix, fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return wine.Parse(data)
+}
@@ -0,0 +1,179 @@
+//go:build unit && !windows
+
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testWinePrefix = "/home/user/.wine"
+	testUserReg    = "WINE REGISTRY Version 2\n" +
+		";; All keys relative to \\\\User\\\\S-1-5-21-0-0-0-1000\n\n" +
+		"#arch=win64\n\n" +
+		"[Software\\\\Microsoft\\\\Windows\\\\CurrentVersion\\\\Explorer\\\\Shell Folders] 1700000000\n" +
+		"#time=1da0000000000000\n" +
+		"\"Personal\"=\"D:\\\\Documents\"\n"
+	testSystemReg = "WINE REGISTRY Version 2\n" +
+		";; All keys relative to \\\\Machine\n\n" +
+		"[Software\\\\Wow6432Node\\\\Electronic Arts\\\\EA Games\\\\Battlefield 2] 1700000000\n" +
+		"\"InstallDir\"=\"C:\\\\Program Files (x86)\\\\EA Games\\\\Battlefield 2\"\n\n" +
+		"[Software\\\\Wow6432Node\\\\EA GAMES\\\\Battlefield 1942] 1700000000\n" +
+		"\"GAMEDIR\"=\"C:\\\\Program Files (x86)\\\\EA Games\\\\Battlefield 1942\"\n"
+)
+
+func TestHandler_BuildProfilesFolderPath_Wine(t *testing.T) {
+	type test struct {
+		name            string
+		givenGame       Game
+		expect          func(repository *MockFileRepository)
+		wantPath        string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:      "builds profiles folder path from wine user registry",
+			givenGame: GameBf2,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(gomock.Eq(filepath.Join(testWinePrefix, "user.reg"))).Return([]byte(testUserReg), nil)
+			},
+			wantPath: filepath.Join(testWinePrefix, "dosdevices", "d:", "Documents", bf2GameDirName, profilesDirName),
+		},
+		{
+			name:      "error reading wine user registry",
+			givenGame: GameBf2,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(gomock.Eq(filepath.Join(testWinePrefix, "user.reg"))).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name:      "error for wine user registry without personal folder",
+			givenGame: GameBf2,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(gomock.Eq(filepath.Join(testWinePrefix, "user.reg"))).Return([]byte("WINE REGISTRY Version 2\n"), nil)
+			},
+			wantErrContains: "no such registry key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			_, handler, mockRepository := getHandlerWithDependencies(t)
+			t.Setenv("WINEPREFIX", testWinePrefix)
+
+			// EXPECT
+			tt.expect(mockRepository)
+
+			// WHEN
+			path, err := handler.BuildProfilesFolderPath(tt.givenGame)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantPath, path)
+			}
+		})
+	}
+}
+
+func TestHandler_BuildInstallPath_Wine(t *testing.T) {
+	type test struct {
+		name            string
+		givenGame       Game
+		expect          func(repository *MockFileRepository)
+		wantPath        string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name:      "builds install path from wine system registry",
+			givenGame: GameBf2,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(gomock.Eq(filepath.Join(testWinePrefix, "system.reg"))).Return([]byte(testSystemReg), nil)
+			},
+			wantPath: filepath.Join(testWinePrefix, "dosdevices", "c:", "Program Files (x86)", "EA Games", "Battlefield 2"),
+		},
+		{
+			name:      "builds Battlefield 1942 install path from wine system registry",
+			givenGame: GameBf1942,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(gomock.Eq(filepath.Join(testWinePrefix, "system.reg"))).Return([]byte(testSystemReg), nil)
+			},
+			wantPath: filepath.Join(testWinePrefix, "dosdevices", "c:", "Program Files (x86)", "EA Games", "Battlefield 1942"),
+		},
+		{
+			name:      "error reading wine system registry",
+			givenGame: GameBf2,
+			expect: func(repository *MockFileRepository) {
+				repository.EXPECT().ReadFile(gomock.Eq(filepath.Join(testWinePrefix, "system.reg"))).Return(nil, fmt.Errorf("some-error"))
+			},
+			wantErrContains: "some-error",
+		},
+		{
+			name:            "error for unsupported game",
+			givenGame:       "not-a-supported-game",
+			expect:          func(repository *MockFileRepository) {},
+			wantErrContains: "game not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			_, handler, mockRepository := getHandlerWithDependencies(t)
+			t.Setenv("WINEPREFIX", testWinePrefix)
+
+			// EXPECT
+			tt.expect(mockRepository)
+
+			// WHEN
+			path, err := handler.BuildInstallPath(tt.givenGame)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantPath, path)
+			}
+		})
+	}
+}
+
+func TestHandler_CheckNotRunning_ResolvesWinePathsOnce(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	_, handler, mockRepository := getHandlerWithDependencies(t, WithBasePath(GameBf2, basePath), WithProcessDetector(&fakeDetector{}))
+	t.Setenv("WINEPREFIX", testWinePrefix)
+	path := filepath.Join(basePath, profilesDirName, globalConFileName)
+
+	// EXPECT
+	reads := 0
+	mockRepository.EXPECT().ReadFile(gomock.Any()).DoAndReturn(func(string) ([]byte, error) {
+		reads++
+		return nil, fmt.Errorf("some-error")
+	}).AnyTimes()
+
+	// WHEN
+	firstErr := handler.checkNotRunning(path)
+	readsAfterFirst := reads
+	secondErr := handler.checkNotRunning(path)
+
+	// THEN
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.NotZero(t, readsAfterFirst)
+	assert.Equal(t, readsAfterFirst, reads)
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package handler
+
+import (
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	softwareKeyPath = "SOFTWARE\\"
+)
+
+func (h *Handler) getDocumentsDirPath() (string, error) {
+	return windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
+}
+
+func (h *Handler) getInstallDirPath(keyPath string, valueName string) (string, error) {
+	// All supported games are 32-bit applications, so make sure to read from the 32-bit registry view (Wow6432Node)
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, softwareKeyPath+keyPath, registry.QUERY_VALUE|registry.WOW64_32KEY)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = key.Close()
+	}()
+
+	installDirPath, _, err := key.GetStringValue(valueName)
+	if err != nil {
+		return "", err
+	}
+
+	return installDirPath, nil
+}
@@ -0,0 +1,26 @@
+//go:build unit && windows
+
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+func TestHandler_BuildProfilesFolderPath_KnownFolder(t *testing.T) {
+	// GIVEN
+	_, handler, _ := getHandlerWithDependencies(t)
+	documentsDirPath, err := windows.KnownFolderPath(windows.FOLDERID_Documents, windows.KF_FLAG_DEFAULT)
+	require.NoError(t, err)
+
+	// WHEN
+	path, err := handler.BuildProfilesFolderPath(GameBf2)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(documentsDirPath, bf2GameDirName, profilesDirName), path)
+}
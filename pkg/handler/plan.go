@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cetteup/conman/pkg/config"
+)
+
+// Plan Changes a handler in dry-run mode would have made, in the order they would have been made
+type Plan struct {
+	Writes   []PlannedWrite
+	Removals []PlannedRemoval
+	Moves    []PlannedMove
+}
+
+type PlannedWrite struct {
+	Path string
+	// Whether the file does not exist yet
+	Create  bool
+	Changes []config.Change
+	// Content of the file before the first planned write
+	original []byte
+	// Content of the file after the last planned write
+	staged []byte
+}
+
+type PlannedRemoval struct {
+	Path string
+	// Combined size of all files which would be removed
+	Size int64
+}
+
+type PlannedMove struct {
+	From string
+	To   string
+}
+
+// Whether any changes have been planned
+func (p *Plan) IsEmpty() bool {
+	return len(p.Writes) == 0 && len(p.Removals) == 0 && len(p.Moves) == 0
+}
+
+// Whether the handler is in dry-run mode (recording planned changes instead of making them)
+func (h *Handler) DryRun() bool {
+	return h.plan != nil
+}
+
+// Retrieve the changes planned so far (nil unless the handler is in dry-run mode)
+func (h *Handler) Plan() *Plan {
+	return h.plan
+}
+
+// planWrite Record a write, combining multiple writes to the same file into a single planned write
+func (h *Handler) planWrite(path string, data []byte) error {
+	for i, write := range h.plan.Writes {
+		if write.Path == path {
+			h.plan.Writes[i].staged = data
+			h.plan.Writes[i].Changes = config.Diff(config.FromBytes(path, write.original), config.FromBytes(path, data))
+			return nil
+		}
+	}
+
+	original, err := h.repository.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var old *config.Config
+	if err == nil {
+		old = config.FromBytes(path, original)
+	}
+
+	h.plan.Writes = append(h.plan.Writes, PlannedWrite{
+		Path:     path,
+		Create:   err != nil,
+		Changes:  config.Diff(old, config.FromBytes(path, data)),
+		original: original,
+		staged:   data,
+	})
+
+	return nil
+}
+
+// planRemoval Record the removal of the file or folder at the given path
+func (h *Handler) planRemoval(path string) error {
+	u, err := h.usage(path)
+	if err != nil {
+		return err
+	}
+
+	h.plan.Removals = append(h.plan.Removals, PlannedRemoval{
+		Path: path,
+		Size: u.bytes,
+	})
+
+	return nil
+}
+
+// planMove Record moving the file or folder at the given path
+func (h *Handler) planMove(from string, to string) {
+	h.plan.Moves = append(h.plan.Moves, PlannedMove{
+		From: from,
+		To:   to,
+	})
+}
+
+// readFile Read a file, taking any planned (but not actually made) changes into account
+func (h *Handler) readFile(path string) ([]byte, error) {
+	if h.plan != nil {
+		for _, write := range h.plan.Writes {
+			if write.Path == path {
+				return write.staged, nil
+			}
+		}
+	}
+
+	resolved, ok := h.resolvePlanned(path)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+
+	return h.repository.ReadFile(resolved)
+}
+
+// stat Stat a file or folder, taking any planned (but not actually made) changes into account
+func (h *Handler) stat(path string) (os.FileInfo, error) {
+	if h.plan == nil {
+		return h.repository.Stat(path)
+	}
+
+	for _, write := range h.plan.Writes {
+		if write.Path == path {
+			return &plannedFileInfo{name: filepath.Base(path), size: int64(len(write.staged)), modTime: h.now()}, nil
+		}
+	}
+
+	resolved, ok := h.resolvePlanned(path)
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+
+	info, err := h.repository.Stat(resolved)
+	if errors.Is(err, os.ErrNotExist) && h.hasPlannedWritesBelow(path) {
+		// Folder would be created by a planned write
+		return &plannedFileInfo{name: filepath.Base(path), dir: true, modTime: h.now()}, nil
+	}
+
+	return info, err
+}
+
+// fileExists Check whether a file exists, taking any planned (but not actually made) changes into account
+func (h *Handler) fileExists(path string) (bool, error) {
+	if h.plan == nil {
+		return h.repository.FileExists(path)
+	}
+
+	info, err := h.stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !info.IsDir(), nil
+}
+
+// readDir List a folder, taking any planned (but not actually made) changes into account
+func (h *Handler) readDir(path string) ([]os.DirEntry, error) {
+	if h.plan == nil {
+		return h.repository.ReadDir(path)
+	}
+
+	var entries []os.DirEntry
+	resolved, ok := h.resolvePlanned(path)
+	if ok {
+		var err error
+		entries, err = h.repository.ReadDir(resolved)
+		if err != nil && (!errors.Is(err, os.ErrNotExist) || !h.hasPlannedWritesBelow(path)) {
+			return nil, err
+		}
+	} else if !h.hasPlannedWritesBelow(path) {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+
+	byName := map[string]os.DirEntry{}
+	for _, entry := range entries {
+		byName[entry.Name()] = entry
+	}
+
+	// Entries which would be removed or moved away are no longer listed, entries which would be written or moved
+	// here are listed in addition
+	candidates := make([]string, 0, len(byName))
+	for name := range byName {
+		candidates = append(candidates, name)
+	}
+	for _, write := range h.plan.Writes {
+		if name, ok := childName(path, write.Path); ok {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, move := range h.plan.Moves {
+		if name, ok := childName(path, move.To); ok {
+			candidates = append(candidates, name)
+		}
+	}
+
+	listed := map[string]os.DirEntry{}
+	for _, name := range candidates {
+		if _, ok := listed[name]; ok {
+			continue
+		}
+		info, err := h.stat(filepath.Join(path, name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		listed[name] = fs.FileInfoToDirEntry(&namedFileInfo{FileInfo: info, name: name})
+	}
+
+	result := make([]os.DirEntry, 0, len(listed))
+	for _, entry := range listed {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result, nil
+}
+
+// resolvePlanned Determine where the given path's content currently is on disk, considering planned moves (returns
+// false if the path would have been removed or moved away)
+func (h *Handler) resolvePlanned(path string) (string, bool) {
+	if h.plan == nil {
+		return path, true
+	}
+
+	// Walk back through the moves, so a path moved more than once is traced back to its origin
+	for i := len(h.plan.Moves) - 1; i >= 0; i-- {
+		move := h.plan.Moves[i]
+		if rel, ok := relativeTo(move.To, path); ok {
+			path = filepath.Join(move.From, rel)
+			continue
+		}
+		if _, ok := relativeTo(move.From, path); ok {
+			return "", false
+		}
+	}
+
+	for _, removal := range h.plan.Removals {
+		if _, ok := relativeTo(removal.Path, path); ok {
+			return "", false
+		}
+	}
+
+	return path, true
+}
+
+// hasPlannedWritesBelow Whether any planned write would create a file below the given folder
+func (h *Handler) hasPlannedWritesBelow(dirPath string) bool {
+	if h.plan == nil {
+		return false
+	}
+
+	for _, write := range h.plan.Writes {
+		if rel, ok := relativeTo(dirPath, write.Path); ok && rel != "." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// relativeTo Determine the given path relative to base, if the path is base itself or below it
+func relativeTo(base string, path string) (string, bool) {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// childName Determine the name of the direct child of dirPath which the given path is (or is below)
+func childName(dirPath string, path string) (string, bool) {
+	rel, ok := relativeTo(dirPath, path)
+	if !ok || rel == "." {
+		return "", false
+	}
+	return strings.SplitN(rel, string(filepath.Separator), 2)[0], true
+}
+
+// plannedFileInfo Details of a file (or folder) which would be created by a planned write
+type plannedFileInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (i *plannedFileInfo) Name() string { return i.name }
+func (i *plannedFileInfo) Size() int64  { return i.size }
+func (i *plannedFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0777
+	}
+	return 0666
+}
+func (i *plannedFileInfo) ModTime() time.Time { return i.modTime }
+func (i *plannedFileInfo) IsDir() bool        { return i.dir }
+func (i *plannedFileInfo) Sys() any           { return nil }
+
+// namedFileInfo File info listed under another name (e.g. a folder which would be moved to a new path)
+type namedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (i *namedFileInfo) Name() string { return i.name }
+
+// diskUsage Files stored at/below a path
+type diskUsage struct {
+	files int
+	bytes int64
+	// Most recent modification time of the path itself or any file/folder below it
+	modified time.Time
+}
+
+// usage Determine the number, combined size and most recent modification of all files at/below the given path
+func (h *Handler) usage(path string) (diskUsage, error) {
+	info, err := h.repository.Stat(path)
+	if err != nil {
+		return diskUsage{}, err
+	}
+
+	if !info.IsDir() {
+		return diskUsage{files: 1, bytes: info.Size(), modified: info.ModTime()}, nil
+	}
+
+	entries, err := h.repository.ReadDir(path)
+	if err != nil {
+		return diskUsage{}, err
+	}
+
+	total := diskUsage{modified: info.ModTime()}
+	for _, entry := range entries {
+		u, err := h.usage(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return diskUsage{}, err
+		}
+		total.files += u.files
+		total.bytes += u.bytes
+		if u.modified.After(total.modified) {
+			total.modified = u.modified
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,239 @@
+//go:build unit
+
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestHandler_DryRun(t *testing.T) {
+	basePath := filepath.Join("sandbox", bf2GameDirName)
+	backupDirPath := filepath.Join("sandbox", "backups")
+	profilesPath := filepath.Join(basePath, profilesDirName)
+
+	givenFiles := fstest.MapFS{
+		"Profiles/Global.con":                     {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+		"Profiles/0001/Profile.con":               {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+		"Profiles/0001/General.con":               {Data: []byte("GeneralSettings.addFavouriteServer \"2.2.2.2\"\r\nGeneralSettings.addServerHistory \"1.1.1.1\"\r\n")},
+		"mods/bf2/cache/{D7B71E3E}_112_1/a.cfx":   {Data: []byte("0123456789")},
+		"mods/bf2/cache/{D7B71E3E}_112_1/b.cfx":   {Data: []byte("01234")},
+		"mods/xpack/cache/{D7B71E3E}_112_2/c.cfx": {Data: []byte("012")},
+	}
+
+	setup := func(t *testing.T, options ...Option) (*Handler, *repository.MemoryRepository) {
+		repo := repository.NewMemory()
+		require.NoError(t, repo.Load(givenFiles, basePath))
+		options = append([]Option{WithBasePath(GameBf2, basePath), WithBackups(backupDirPath, 0)}, options...)
+		return New(repo, options...), repo
+	}
+
+	t.Run("records key-level diff instead of writing", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, WithDryRun())
+		globalCon, err := h.ReadGlobalConfig(GameBf2)
+		require.NoError(t, err)
+		globalCon.SetValue("GlobalSettings.setDefaultUser", *config.NewQuotedValue("0002"))
+
+		// WHEN
+		err = h.WriteConfigFile(globalCon)
+
+		// THEN
+		require.NoError(t, err)
+		assert.True(t, h.DryRun())
+		require.Len(t, h.Plan().Writes, 1)
+		write := h.Plan().Writes[0]
+		assert.Equal(t, filepath.Join(profilesPath, globalConFileName), write.Path)
+		assert.False(t, write.Create)
+		assert.Equal(t, []config.Change{
+			{
+				Key:  "GlobalSettings.setDefaultUser",
+				Type: config.ChangeTypeModified,
+				Old:  *config.NewQuotedValue("0001"),
+				New:  *config.NewQuotedValue("0002"),
+			},
+		}, write.Changes)
+		data, err := repo.ReadFile(filepath.Join(profilesPath, globalConFileName))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0001\"\r\n"), data)
+		// Nothing is written, so nothing is backed up either
+		exists, err := repo.DirExists(backupDirPath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("combines multiple writes to the same file", func(t *testing.T) {
+		// GIVEN
+		h, _ := setup(t, WithDryRun())
+		path := filepath.Join(profilesPath, "0001", "General.con")
+
+		// WHEN
+		generalCon, err := h.ReadConfigFile(path)
+		require.NoError(t, err)
+		generalCon.Delete("GeneralSettings.addServerHistory")
+		require.NoError(t, h.WriteConfigFile(generalCon))
+		// Reading the file again must return the planned content
+		generalCon, err = h.ReadConfigFile(path)
+		require.NoError(t, err)
+		generalCon.Delete("GeneralSettings.addFavouriteServer")
+		err = h.WriteConfigFile(generalCon)
+
+		// THEN
+		require.NoError(t, err)
+		require.Len(t, h.Plan().Writes, 1)
+		assert.Equal(t, []config.Change{
+			{Key: "GeneralSettings.addFavouriteServer", Type: config.ChangeTypeRemoved, Old: *config.NewQuotedValue("2.2.2.2")},
+			{Key: "GeneralSettings.addServerHistory", Type: config.ChangeTypeRemoved, Old: *config.NewQuotedValue("1.1.1.1")},
+		}, h.Plan().Writes[0].Changes)
+	})
+
+	t.Run("records creation of new file", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, WithDryRun())
+		path := filepath.Join(profilesPath, "0001", "DemoBookmarks.con")
+		demoBookmarksCon := config.New(path, map[string]config.Value{})
+		demoBookmarksCon.SetValue("LocalProfile.addDemoBookmark", *config.NewValue("\"server\""))
+
+		// WHEN
+		err := h.WriteConfigFile(demoBookmarksCon)
+
+		// THEN
+		require.NoError(t, err)
+		require.Len(t, h.Plan().Writes, 1)
+		assert.True(t, h.Plan().Writes[0].Create)
+		assert.Equal(t, config.ChangeTypeAdded, h.Plan().Writes[0].Changes[0].Type)
+		exists, err := repo.FileExists(path)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("records removals with sizes instead of removing", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, WithDryRun())
+
+		// WHEN
+		err := h.PurgeShaderCache(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, []PlannedRemoval{
+			{Path: filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E}_112_1"), Size: 15},
+			{Path: filepath.Join(basePath, modsDirName, "xpack", cacheDirName, "{D7B71E3E}_112_2"), Size: 3},
+		}, h.Plan().Removals)
+		exists, err := repo.FileExists(filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E}_112_1", "a.cfx"))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("reads reflect planned removals and writes", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, WithDryRun())
+		tx := h.Begin()
+		require.NoError(t, tx.Remove(filepath.Join(profilesPath, "0001")))
+		require.NoError(t, tx.WriteFile(filepath.Join(profilesPath, "0002", profileConFileName), []byte("LocalProfile.setName \"mister250\"\r\n")))
+		require.NoError(t, tx.Commit())
+
+		// WHEN
+		profileKeys, err := h.GetProfileKeys(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0002"}, profileKeys)
+		_, err = h.ReadFile(filepath.Join(profilesPath, "0001", profileConFileName))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		entries, err := h.ReadDir(filepath.Join(profilesPath, "0002"))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, profileConFileName, entries[0].Name())
+		exists, err := repo.DirExists(filepath.Join(profilesPath, "0001"))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("reads reflect planned moves", func(t *testing.T) {
+		// GIVEN
+		h, _ := setup(t, WithDryRun())
+		tx := h.Begin()
+		require.NoError(t, tx.Move(filepath.Join(profilesPath, "0001"), filepath.Join(profilesPath, "0003")))
+		require.NoError(t, tx.Commit())
+
+		// WHEN
+		profileKeys, err := h.GetProfileKeys(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0003"}, profileKeys)
+		data, err := h.ReadFile(filepath.Join(profilesPath, "0003", profileConFileName))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("LocalProfile.setName \"mister249\"\r\n"), data)
+		entries, err := h.ReadDir(profilesPath)
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.Equal(t, []string{"0003", globalConFileName}, names)
+	})
+
+	t.Run("does not plan anything outside of dry-run mode", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t)
+
+		// WHEN
+		err := h.PurgeShaderCache(GameBf2)
+
+		// THEN
+		require.NoError(t, err)
+		assert.False(t, h.DryRun())
+		assert.Nil(t, h.Plan())
+		exists, err := repo.DirExists(filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E}_112_1"))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestPlan_IsEmpty(t *testing.T) {
+	type test struct {
+		name          string
+		givenPlan     Plan
+		expectedEmpty bool
+	}
+
+	tests := []test{
+		{
+			name:          "empty without writes and removals",
+			givenPlan:     Plan{},
+			expectedEmpty: true,
+		},
+		{
+			name:      "not empty with writes",
+			givenPlan: Plan{Writes: []PlannedWrite{{Path: globalConFileName}}},
+		},
+		{
+			name:      "not empty with removals",
+			givenPlan: Plan{Removals: []PlannedRemoval{{Path: logoCacheDirName}}},
+		},
+		{
+			name:      "not empty with moves",
+			givenPlan: Plan{Moves: []PlannedMove{{From: logoCacheDirName, To: logoCacheDirName + ".old"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			empty := tt.givenPlan.IsEmpty()
+
+			// THEN
+			assert.Equal(t, tt.expectedEmpty, empty)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"time"
+)
+
+// Select which cache folders are purged
+type PurgeOption func(f *purgeFilter)
+
+type purgeFilter struct {
+	mods      []string
+	hosts     []string
+	olderThan time.Duration
+	maxBytes  int64
+}
+
+// Only purge the caches of the given mods (all mods' caches are purged by default) [shader cache only]
+func PurgeMods(mods ...string) PurgeOption {
+	return func(f *purgeFilter) {
+		f.mods = append(f.mods, mods...)
+	}
+}
+
+// Only purge cache folders which have not been modified for at least the given duration
+func PurgeOlderThan(age time.Duration) PurgeOption {
+	return func(f *purgeFilter) {
+		f.olderThan = age
+	}
+}
+
+// Only purge the cached images of the given hosts (all hosts' images are purged by default) [logo cache only]
+func PurgeHosts(hosts ...string) PurgeOption {
+	return func(f *purgeFilter) {
+		f.hosts = append(f.hosts, hosts...)
+	}
+}
+
+// Keep the cache within the given total size by purging the least recently modified folders first [logo cache only]
+func PurgeToQuota(maxBytes int64) PurgeOption {
+	return func(f *purgeFilter) {
+		f.maxBytes = maxBytes
+	}
+}
+
+// PurgeReport Cache folders removed by a purge (or which would have been removed in dry-run mode)
+type PurgeReport struct {
+	Removed []PurgedDir
+}
+
+// PurgedDir A single removed cache folder
+type PurgedDir struct {
+	Path string
+	// Name of the mod the cache folder belongs to [shader cache only]
+	Mod string
+	// Host the cached images were downloaded from [logo cache only]
+	Host  string
+	Files int
+	Bytes int64
+	// Most recent modification of the folder or any file in it
+	Modified time.Time
+}
+
+// Total number of files removed
+func (r *PurgeReport) Files() int {
+	var files int
+	for _, dir := range r.Removed {
+		files += dir.Files
+	}
+	return files
+}
+
+// Total number of bytes freed
+func (r *PurgeReport) Bytes() int64 {
+	var bytes int64
+	for _, dir := range r.Removed {
+		bytes += dir.Bytes
+	}
+	return bytes
+}
+
+// findCacheDirs Find all cache folders matching the given pattern, determining their usage
+func (h *Handler) findCacheDirs(pattern string) ([]PurgedDir, error) {
+	matches, err := h.repository.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]PurgedDir, 0, len(matches))
+	for _, match := range matches {
+		u, err := h.usage(match)
+		if err != nil {
+			return nil, err
+		}
+
+		dirs = append(dirs, PurgedDir{
+			Path:     match,
+			Files:    u.files,
+			Bytes:    u.bytes,
+			Modified: u.modified,
+		})
+	}
+
+	return dirs, nil
+}
+
+// isOlderThan Whether the given folder has not been modified for at least the filter's age (any folder is if no age is set)
+func (h *Handler) isOlderThan(dir PurgedDir, filter purgeFilter) bool {
+	return filter.olderThan <= 0 || h.now().Sub(dir.Modified) >= filter.olderThan
+}
+
+// purgeCacheDirs Remove the given cache folders (only recording the removals in dry-run mode)
+func (h *Handler) purgeCacheDirs(dirs []PurgedDir) (*PurgeReport, error) {
+	report := &PurgeReport{}
+	for _, dir := range dirs {
+		if err := h.removeAll(dir.Path); err != nil {
+			return report, err
+		}
+		report.Removed = append(report.Removed, dir)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type ErrGameRunning struct {
+	game string
+}
+
+func (e *ErrGameRunning) Error() string {
+	return fmt.Sprintf("game is running, changes would be lost once it exits: %s", e.game)
+}
+
+// checkNotRunning Ensure none of the games owning any of the given paths is running, since games overwrite their
+// configuration files on exit (only checked if a process detector is configured)
+func (h *Handler) checkNotRunning(paths ...string) error {
+	if h.detector == nil || h.ignoreRunning {
+		return nil
+	}
+
+	for _, game := range RegisteredGames() {
+		descriptor, ok := LookupGame(game)
+		if !ok || len(descriptor.Executables) == 0 || !h.ownsAny(game, paths) {
+			continue
+		}
+
+		running, err := h.detector.IsRunning(descriptor.Executables...)
+		if err != nil {
+			return fmt.Errorf("failed to determine whether %s is running: %w", game, err)
+		}
+		if running {
+			return &ErrGameRunning{game: string(game)}
+		}
+	}
+
+	return nil
+}
+
+// ownsAny Whether any of the given paths is located in the given game's base or profiles folder
+func (h *Handler) ownsAny(game Game, paths []string) bool {
+	for _, root := range h.gameRoots(game) {
+		for _, path := range paths {
+			if isWithin(root, path) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// gameRoots Base and profiles folder of the given game, which are only resolved once per handler since resolving them
+// may require reading (Wine) registry files
+func (h *Handler) gameRoots(game Game) []string {
+	h.rootsMu.Lock()
+	defer h.rootsMu.Unlock()
+
+	if roots, ok := h.roots[game]; ok {
+		return roots
+	}
+
+	roots := []string{}
+	// Games whose folders cannot be determined (e.g. because they are not installed) cannot own any path
+	if basePath, err := h.BuildBasePath(game); err == nil {
+		roots = append(roots, basePath)
+	}
+	if profilesPath, err := h.BuildProfilesFolderPath(game); err == nil {
+		roots = append(roots, profilesPath)
+	}
+
+	h.roots[game] = roots
+	return roots
+}
+
+// isWithin Whether the given path is the given root folder or located below it
+func isWithin(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
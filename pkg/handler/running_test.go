@@ -0,0 +1,209 @@
+//go:build unit
+
+package handler
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+// fakeDetector Detector reporting the given executables as running
+type fakeDetector struct {
+	running []string
+	err     error
+}
+
+func (d *fakeDetector) IsRunning(executables ...string) (bool, error) {
+	if d.err != nil {
+		return false, d.err
+	}
+	for _, executable := range executables {
+		if slices.Contains(d.running, executable) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestHandler_RefusesWritesWhileGameIsRunning(t *testing.T) {
+	documentsPath := filepath.Join("build", "documents")
+	bf2BasePath := filepath.Join(documentsPath, bf2GameDirName)
+	bf2142BasePath := filepath.Join(documentsPath, bf2142GameDirName)
+	globalConPath := filepath.Join(bf2BasePath, profilesDirName, globalConFileName)
+
+	type test struct {
+		name            string
+		givenDetector   *fakeDetector
+		givenOptions    []Option
+		givenAction     func(h *Handler) error
+		wantUnchanged   bool
+		wantErrContains string
+	}
+
+	writeGlobalCon := func(h *Handler) error {
+		globalCon := config.FromBytes(globalConPath, []byte{})
+		globalCon.SetValue("GlobalSettings.setDefaultUser", *config.NewQuotedValue("0002"))
+		return h.WriteConfigFile(globalCon)
+	}
+
+	tests := []test{
+		{
+			name:            "error writing config file while game is running",
+			givenDetector:   &fakeDetector{running: []string{bf2Executable}},
+			givenAction:     writeGlobalCon,
+			wantUnchanged:   true,
+			wantErrContains: "game is running, changes would be lost once it exits: bf2",
+		},
+		{
+			name:          "writes config file while other game is running",
+			givenDetector: &fakeDetector{running: []string{bf2142Executable}},
+			givenAction:   writeGlobalCon,
+		},
+		{
+			name:          "writes config file while game is running if overridden",
+			givenDetector: &fakeDetector{running: []string{bf2Executable}},
+			givenOptions:  []Option{WithIgnoreRunningGame()},
+			givenAction:   writeGlobalCon,
+		},
+		{
+			name:          "plans writes while game is running in dry-run mode",
+			givenDetector: &fakeDetector{running: []string{bf2Executable}},
+			givenOptions:  []Option{WithDryRun()},
+			givenAction:   writeGlobalCon,
+			wantUnchanged: true,
+		},
+		{
+			name:          "error purging cache while game is running",
+			givenDetector: &fakeDetector{running: []string{bf2Executable}},
+			givenAction: func(h *Handler) error {
+				return h.PurgeLogoCache(GameBf2)
+			},
+			wantUnchanged:   true,
+			wantErrContains: "game is running",
+		},
+		{
+			name:          "error committing transaction while game is running",
+			givenDetector: &fakeDetector{running: []string{bf2Executable}},
+			givenAction: func(h *Handler) error {
+				tx := h.Begin()
+				// The first step does not belong to the running game, but must not be applied either
+				generalCon := config.FromBytes(filepath.Join(bf2142BasePath, profilesDirName, "0001", "General.con"), []byte{})
+				if err := tx.WriteConfigFile(generalCon); err != nil {
+					return err
+				}
+				if err := tx.Remove(globalConPath); err != nil {
+					return err
+				}
+				return tx.Commit()
+			},
+			wantUnchanged:   true,
+			wantErrContains: "game is running",
+		},
+		{
+			name:            "error if detector fails",
+			givenDetector:   &fakeDetector{err: errors.New("access denied")},
+			givenAction:     writeGlobalCon,
+			wantUnchanged:   true,
+			wantErrContains: "failed to determine whether bf2 is running: access denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"Profiles/Global.con":             {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+				"LogoCache/www.example.com/a.png": {Data: []byte{}},
+			}, bf2BasePath))
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"Profiles/0001/Profile.con": {Data: []byte{}},
+			}, bf2142BasePath))
+			options := append([]Option{WithDocumentsPath(documentsPath), WithProcessDetector(tt.givenDetector)}, tt.givenOptions...)
+			handler := New(repo, options...)
+
+			// WHEN
+			err := tt.givenAction(handler)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+			}
+			data, err := repo.ReadFile(globalConPath)
+			if tt.wantUnchanged {
+				require.NoError(t, err)
+				assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0001\"\r\n"), data)
+				exists, err := repo.DirExists(filepath.Join(bf2BasePath, logoCacheDirName, "www.example.com"))
+				require.NoError(t, err)
+				assert.True(t, exists)
+				exists, err = repo.FileExists(filepath.Join(bf2142BasePath, profilesDirName, "0001", "General.con"))
+				require.NoError(t, err)
+				assert.False(t, exists)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0002\"\r\n"), data)
+			}
+		})
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	type test struct {
+		name      string
+		givenRoot string
+		givenPath string
+		want      bool
+	}
+
+	tests := []test{
+		{
+			name:      "path below root",
+			givenRoot: filepath.Join("documents", bf2GameDirName),
+			givenPath: filepath.Join("documents", bf2GameDirName, profilesDirName, globalConFileName),
+			want:      true,
+		},
+		{
+			name:      "path is root",
+			givenRoot: filepath.Join("documents", bf2GameDirName),
+			givenPath: filepath.Join("documents", bf2GameDirName),
+			want:      true,
+		},
+		{
+			name:      "path in sibling folder with common prefix",
+			givenRoot: filepath.Join("documents", bf2GameDirName),
+			givenPath: filepath.Join("documents", bf2142GameDirName, profilesDirName),
+		},
+		{
+			name:      "path in folder starting with dots",
+			givenRoot: filepath.Join("documents", bf2GameDirName),
+			givenPath: filepath.Join("documents", bf2GameDirName, "..backup"),
+			want:      true,
+		},
+		{
+			name:      "path above root",
+			givenRoot: filepath.Join("documents", bf2GameDirName),
+			givenPath: "documents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			within := isWithin(tt.givenRoot, tt.givenPath)
+
+			// THEN
+			assert.Equal(t, tt.want, within)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"path/filepath"
+)
+
+// Purge the shader cache folders selected by the given options, reporting which folders were removed
+func (h *Handler) PurgeShaderCacheWithReport(game Game, options ...PurgeOption) (*PurgeReport, error) {
+	descriptor, err := getGame(game)
+	if err != nil {
+		return nil, err
+	}
+	if !descriptor.Supports(ActionPurgeShaderCache) {
+		return nil, &ErrActionNotSupportedForGame{
+			action: string(ActionPurgeShaderCache),
+			game:   string(game),
+		}
+	}
+
+	filter := purgeFilter{}
+	for _, option := range options {
+		option(&filter)
+	}
+
+	basePath, err := h.BuildBasePath(game)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := []string{filepath.Join(basePath, descriptor.ShaderCachePattern)}
+	if len(filter.mods) > 0 {
+		patterns = nil
+		seen := map[string]bool{}
+		for _, name := range filter.mods {
+			// Only purge caches of mods which are actually installed
+			mod, err := h.GetMod(game, name)
+			if err != nil {
+				return nil, err
+			}
+			// Purge each mod's cache only once, even if the mod was given more than once (or in different case)
+			if seen[mod.Name] {
+				continue
+			}
+			seen[mod.Name] = true
+			patterns = append(patterns, filepath.Join(basePath, descriptor.ModsDirName, mod.Name, cacheDirName, "*"))
+		}
+	}
+
+	var selected []PurgedDir
+	for _, pattern := range patterns {
+		dirs, err := h.findCacheDirs(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dir := range dirs {
+			if !h.isOlderThan(dir, filter) {
+				continue
+			}
+			// Cache folders are stored in [mod]/cache/[cache folder]
+			dir.Mod = filepath.Base(filepath.Dir(filepath.Dir(dir.Path)))
+			selected = append(selected, dir)
+		}
+	}
+
+	return h.purgeCacheDirs(selected)
+}
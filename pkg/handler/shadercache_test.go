@@ -0,0 +1,156 @@
+//go:build unit
+
+package handler
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestHandler_PurgeShaderCacheWithReport(t *testing.T) {
+	installPath := filepath.Join("games", "bf2")
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+	bf2CachePath := filepath.Join(basePath, modsDirName, "bf2", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1")
+	xpackCachePath := filepath.Join(basePath, modsDirName, "xpack", cacheDirName, "{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_2")
+
+	type test struct {
+		name            string
+		givenOptions    []PurgeOption
+		givenDryRun     bool
+		wantReport      *PurgeReport
+		wantFiles       int
+		wantBytes       int64
+		wantRemaining   []string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "purges all mods' caches",
+			wantReport: &PurgeReport{Removed: []PurgedDir{
+				{Path: bf2CachePath, Mod: "bf2", Files: 2, Bytes: 7, Modified: old},
+				{Path: xpackCachePath, Mod: "xpack", Files: 1, Bytes: 5, Modified: recent},
+			}},
+			wantFiles: 3,
+			wantBytes: 12,
+		},
+		{
+			name:         "purges caches of selected mods only",
+			givenOptions: []PurgeOption{PurgeMods("XPACK")},
+			wantReport: &PurgeReport{Removed: []PurgedDir{
+				{Path: xpackCachePath, Mod: "xpack", Files: 1, Bytes: 5, Modified: recent},
+			}},
+			wantFiles:     1,
+			wantBytes:     5,
+			wantRemaining: []string{bf2CachePath},
+		},
+		{
+			name:         "purges cache of mod given more than once only once",
+			givenOptions: []PurgeOption{PurgeMods("xpack", "XPACK"), PurgeMods("xpack")},
+			wantReport: &PurgeReport{Removed: []PurgedDir{
+				{Path: xpackCachePath, Mod: "xpack", Files: 1, Bytes: 5, Modified: recent},
+			}},
+			wantFiles:     1,
+			wantBytes:     5,
+			wantRemaining: []string{bf2CachePath},
+		},
+		{
+			name:         "purges caches older than given age only",
+			givenOptions: []PurgeOption{PurgeOlderThan(7 * 24 * time.Hour)},
+			wantReport: &PurgeReport{Removed: []PurgedDir{
+				{Path: bf2CachePath, Mod: "bf2", Files: 2, Bytes: 7, Modified: old},
+			}},
+			wantFiles:     2,
+			wantBytes:     7,
+			wantRemaining: []string{xpackCachePath},
+		},
+		{
+			name:         "reports but does not purge caches in dry-run mode",
+			givenOptions: []PurgeOption{PurgeMods("bf2")},
+			givenDryRun:  true,
+			wantReport: &PurgeReport{Removed: []PurgedDir{
+				{Path: bf2CachePath, Mod: "bf2", Files: 2, Bytes: 7, Modified: old},
+			}},
+			wantFiles:     2,
+			wantBytes:     7,
+			wantRemaining: []string{bf2CachePath, xpackCachePath},
+		},
+		{
+			name:            "error for mod which is not installed",
+			givenOptions:    []PurgeOption{PurgeMods("bf2", "pr")},
+			wantRemaining:   []string{bf2CachePath, xpackCachePath},
+			wantErrContains: "mod not found: pr, bf2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"mods/bf2/mod.desc":   {Data: []byte(bf2ModDesc)},
+				"mods/xpack/mod.desc": {Data: []byte(xpackModDesc)},
+			}, installPath))
+			require.NoError(t, repo.Load(fstest.MapFS{
+				"mods/bf2/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1":       {Mode: fs.ModeDir, ModTime: old},
+				"mods/bf2/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1/a.cfx": {Data: []byte("abc"), ModTime: old},
+				"mods/bf2/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_1/b.cfx": {Data: []byte("defg"), ModTime: old},
+				// Folder itself is old, but contains a recently modified file
+				"mods/xpack/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_2":       {Mode: fs.ModeDir, ModTime: old},
+				"mods/xpack/cache/{D7B71E3E-5F43-11CF-726F-0D3C0EC2D335}_112_2/a.cfx": {Data: []byte("hijkl"), ModTime: recent},
+			}, basePath))
+			options := []Option{WithBasePath(GameBf2, basePath), WithInstallPath(GameBf2, installPath)}
+			if tt.givenDryRun {
+				options = append(options, WithDryRun())
+			}
+			handler := New(repo, options...)
+			handler.now = func() time.Time {
+				return now
+			}
+
+			// WHEN
+			report, err := handler.PurgeShaderCacheWithReport(GameBf2, tt.givenOptions...)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantReport, report)
+				assert.Equal(t, tt.wantFiles, report.Files())
+				assert.Equal(t, tt.wantBytes, report.Bytes())
+			}
+			for _, path := range []string{bf2CachePath, xpackCachePath} {
+				exists, err := repo.DirExists(path)
+				require.NoError(t, err)
+				assert.Equal(t, slices.Contains(tt.wantRemaining, path), exists, path)
+			}
+			if tt.givenDryRun {
+				assert.Equal(t, []PlannedRemoval{{Path: bf2CachePath, Size: 7}}, handler.Plan().Removals)
+			}
+		})
+	}
+}
+
+func TestHandler_PurgeShaderCacheWithReport_NotSupported(t *testing.T) {
+	// GIVEN
+	handler := New(repository.NewMemory(), WithBasePath(GameBf1942, filepath.Join("games", "bf1942")))
+
+	// WHEN
+	_, err := handler.PurgeShaderCacheWithReport(GameBf1942)
+
+	// THEN
+	require.ErrorContains(t, err, "action not supported for game: PurgeShaderCache, bf1942")
+}
@@ -0,0 +1,421 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cetteup/conman/pkg/config"
+)
+
+const (
+	trashFileSuffix = ".trash"
+)
+
+var ErrTransactionDone = errors.New("transaction has already been committed or rolled back")
+
+type ErrCommitFailed struct {
+	path        string
+	err         error
+	rollbackErr error
+}
+
+func (e *ErrCommitFailed) Error() string {
+	if e.rollbackErr != nil {
+		return fmt.Sprintf("failed to commit change to %s: %s (rollback failed: %s)", e.path, e.err, e.rollbackErr)
+	}
+	return fmt.Sprintf("failed to commit change to %s: %s (rolled back)", e.path, e.err)
+}
+
+func (e *ErrCommitFailed) Unwrap() error {
+	return e.err
+}
+
+// ErrCleanupFailed All changes were applied, but some removed files/folders could not be deleted from where they
+// were moved aside to (e.g. because a file is still opened by another program)
+type ErrCleanupFailed struct {
+	errs []error
+}
+
+func (e *ErrCleanupFailed) Error() string {
+	return fmt.Sprintf("changes were applied, but failed to delete removed files: %s", errors.Join(e.errs...))
+}
+
+func (e *ErrCleanupFailed) Unwrap() []error {
+	return e.errs
+}
+
+// Transaction Writes and removals staged to be applied together (either all of them are applied or none are)
+type Transaction struct {
+	h     *Handler
+	steps []*transactionStep
+	done  bool
+}
+
+type transactionStep struct {
+	path   string
+	data   []byte
+	remove bool
+	moveTo string
+
+	// State required to undo the step once it has been applied
+	applied  bool
+	existed  bool
+	previous []byte
+	// Top-most parent folder created for the write (if any)
+	createdDir string
+	// Path the removed file/folder was moved aside to
+	trashPath string
+	// Hidden folder the removed folder was moved into (if any)
+	trashDir string
+}
+
+// Start a new transaction (nothing is changed until the transaction is committed)
+func (h *Handler) Begin() *Transaction {
+	return &Transaction{h: h}
+}
+
+// Read the config file at given path, taking any changes staged in the transaction into account
+func (t *Transaction) ReadConfigFile(path string) (*config.Config, error) {
+	data, err := t.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.FromBytes(path, data), nil
+}
+
+// Read the raw content of the file at given path, taking any changes staged in the transaction into account
+func (t *Transaction) ReadFile(path string) ([]byte, error) {
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		if t.steps[i].path == path {
+			if t.steps[i].remove || t.steps[i].moveTo != "" {
+				return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+			}
+			return t.steps[i].data, nil
+		}
+	}
+
+	return t.h.readFile(path)
+}
+
+// Stage writing the given config file (creating any missing parent folders)
+func (t *Transaction) WriteConfigFile(c *config.Config) error {
+	return t.WriteFile(c.Path, c.ToBytes())
+}
+
+// Stage writing the given raw content to the file at given path (creating any missing parent folders), which keeps
+// the file exactly as is rather than re-serializing it like WriteConfigFile does
+func (t *Transaction) WriteFile(path string, data []byte) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.steps = append(t.steps, &transactionStep{path: path, data: data})
+	return nil
+}
+
+// Stage removing the file or folder at the given path (removing a non-existing path is not an error); removed files
+// are backed up like overwritten ones (if backups are enabled), but removed folders are not, since e.g. a profile folder
+// also holds demos and other large files (move the folder instead to keep it)
+func (t *Transaction) Remove(path string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.steps = append(t.steps, &transactionStep{path: path, remove: true})
+	return nil
+}
+
+// Stage moving the file or folder at the given path to a new path (creating any missing parent folders),
+// committing fails if the old path does not exist or the new path already exists
+func (t *Transaction) Move(oldPath string, newPath string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.steps = append(t.steps, &transactionStep{path: oldPath, moveTo: newPath})
+	return nil
+}
+
+// Discard all staged changes
+func (t *Transaction) Rollback() {
+	t.done = true
+	t.steps = nil
+}
+
+// Apply all staged changes in order, undoing any already applied changes if a later change fails (returns
+// ErrCleanupFailed if all changes were applied, but removed files/folders could not be deleted afterwards)
+func (t *Transaction) Commit() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+
+	// Check all paths up front, so the transaction is not even partially applied while a game is running
+	if !t.h.DryRun() {
+		paths := make([]string, 0, len(t.steps))
+		for _, step := range t.steps {
+			paths = append(paths, step.path)
+			if step.moveTo != "" {
+				paths = append(paths, step.moveTo)
+			}
+		}
+		if err := t.h.checkNotRunning(paths...); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range t.steps {
+		if err := t.apply(step); err != nil {
+			return &ErrCommitFailed{
+				path:        step.path,
+				err:         err,
+				rollbackErr: t.undo(),
+			}
+		}
+	}
+
+	// Removed files are only moved aside while the transaction is applied, actually remove them now that it cannot fail anymore
+	var errs []error
+	for _, step := range t.steps {
+		if step.remove && step.existed && !t.h.DryRun() {
+			trashPath := step.trashPath
+			if step.trashDir != "" {
+				trashPath = step.trashDir
+			}
+			if err := t.h.repository.RemoveAll(trashPath); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", trashPath, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return &ErrCleanupFailed{errs: errs}
+	}
+
+	return nil
+}
+
+func (t *Transaction) apply(step *transactionStep) error {
+	if t.h.DryRun() {
+		if step.remove {
+			return t.planRemove(step)
+		}
+		if step.moveTo != "" {
+			return t.planMove(step)
+		}
+		return t.h.writeFile(step.path, step.data)
+	}
+
+	if step.remove {
+		return t.remove(step)
+	}
+	if step.moveTo != "" {
+		return t.move(step)
+	}
+
+	return t.write(step)
+}
+
+func (t *Transaction) write(step *transactionStep) error {
+	previous, err := t.h.repository.ReadFile(step.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	step.existed = err == nil
+	step.previous = previous
+
+	if !step.existed {
+		if step.createdDir, err = t.mkdirParents(step.path); err != nil {
+			return err
+		}
+	}
+
+	if err = t.h.writeFile(step.path, step.data); err != nil {
+		if step.createdDir != "" {
+			_ = t.h.repository.RemoveAll(step.createdDir)
+		}
+		return err
+	}
+
+	step.applied = true
+	return nil
+}
+
+// mkdirParents Create any missing parent folders of the given path, returning the top-most folder which was created
+func (t *Transaction) mkdirParents(path string) (string, error) {
+	var created string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		exists, err := t.h.repository.DirExists(dir)
+		if err != nil {
+			return "", err
+		}
+		if exists || dir == filepath.Dir(dir) {
+			break
+		}
+		created = dir
+	}
+
+	if created == "" {
+		return "", nil
+	}
+
+	if err := t.h.repository.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return "", err
+	}
+
+	return created, nil
+}
+
+func (t *Transaction) remove(step *transactionStep) error {
+	info, err := t.h.repository.Stat(step.path)
+	if err != nil {
+		// Nothing to remove
+		if errors.Is(err, os.ErrNotExist) {
+			step.applied = true
+			return nil
+		}
+		return err
+	}
+
+	if !info.IsDir() {
+		if err = t.h.backupFile(step.path); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", step.path, err)
+		}
+	}
+
+	// Move the file/folder aside instead of removing it, so it can be moved back if the transaction fails
+	// (staying in the same parent folder, so the rename is atomic and does not depend on any other folder)
+	trashPath := t.buildTrashPath(step.path)
+	if info.IsDir() {
+		// Nest folders in a hidden folder, so a folder which cannot be deleted after all (e.g. a profile folder)
+		// is never mistaken for the original
+		if err = t.h.repository.MkdirAll(trashPath, 0777); err != nil {
+			return err
+		}
+		step.trashDir = trashPath
+		trashPath = filepath.Join(trashPath, filepath.Base(step.path))
+	}
+	if err = t.h.repository.Rename(step.path, trashPath); err != nil {
+		if step.trashDir != "" {
+			_ = t.h.repository.RemoveAll(step.trashDir)
+			step.trashDir = ""
+		}
+		return err
+	}
+
+	step.trashPath = trashPath
+	step.existed = true
+	step.applied = true
+	return nil
+}
+
+// buildTrashPath Build the (hidden) path next to the given file/folder to move it aside to
+func (t *Transaction) buildTrashPath(path string) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.%s%s", filepath.Base(path), t.h.tmpSuffix(), trashFileSuffix))
+}
+
+func (t *Transaction) planRemove(step *transactionStep) error {
+	if _, err := t.h.stat(step.path); err != nil {
+		// Nothing to remove
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	return t.h.planRemoval(step.path)
+}
+
+func (t *Transaction) move(step *transactionStep) error {
+	if err := t.checkMovable(step); err != nil {
+		return err
+	}
+
+	createdDir, err := t.mkdirParents(step.moveTo)
+	if err != nil {
+		return err
+	}
+
+	if err = t.h.repository.Rename(step.path, step.moveTo); err != nil {
+		if createdDir != "" {
+			_ = t.h.repository.RemoveAll(createdDir)
+		}
+		return err
+	}
+
+	step.createdDir = createdDir
+	step.applied = true
+	return nil
+}
+
+func (t *Transaction) planMove(step *transactionStep) error {
+	if err := t.checkMovable(step); err != nil {
+		return err
+	}
+
+	t.h.planMove(step.path, step.moveTo)
+	return nil
+}
+
+// checkMovable Ensure the step's old path exists and its new path does not (renaming would replace it on some platforms)
+func (t *Transaction) checkMovable(step *transactionStep) error {
+	if _, err := t.h.stat(step.path); err != nil {
+		return err
+	}
+
+	_, err := t.h.stat(step.moveTo)
+	if err == nil {
+		return &os.PathError{Op: "rename", Path: step.moveTo, Err: os.ErrExist}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// undo Revert all applied steps in reverse order
+func (t *Transaction) undo() error {
+	if t.h.DryRun() {
+		return nil
+	}
+
+	var errs []error
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		if !step.applied {
+			continue
+		}
+
+		var err error
+		switch {
+		case step.remove && step.existed:
+			err = t.h.repository.Rename(step.trashPath, step.path)
+			if err == nil && step.trashDir != "" {
+				err = t.h.repository.RemoveAll(step.trashDir)
+			}
+		case step.remove:
+			// Nothing was removed, so there is nothing to restore
+		case step.moveTo != "":
+			err = t.h.repository.Rename(step.moveTo, step.path)
+			if err == nil && step.createdDir != "" {
+				err = t.h.repository.RemoveAll(step.createdDir)
+			}
+		case step.existed:
+			err = t.h.writeFileAtomic(step.path, step.previous)
+		case step.createdDir != "":
+			err = t.h.repository.RemoveAll(step.createdDir)
+		default:
+			err = t.h.repository.RemoveAll(step.path)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", step.path, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
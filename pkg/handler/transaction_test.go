@@ -0,0 +1,418 @@
+//go:build unit
+
+package handler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+// failingRepository Memory repository failing to rename anything to the given path or remove the given path
+type failingRepository struct {
+	*repository.MemoryRepository
+	failRenameTo string
+	failRemove   string
+}
+
+func (r *failingRepository) Rename(oldpath string, newpath string) error {
+	if newpath == r.failRenameTo {
+		return errors.New("disk full")
+	}
+	return r.MemoryRepository.Rename(oldpath, newpath)
+}
+
+func (r *failingRepository) RemoveAll(path string) error {
+	if path == r.failRemove {
+		return errors.New("file is locked")
+	}
+	return r.MemoryRepository.RemoveAll(path)
+}
+
+func TestTransaction_Commit(t *testing.T) {
+	basePath := filepath.Join("sandbox", bf2GameDirName)
+	profilesPath := filepath.Join(basePath, profilesDirName)
+	globalConPath := filepath.Join(profilesPath, globalConFileName)
+	profileConPath := filepath.Join(profilesPath, "0001", profileConFileName)
+	generalConPath := filepath.Join(profilesPath, "0001", "General.con")
+	demoBookmarksConPath := filepath.Join(profilesPath, "0001", "DemoBookmarks.con")
+	demoBookmarksConTrashPath := filepath.Join(profilesPath, "0001", ".DemoBookmarks.con."+testTmpSuffix+trashFileSuffix)
+
+	givenFiles := fstest.MapFS{
+		"Profiles/Global.con":             {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+		"Profiles/0001/Profile.con":       {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+		"Profiles/0001/General.con":       {Data: []byte("GeneralSettings.addServerHistory \"1.1.1.1\"\r\n")},
+		"Profiles/0001/DemoBookmarks.con": {Data: []byte("LocalProfile.addDemoBookmark \"server\"\r\n")},
+	}
+
+	setup := func(t *testing.T, failRenameTo string, options ...Option) (*Handler, *failingRepository) {
+		repo := &failingRepository{MemoryRepository: repository.NewMemory(), failRenameTo: failRenameTo}
+		require.NoError(t, repo.Load(givenFiles, basePath))
+		options = append([]Option{WithBasePath(GameBf2, basePath)}, options...)
+		h := New(repo, options...)
+		h.tmpSuffix = func() string {
+			return testTmpSuffix
+		}
+		return h, repo
+	}
+
+	stage := func(t *testing.T, h *Handler) *Transaction {
+		tx := h.Begin()
+		globalCon := config.FromBytes(globalConPath, []byte{})
+		globalCon.SetValue("GlobalSettings.setDefaultUser", *config.NewQuotedValue("0002"))
+		require.NoError(t, tx.WriteConfigFile(globalCon))
+		profileCon := config.FromBytes(profileConPath, []byte{})
+		profileCon.SetValue("LocalProfile.setName", *config.NewQuotedValue("mister250"))
+		require.NoError(t, tx.WriteConfigFile(profileCon))
+		require.NoError(t, tx.Remove(demoBookmarksConPath))
+		serverSettingsCon := config.FromBytes(filepath.Join(profilesPath, "0001", "ServerSettings.con"), []byte{})
+		serverSettingsCon.SetValue("ServerSettings.setServerName", *config.NewQuotedValue("bf2"))
+		require.NoError(t, tx.WriteConfigFile(serverSettingsCon))
+		return tx
+	}
+
+	t.Run("applies all staged changes", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, "")
+		tx := stage(t, h)
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repo.ReadFile(globalConPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0002\"\r\n"), data)
+		data, err = repo.ReadFile(profileConPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("LocalProfile.setName \"mister250\"\r\n"), data)
+		exists, err := repo.FileExists(demoBookmarksConPath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = repo.FileExists(demoBookmarksConTrashPath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = repo.FileExists(filepath.Join(profilesPath, "0001", "ServerSettings.con"))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("backs up removed files", func(t *testing.T) {
+		// GIVEN
+		backupDirPath := filepath.Join("sandbox", "backups")
+		h, _ := setup(t, "", WithBackups(backupDirPath, 0))
+		tx := h.Begin()
+		require.NoError(t, tx.Remove(demoBookmarksConPath))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.NoError(t, err)
+		backups, err := h.ListBackups()
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		assert.Equal(t, demoBookmarksConPath, backups[0].Path)
+	})
+
+	t.Run("moves removed folder aside into hidden folder", func(t *testing.T) {
+		// GIVEN
+		profilePath := filepath.Join(profilesPath, "0001")
+		trashPath := filepath.Join(profilesPath, ".0001."+testTmpSuffix+trashFileSuffix)
+		h, repo := setup(t, "")
+		repo.failRemove = trashPath
+		tx := h.Begin()
+		require.NoError(t, tx.Remove(profilePath))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		var cleanupErr *ErrCleanupFailed
+		require.ErrorAs(t, err, &cleanupErr)
+		assert.ErrorContains(t, err, "file is locked")
+		exists, err := repo.DirExists(profilePath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		// Folder which could not be deleted must not be listed as a profile
+		exists, err = repo.DirExists(trashPath)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		profileKeys, err := h.GetProfileKeys(GameBf2)
+		require.NoError(t, err)
+		assert.Empty(t, profileKeys)
+	})
+
+	t.Run("rolls back applied changes if a later change fails", func(t *testing.T) {
+		// GIVEN
+		// Fail writing the last file (after all other changes have been applied)
+		serverSettingsConPath := filepath.Join(profilesPath, "0001", "ServerSettings.con")
+		h, repo := setup(t, serverSettingsConPath)
+		tx := stage(t, h)
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		var commitErr *ErrCommitFailed
+		require.ErrorAs(t, err, &commitErr)
+		assert.ErrorContains(t, err, "disk full")
+		assert.ErrorContains(t, err, "rolled back")
+		for path, want := range map[string]string{
+			globalConPath:        "GlobalSettings.setDefaultUser \"0001\"\r\n",
+			profileConPath:       "LocalProfile.setName \"mister249\"\r\n",
+			generalConPath:       "GeneralSettings.addServerHistory \"1.1.1.1\"\r\n",
+			demoBookmarksConPath: "LocalProfile.addDemoBookmark \"server\"\r\n",
+		} {
+			data, err := repo.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, []byte(want), data, path)
+		}
+		exists, err := repo.FileExists(serverSettingsConPath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = repo.FileExists(demoBookmarksConTrashPath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("restores removed folder on rollback", func(t *testing.T) {
+		// GIVEN
+		profilePath := filepath.Join(profilesPath, "0001")
+		h, repo := setup(t, globalConPath)
+		tx := h.Begin()
+		require.NoError(t, tx.Remove(profilePath))
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(globalConPath, []byte{})))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		var commitErr *ErrCommitFailed
+		require.ErrorAs(t, err, &commitErr)
+		data, err := repo.ReadFile(profileConPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("LocalProfile.setName \"mister249\"\r\n"), data)
+		trashed, err := repo.Glob(filepath.Join(profilesPath, "*"+trashFileSuffix))
+		require.NoError(t, err)
+		assert.Empty(t, trashed)
+	})
+
+	t.Run("removes newly created files on rollback", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, globalConPath)
+		tx := h.Begin()
+		videoCon := config.FromBytes(filepath.Join(profilesPath, "0001", "Video.con"), []byte{})
+		videoCon.SetValue("VideoSettings.setResolution", *config.NewValue("1920x1080@60Hz"))
+		require.NoError(t, tx.WriteConfigFile(videoCon))
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(globalConPath, []byte{})))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.Error(t, err)
+		exists, err := repo.FileExists(videoCon.Path)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("creates missing parent folders", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, "")
+		tx := h.Begin()
+		newProfileConPath := filepath.Join(profilesPath, "0002", profileConFileName)
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(newProfileConPath, []byte("LocalProfile.setName \"mister250\"\r\n"))))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repo.ReadFile(newProfileConPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("LocalProfile.setName \"mister250\"\r\n"), data)
+	})
+
+	t.Run("removes newly created folders on rollback", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, globalConPath)
+		tx := h.Begin()
+		newProfilePath := filepath.Join(profilesPath, "0002")
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(filepath.Join(newProfilePath, profileConFileName), []byte("LocalProfile.setName \"mister250\"\r\n"))))
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(filepath.Join(newProfilePath, "General.con"), []byte("GeneralSettings.setHUDTransparency 100\r\n"))))
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(globalConPath, []byte{})))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.Error(t, err)
+		exists, err := repo.DirExists(newProfilePath)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("moves folder into missing parent folders", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, "")
+		tx := h.Begin()
+		recyclePath := filepath.Join("sandbox", "recycle", "0001")
+		require.NoError(t, tx.Move(filepath.Join(profilesPath, "0001"), recyclePath))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.NoError(t, err)
+		exists, err := repo.DirExists(filepath.Join(profilesPath, "0001"))
+		require.NoError(t, err)
+		assert.False(t, exists)
+		data, err := repo.ReadFile(filepath.Join(recyclePath, profileConFileName))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("LocalProfile.setName \"mister249\"\r\n"), data)
+	})
+
+	t.Run("moves folder back on rollback", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, globalConPath)
+		tx := h.Begin()
+		recyclePath := filepath.Join("sandbox", "recycle", "0001")
+		require.NoError(t, tx.Move(filepath.Join(profilesPath, "0001"), recyclePath))
+		require.NoError(t, tx.WriteConfigFile(config.FromBytes(globalConPath, []byte{})))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.Error(t, err)
+		exists, err := repo.FileExists(profileConPath)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = repo.DirExists(filepath.Join("sandbox", "recycle"))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("error when moving to existing path", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, "")
+		tx := h.Begin()
+		require.NoError(t, tx.Move(generalConPath, profileConPath))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrExist)
+		exists, err := repo.FileExists(generalConPath)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("only plans changes in dry-run mode", func(t *testing.T) {
+		// GIVEN
+		h, repo := setup(t, "", WithDryRun())
+		tx := stage(t, h)
+		require.NoError(t, tx.Move(generalConPath, generalConPath+".bak"))
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.NoError(t, err)
+		assert.Len(t, h.Plan().Writes, 3)
+		assert.Equal(t, []PlannedRemoval{{Path: demoBookmarksConPath, Size: 39}}, h.Plan().Removals)
+		assert.Equal(t, []PlannedMove{{From: generalConPath, To: generalConPath + ".bak"}}, h.Plan().Moves)
+		exists, err := repo.FileExists(demoBookmarksConPath)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("error when committing twice", func(t *testing.T) {
+		// GIVEN
+		h, _ := setup(t, "")
+		tx := h.Begin()
+		require.NoError(t, tx.Commit())
+
+		// WHEN
+		err := tx.Commit()
+
+		// THEN
+		require.ErrorIs(t, err, ErrTransactionDone)
+	})
+
+	t.Run("error when staging changes after rollback", func(t *testing.T) {
+		// GIVEN
+		h, _ := setup(t, "")
+		tx := h.Begin()
+		tx.Rollback()
+
+		// WHEN
+		err := tx.Remove(demoBookmarksConPath)
+
+		// THEN
+		require.ErrorIs(t, err, ErrTransactionDone)
+	})
+}
+
+func TestTransaction_ReadConfigFile(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("sandbox", bf2GameDirName)
+	globalConPath := filepath.Join(basePath, profilesDirName, globalConFileName)
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"Profiles/Global.con": {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+	}, basePath))
+	h := New(repo, WithBasePath(GameBf2, basePath))
+	tx := h.Begin()
+
+	// WHEN
+	before, err := tx.ReadConfigFile(globalConPath)
+	require.NoError(t, err)
+	before.SetValue("GlobalSettings.setDefaultUser", *config.NewQuotedValue("0002"))
+	require.NoError(t, tx.WriteConfigFile(before))
+	after, err := tx.ReadConfigFile(globalConPath)
+	require.NoError(t, err)
+	require.NoError(t, tx.Remove(globalConPath))
+	_, removedErr := tx.ReadConfigFile(globalConPath)
+
+	// THEN
+	value, err := after.GetValue("GlobalSettings.setDefaultUser")
+	require.NoError(t, err)
+	assert.Equal(t, "0002", value.String())
+	assert.ErrorIs(t, removedErr, os.ErrNotExist)
+}
+
+func TestTransaction_WriteFile(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("sandbox", bf2GameDirName)
+	controlsConPath := filepath.Join(basePath, profilesDirName, "0001", "Controls.con")
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{}, basePath))
+	h := New(repo, WithBasePath(GameBf2, basePath))
+	tx := h.Begin()
+	// Order matters and some lines do not have any arguments, so the file must not be re-serialized
+	data := []byte("ControlMap.create InfantryPlaneControlMap\r\nControlMap.addKeyToTriggerMapping c_PIFire 0 1 0\r\nControlMap.create LandControlMap\r\nControlMap.addKeyToTriggerMapping c_PIFire 0 1 0\r\nmapList.clear\r\n")
+
+	// WHEN
+	require.NoError(t, tx.WriteFile(controlsConPath, data))
+	staged, err := tx.ReadFile(controlsConPath)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// THEN
+	assert.Equal(t, data, staged)
+	written, err := repo.ReadFile(controlsConPath)
+	require.NoError(t, err)
+	assert.Equal(t, data, written)
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWatchInterval = time.Second
+	defaultWatchDebounce = 2 * time.Second
+
+	conFileExt = ".con"
+)
+
+type WatchOp string
+
+const (
+	WatchOpCreated WatchOp = "created"
+	WatchOpChanged WatchOp = "changed"
+	WatchOpRemoved WatchOp = "removed"
+)
+
+// WatchEvent Change to the global config, a profile folder or one of a profile's config files
+type WatchEvent struct {
+	Op   WatchOp
+	Path string
+	// Key of the profile the path belongs to (empty for the global config)
+	ProfileKey string
+	IsDir      bool
+}
+
+type WatchOption func(w *Watcher)
+
+// Scan the profiles folder at the given interval (defaults to one second)
+func WatchInterval(interval time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.interval = interval
+	}
+}
+
+// Only report changes once no further changes were seen for the given duration (defaults to two seconds),
+// such that a burst of writes (e.g. the game saving all of a profile's files) is reported once per path
+func WatchDebounce(debounce time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.debounce = debounce
+	}
+}
+
+// Watcher Polls a game's global config and profile folders for changes
+type Watcher struct {
+	h        *Handler
+	game     Game
+	interval time.Duration
+	debounce time.Duration
+
+	// State as of the last reported events
+	reported map[string]watchEntry
+	// State as of the last poll and the time it last differed from the poll before
+	seen    map[string]watchEntry
+	changed time.Time
+}
+
+type watchEntry struct {
+	profileKey string
+	isDir      bool
+	size       int64
+	modified   time.Time
+}
+
+// Start watching the given game's global config and profiles, changes are reported relative to the current state
+func (h *Handler) Watch(game Game, options ...WatchOption) (*Watcher, error) {
+	w := &Watcher{
+		h:        h,
+		game:     game,
+		interval: defaultWatchInterval,
+		debounce: defaultWatchDebounce,
+	}
+
+	for _, option := range options {
+		option(w)
+	}
+
+	state, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	w.reported = state
+	w.seen = state
+
+	return w, nil
+}
+
+// Scan for changes once, returning all changes which have settled since the last reported events (sorted by path)
+func (w *Watcher) Poll() ([]WatchEvent, error) {
+	state, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.h.now()
+	if len(diffWatchState(w.seen, state)) > 0 {
+		w.seen = state
+		w.changed = now
+	}
+
+	if now.Sub(w.changed) < w.debounce {
+		return nil, nil
+	}
+
+	events := diffWatchState(w.reported, state)
+	w.reported = state
+
+	return events, nil
+}
+
+// Poll for changes until the given context is done, calling handle for every reported event
+func (w *Watcher) Run(ctx context.Context, handle func(event WatchEvent)) error {
+	timer := time.NewTimer(w.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		events, err := w.Poll()
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			handle(event)
+		}
+
+		timer.Reset(w.interval)
+	}
+}
+
+// scan Determine the current state of the global config, all profile folders and their config files
+func (w *Watcher) scan() (map[string]watchEntry, error) {
+	state := map[string]watchEntry{}
+
+	globalConPath, err := w.h.buildGlobalConfigPath(w.game)
+	if err != nil {
+		return nil, err
+	}
+	if err = w.add(state, globalConPath, ""); err != nil {
+		return nil, err
+	}
+
+	profilesPath, err := w.h.BuildProfilesFolderPath(w.game)
+	if err != nil {
+		return nil, err
+	}
+
+	profileEntries, err := w.h.repository.ReadDir(profilesPath)
+	if err != nil {
+		// Profiles folder does not exist until the game is started for the first time
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	for _, profileEntry := range profileEntries {
+		if !profileEntry.IsDir() {
+			continue
+		}
+
+		profileKey := profileEntry.Name()
+		profilePath := filepath.Join(profilesPath, profileKey)
+		if err = w.add(state, profilePath, profileKey); err != nil {
+			return nil, err
+		}
+
+		entries, err := w.h.repository.ReadDir(profilePath)
+		if err != nil {
+			// Profile folder may have been removed since reading the profiles folder
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), conFileExt) {
+				continue
+			}
+			if err = w.add(state, filepath.Join(profilePath, entry.Name()), profileKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// add Add the given path to the state, unless it does not exist
+func (w *Watcher) add(state map[string]watchEntry, path string, profileKey string) error {
+	info, err := w.h.repository.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	entry := watchEntry{
+		profileKey: profileKey,
+		isDir:      info.IsDir(),
+	}
+	// A folder's modification time changes whenever any file in it changes, which is already reported for the file
+	if !entry.isDir {
+		entry.size = info.Size()
+		entry.modified = info.ModTime()
+	}
+
+	state[path] = entry
+	return nil
+}
+
+func diffWatchState(before, after map[string]watchEntry) []WatchEvent {
+	var events []WatchEvent
+	for path, entry := range after {
+		previous, ok := before[path]
+		switch {
+		case !ok:
+			events = append(events, newWatchEvent(WatchOpCreated, path, entry))
+		case previous.isDir != entry.isDir:
+			// Replaced by a file/folder of the same name
+			events = append(events, newWatchEvent(WatchOpRemoved, path, previous), newWatchEvent(WatchOpCreated, path, entry))
+		case !previous.modified.Equal(entry.modified) || previous.size != entry.size:
+			events = append(events, newWatchEvent(WatchOpChanged, path, entry))
+		}
+	}
+	for path, entry := range before {
+		if _, ok := after[path]; !ok {
+			events = append(events, newWatchEvent(WatchOpRemoved, path, entry))
+		}
+	}
+
+	// Report removals before creations of the same path
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Path != events[j].Path {
+			return events[i].Path < events[j].Path
+		}
+		return events[i].Op == WatchOpRemoved && events[j].Op != WatchOpRemoved
+	})
+
+	return events
+}
+
+func newWatchEvent(op WatchOp, path string, entry watchEntry) WatchEvent {
+	return WatchEvent{
+		Op:         op,
+		Path:       path,
+		ProfileKey: entry.profileKey,
+		IsDir:      entry.isDir,
+	}
+}
@@ -0,0 +1,223 @@
+//go:build unit
+
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/repository"
+)
+
+func TestWatcher_Poll(t *testing.T) {
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	profilesPath := filepath.Join(basePath, profilesDirName)
+	globalConPath := filepath.Join(profilesPath, globalConFileName)
+	profilePath := func(profileKey string) string {
+		return filepath.Join(profilesPath, profileKey)
+	}
+	profileFilePath := func(profileKey string, fileName string) string {
+		return filepath.Join(profilesPath, profileKey, fileName)
+	}
+	write := func(path string, data string) func(t *testing.T, repo *repository.MemoryRepository) {
+		return func(t *testing.T, repo *repository.MemoryRepository) {
+			require.NoError(t, repo.MkdirAll(filepath.Dir(path), 0777))
+			require.NoError(t, repo.WriteFile(path, []byte(data), 0666))
+		}
+	}
+	remove := func(path string) func(t *testing.T, repo *repository.MemoryRepository) {
+		return func(t *testing.T, repo *repository.MemoryRepository) {
+			require.NoError(t, repo.RemoveAll(path))
+		}
+	}
+	mkdir := func(path string) func(t *testing.T, repo *repository.MemoryRepository) {
+		return func(t *testing.T, repo *repository.MemoryRepository) {
+			require.NoError(t, repo.MkdirAll(path, 0777))
+		}
+	}
+
+	// Each step applies the change (if any), advances the clock and polls once
+	type step struct {
+		change     func(t *testing.T, repo *repository.MemoryRepository)
+		advance    time.Duration
+		wantEvents []WatchEvent
+	}
+
+	type test struct {
+		name       string
+		givenFiles fstest.MapFS
+		steps      []step
+	}
+
+	tests := []test{
+		{
+			name: "reports nothing without changes",
+			steps: []step{
+				{advance: time.Second},
+				{advance: 5 * time.Second},
+			},
+		},
+		{
+			name: "reports burst of writes once settled",
+			steps: []step{
+				{change: write(profileFilePath("0001", "General.con"), "a"), advance: time.Second},
+				{change: write(profileFilePath("0001", "General.con"), "ab"), advance: time.Second},
+				{change: write(profileFilePath("0001", "Profile.con"), "abc"), advance: time.Second},
+				{advance: time.Second},
+				{
+					advance: time.Second,
+					wantEvents: []WatchEvent{
+						{Op: WatchOpChanged, Path: profileFilePath("0001", "General.con"), ProfileKey: "0001"},
+						{Op: WatchOpChanged, Path: profileFilePath("0001", "Profile.con"), ProfileKey: "0001"},
+					},
+				},
+				{advance: 5 * time.Second},
+			},
+		},
+		{
+			name: "reports changed global config",
+			steps: []step{
+				{change: write(globalConPath, "GlobalSettings.setDefaultUser \"0002\""), advance: time.Second},
+				{
+					advance: 2 * time.Second,
+					wantEvents: []WatchEvent{
+						{Op: WatchOpChanged, Path: globalConPath},
+					},
+				},
+			},
+		},
+		{
+			name: "reports created profile",
+			steps: []step{
+				{change: mkdir(profilePath("0002")), advance: time.Second},
+				{change: write(profileFilePath("0002", "Profile.con"), "a"), advance: time.Second},
+				{
+					advance: 2 * time.Second,
+					wantEvents: []WatchEvent{
+						{Op: WatchOpCreated, Path: profilePath("0002"), ProfileKey: "0002", IsDir: true},
+						{Op: WatchOpCreated, Path: profileFilePath("0002", "Profile.con"), ProfileKey: "0002"},
+					},
+				},
+			},
+		},
+		{
+			name: "reports removed profile",
+			steps: []step{
+				{change: remove(profilePath("0001")), advance: time.Second},
+				{
+					advance: 2 * time.Second,
+					wantEvents: []WatchEvent{
+						{Op: WatchOpRemoved, Path: profilePath("0001"), ProfileKey: "0001", IsDir: true},
+						{Op: WatchOpRemoved, Path: profileFilePath("0001", "General.con"), ProfileKey: "0001"},
+						{Op: WatchOpRemoved, Path: profileFilePath("0001", "Profile.con"), ProfileKey: "0001"},
+					},
+				},
+			},
+		},
+		{
+			name: "reports nothing for file created and removed before settling",
+			steps: []step{
+				{change: write(profileFilePath("0001", "Temp.con"), "a"), advance: time.Second},
+				{change: remove(profileFilePath("0001", "Temp.con")), advance: time.Second},
+				{advance: 5 * time.Second},
+			},
+		},
+		{
+			name: "ignores files other than config files",
+			steps: []step{
+				{change: write(profileFilePath("0001", "conman.lock"), "a"), advance: time.Second},
+				{change: write(filepath.Join(profilesPath, "notes.txt"), "a"), advance: time.Second},
+				{change: mkdir(filepath.Join(profilePath("0001"), "Demos")), advance: time.Second},
+				{advance: 5 * time.Second},
+			},
+		},
+		{
+			name:       "reports profiles folder created after watch started",
+			givenFiles: fstest.MapFS{},
+			steps: []step{
+				{change: write(globalConPath, "a"), advance: time.Second},
+				{change: write(profileFilePath("0001", "Profile.con"), "a"), advance: time.Second},
+				{
+					advance: 2 * time.Second,
+					wantEvents: []WatchEvent{
+						{Op: WatchOpCreated, Path: profilePath("0001"), ProfileKey: "0001", IsDir: true},
+						{Op: WatchOpCreated, Path: profileFilePath("0001", "Profile.con"), ProfileKey: "0001"},
+						{Op: WatchOpCreated, Path: globalConPath},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			givenFiles := tt.givenFiles
+			if givenFiles == nil {
+				givenFiles = fstest.MapFS{
+					"Profiles/Global.con":       {Data: []byte("GlobalSettings.setDefaultUser \"0001\"")},
+					"Profiles/0001/General.con": {Data: []byte{}},
+					"Profiles/0001/Profile.con": {Data: []byte{}},
+				}
+			}
+			repo := repository.NewMemory()
+			require.NoError(t, repo.Load(givenFiles, basePath))
+			handler := New(repo, WithBasePath(GameBf2, basePath))
+			clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			handler.now = func() time.Time {
+				return clock
+			}
+
+			watcher, err := handler.Watch(GameBf2, WatchDebounce(2*time.Second))
+			require.NoError(t, err)
+
+			for i, s := range tt.steps {
+				if s.change != nil {
+					s.change(t, repo)
+				}
+				clock = clock.Add(s.advance)
+
+				// WHEN
+				events, err := watcher.Poll()
+
+				// THEN
+				require.NoError(t, err)
+				assert.Equal(t, s.wantEvents, events, "step %d", i)
+			}
+		})
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("build", "documents", bf2GameDirName)
+	globalConPath := filepath.Join(basePath, profilesDirName, globalConFileName)
+	repo := repository.NewMemory()
+	require.NoError(t, repo.Load(fstest.MapFS{
+		"Profiles/Global.con": {Data: []byte{}},
+	}, basePath))
+	handler := New(repo, WithBasePath(GameBf2, basePath))
+	watcher, err := handler.Watch(GameBf2, WatchInterval(time.Millisecond), WatchDebounce(0))
+	require.NoError(t, err)
+	require.NoError(t, repo.WriteFile(globalConPath, []byte("a"), 0666))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var events []WatchEvent
+
+	// WHEN
+	err = watcher.Run(ctx, func(event WatchEvent) {
+		events = append(events, event)
+		cancel()
+	})
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []WatchEvent{{Op: WatchOpChanged, Path: globalConPath}}, events)
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Create a detector for the current platform (outside of Windows, games are run via Wine and show up in /proc)
+func NewDetector() Detector {
+	return NewProcDetector(os.DirFS("/proc"))
+}
+
+// Whether a process with the given ID exists
+func IsAlive(pid int) bool {
+	_, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid)))
+	return err == nil
+}
@@ -0,0 +1,28 @@
+//go:build unit && !windows
+
+package process
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAlive(t *testing.T) {
+	t.Run("true for current process", func(t *testing.T) {
+		// WHEN
+		alive := IsAlive(os.Getpid())
+
+		// THEN
+		assert.True(t, alive)
+	})
+
+	t.Run("false for non existing process", func(t *testing.T) {
+		// WHEN
+		alive := IsAlive(1 << 30)
+
+		// THEN
+		assert.False(t, alive)
+	})
+}
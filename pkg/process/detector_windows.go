@@ -0,0 +1,62 @@
+//go:build windows
+
+package process
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// Exit code reported for processes which have not exited yet
+	exitCodeStillActive = 259
+)
+
+// WindowsDetector Detects processes using a snapshot of the Windows process list
+type WindowsDetector struct{}
+
+// Create a detector for the current platform
+func NewDetector() Detector {
+	return &WindowsDetector{}
+}
+
+func (d *WindowsDetector) IsRunning(executables ...string) (bool, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return false, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		if matches(windows.UTF16ToString(entry.ExeFile[:]), executables) {
+			return true, nil
+		}
+	}
+
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// Whether a process with the given ID exists
+func IsAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Processes of other users cannot be opened, but still exist
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(handle)
+
+	var exitCode uint32
+	if err = windows.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return true
+	}
+
+	return exitCode == exitCodeStillActive
+}
@@ -0,0 +1,63 @@
+package process
+
+import (
+	"bytes"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// ProcDetector Detects processes by scanning a Linux /proc tree, recognising Windows executables run via Wine
+type ProcDetector struct {
+	fsys fs.FS
+}
+
+// Create a detector scanning the given /proc tree (e.g. os.DirFS("/proc"))
+func NewProcDetector(fsys fs.FS) *ProcDetector {
+	return &ProcDetector{
+		fsys: fsys,
+	}
+}
+
+func (d *ProcDetector) IsRunning(executables ...string) (bool, error) {
+	entries, err := fs.ReadDir(d.fsys, ".")
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		// Only numeric folders represent processes
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err = strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+
+		for _, name := range d.names(entry.Name()) {
+			if matches(name, executables) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// names Determine all names a process is known by (processes may exit during the scan, so unreadable files are skipped)
+func (d *ProcDetector) names(pid string) []string {
+	var names []string
+	if comm, err := fs.ReadFile(d.fsys, pid+"/comm"); err == nil {
+		names = append(names, strings.TrimSpace(string(comm)))
+	}
+
+	// Wine processes are named after the Wine loader (or truncated to 15 characters), but their first argument
+	// is the path to the Windows executable
+	if cmdline, err := fs.ReadFile(d.fsys, pid+"/cmdline"); err == nil {
+		if args := bytes.Split(cmdline, []byte{0}); len(args) > 0 && len(args[0]) > 0 {
+			names = append(names, string(args[0]))
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,93 @@
+//go:build unit
+
+package process
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcDetector_IsRunning(t *testing.T) {
+	type test struct {
+		name             string
+		givenProc        fstest.MapFS
+		givenExecutables []string
+		want             bool
+	}
+
+	tests := []test{
+		{
+			name: "detects BF2.exe run via Wine",
+			givenProc: fstest.MapFS{
+				"1/comm":       {Data: []byte("systemd\n")},
+				"1/cmdline":    {Data: []byte("/sbin/init\x00splash\x00")},
+				"4242/comm":    {Data: []byte("BF2.exe\n")},
+				"4242/cmdline": {Data: []byte("C:\\Program Files (x86)\\EA Games\\Battlefield 2\\BF2.exe\x00+menu\x001\x00")},
+			},
+			givenExecutables: []string{"BF2.exe"},
+			want:             true,
+		},
+		{
+			name: "detects process by command line if name is truncated",
+			givenProc: fstest.MapFS{
+				"4242/comm":    {Data: []byte("BfVietnam_unpat\n")},
+				"4242/cmdline": {Data: []byte("Z:\\games\\bfvietnam\\BfVietnam_unpatched.exe\x00")},
+			},
+			givenExecutables: []string{"BfVietnam_unpatched.exe"},
+			want:             true,
+		},
+		{
+			name: "detects process by name if command line is empty",
+			givenProc: fstest.MapFS{
+				"4242/comm":    {Data: []byte("BF2.exe\n")},
+				"4242/cmdline": {Data: []byte{}},
+			},
+			givenExecutables: []string{"BF2.exe"},
+			want:             true,
+		},
+		{
+			name: "ignores non-process entries",
+			givenProc: fstest.MapFS{
+				"self/comm":    {Data: []byte("BF2.exe\n")},
+				"sys/comm":     {Data: []byte("BF2.exe\n")},
+				"4242/comm":    {Data: []byte("bash\n")},
+				"4242/cmdline": {Data: []byte("bash\x00")},
+				"uptime":       {Data: []byte("1.0 1.0\n")},
+			},
+			givenExecutables: []string{"BF2.exe"},
+		},
+		{
+			name: "ignores processes which cannot be read",
+			givenProc: fstest.MapFS{
+				"4242": {Mode: fs.ModeDir | 0555},
+			},
+			givenExecutables: []string{"BF2.exe"},
+		},
+		{
+			name: "does not detect other games",
+			givenProc: fstest.MapFS{
+				"4242/comm":    {Data: []byte("BF2142.exe\n")},
+				"4242/cmdline": {Data: []byte("C:\\Program Files (x86)\\Electronic Arts\\Battlefield 2142\\BF2142.exe\x00")},
+			},
+			givenExecutables: []string{"BF2.exe"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			detector := NewProcDetector(tt.givenProc)
+
+			// WHEN
+			running, err := detector.IsRunning(tt.givenExecutables...)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, running)
+		})
+	}
+}
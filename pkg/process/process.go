@@ -0,0 +1,27 @@
+// Detect running processes by executable name
+package process
+
+import (
+	"strings"
+)
+
+// Detector Checks whether any process running one of the given executables exists
+type Detector interface {
+	IsRunning(executables ...string) (bool, error)
+}
+
+// matches Whether the given executable path/name matches any of the given executable names (ignoring case and any folders)
+func matches(executable string, names []string) bool {
+	// Under Wine, executable paths are Windows paths, so folders may be separated by either kind of slash
+	if i := strings.LastIndexAny(executable, "/\\"); i != -1 {
+		executable = executable[i+1:]
+	}
+
+	for _, name := range names {
+		if strings.EqualFold(executable, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+//go:build unit
+
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	type test struct {
+		name            string
+		givenExecutable string
+		givenNames      []string
+		want            bool
+	}
+
+	tests := []test{
+		{
+			name:            "matches plain executable name",
+			givenExecutable: "BF2.exe",
+			givenNames:      []string{"BF2.exe"},
+			want:            true,
+		},
+		{
+			name:            "matches ignoring case",
+			givenExecutable: "bf2.EXE",
+			givenNames:      []string{"BF2.exe"},
+			want:            true,
+		},
+		{
+			name:            "matches Windows path",
+			givenExecutable: "C:\\Program Files (x86)\\EA Games\\Battlefield 2\\BF2.exe",
+			givenNames:      []string{"BF2142.exe", "BF2.exe"},
+			want:            true,
+		},
+		{
+			name:            "matches Unix path",
+			givenExecutable: "/home/user/games/bf2/BF2.exe",
+			givenNames:      []string{"BF2.exe"},
+			want:            true,
+		},
+		{
+			name:            "does not match other executable",
+			givenExecutable: "C:\\Program Files (x86)\\EA Games\\Battlefield 2\\BF2 Server\\bf2_w32ded.exe",
+			givenNames:      []string{"BF2.exe"},
+		},
+		{
+			name:            "does not match without names",
+			givenExecutable: "BF2.exe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			matched := matches(tt.givenExecutable, tt.givenNames)
+
+			// THEN
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+}
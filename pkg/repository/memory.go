@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errIsDir  = errors.New("is a directory")
+	errNotDir = errors.New("not a directory")
+)
+
+// MemoryRepository Repository keeping all files and folders in memory, e.g. for tests or to run actions against a sandbox copy
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	nodes map[string]*memoryNode
+	now   func() time.Time
+}
+
+type memoryNode struct {
+	dir     bool
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func NewMemory() *MemoryRepository {
+	return &MemoryRepository{
+		nodes: map[string]*memoryNode{},
+		now:   time.Now,
+	}
+}
+
+// Copy all files and folders from the given file system into the repository, placing them below the given folder
+func (r *MemoryRepository) Load(fsys fs.FS, dest string) error {
+	if err := r.MkdirAll(dest, 0777); err != nil {
+		return err
+	}
+
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(path))
+		if d.IsDir() {
+			if err = r.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		} else {
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+			if err = r.WriteFile(target, data, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+
+		return r.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+}
+
+func (r *MemoryRepository) FileExists(path string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	node, ok := r.nodes[clean(path)]
+	return ok && !node.dir, nil
+}
+
+func (r *MemoryRepository) DirExists(path string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.isDir(clean(path)), nil
+}
+
+func (r *MemoryRepository) WriteFile(path string, data []byte, perm os.FileMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path = clean(path)
+	if !r.isDir(filepath.Dir(path)) {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	node, ok := r.nodes[path]
+	if ok && node.dir {
+		return &fs.PathError{Op: "open", Path: path, Err: errIsDir}
+	}
+
+	// Like os.WriteFile, only apply permissions when creating a new file
+	mode := perm.Perm()
+	if ok {
+		mode = node.mode
+	}
+
+	r.nodes[path] = &memoryNode{
+		data:    append([]byte(nil), data...),
+		mode:    mode,
+		modTime: r.now(),
+	}
+
+	return nil
+}
+
+// Create a new file containing the given data, failing if the file already exists (use errors.Is(err, os.ErrExist) to check)
+func (r *MemoryRepository) CreateFile(path string, data []byte, perm os.FileMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path = clean(path)
+	if !r.isDir(filepath.Dir(path)) {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	if _, ok := r.nodes[path]; ok {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrExist}
+	}
+
+	r.nodes[path] = &memoryNode{
+		data:    append([]byte(nil), data...),
+		mode:    perm.Perm(),
+		modTime: r.now(),
+	}
+
+	return nil
+}
+
+func (r *MemoryRepository) ReadFile(path string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path = clean(path)
+	node, ok := r.nodes[path]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	if node.dir {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: errIsDir}
+	}
+
+	return append([]byte(nil), node.data...), nil
+}
+
+func (r *MemoryRepository) ReadDir(path string) ([]os.DirEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path = clean(path)
+	if !r.isDir(path) {
+		if _, ok := r.nodes[path]; ok {
+			return nil, &fs.PathError{Op: "readdirent", Path: path, Err: errNotDir}
+		}
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	var entries []os.DirEntry
+	for _, child := range r.children(path) {
+		entries = append(entries, fs.FileInfoToDirEntry(r.info(child)))
+	}
+
+	// Like os.ReadDir, return entries sorted by name
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+func (r *MemoryRepository) Glob(pattern string) ([]string, error) {
+	// Validate the pattern, since filepath.Match only reports pattern errors when reaching the invalid part
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pattern = clean(pattern)
+	var matches []string
+	for path := range r.nodes {
+		// Match does not let wildcards match path separators, so matching full paths gives the same results as filepath.Glob
+		if matched, _ := filepath.Match(pattern, path); matched {
+			matches = append(matches, path)
+		}
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (r *MemoryRepository) RemoveAll(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path = clean(path)
+	delete(r.nodes, path)
+	for _, descendant := range r.descendants(path) {
+		delete(r.nodes, descendant)
+	}
+
+	return nil
+}
+
+func (r *MemoryRepository) Stat(path string) (os.FileInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path = clean(path)
+	if _, ok := r.nodes[path]; !ok {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+
+	return r.info(path), nil
+}
+
+func (r *MemoryRepository) Rename(oldpath string, newpath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oldpath, newpath = clean(oldpath), clean(newpath)
+	node, ok := r.nodes[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if !r.isDir(filepath.Dir(newpath)) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if target, exists := r.nodes[newpath]; exists && target.dir {
+		// Only allow replacing empty folders with folders
+		if !node.dir || len(r.children(newpath)) > 0 {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errIsDir}
+		}
+	}
+
+	descendants := r.descendants(oldpath)
+	r.nodes[newpath] = node
+	delete(r.nodes, oldpath)
+	for _, descendant := range descendants {
+		r.nodes[newpath+descendant[len(oldpath):]] = r.nodes[descendant]
+		delete(r.nodes, descendant)
+	}
+
+	return nil
+}
+
+func (r *MemoryRepository) Sync(path string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path = clean(path)
+	if _, ok := r.nodes[path]; !ok {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	// Nothing to do, data is always "committed"
+	return nil
+}
+
+func (r *MemoryRepository) SyncDir(path string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path = clean(path)
+	if !r.isDir(path) {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	// Nothing to do, folders are always "committed"
+	return nil
+}
+
+func (r *MemoryRepository) MkdirAll(path string, perm os.FileMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path = clean(path)
+	var missing []string
+	for p := path; !isRoot(p); p = filepath.Dir(p) {
+		node, ok := r.nodes[p]
+		if ok {
+			if !node.dir {
+				return &fs.PathError{Op: "mkdir", Path: p, Err: errNotDir}
+			}
+			break
+		}
+		missing = append(missing, p)
+	}
+
+	for _, p := range missing {
+		r.nodes[p] = &memoryNode{
+			dir:     true,
+			mode:    fs.ModeDir | perm.Perm(),
+			modTime: r.now(),
+		}
+	}
+
+	return nil
+}
+
+// Change the modification time of the file or folder at the given path (access time is not tracked)
+func (r *MemoryRepository) Chtimes(path string, atime time.Time, mtime time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path = clean(path)
+	node, ok := r.nodes[path]
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: path, Err: fs.ErrNotExist}
+	}
+
+	node.modTime = mtime
+	return nil
+}
+
+func (r *MemoryRepository) isDir(path string) bool {
+	if isRoot(path) {
+		return true
+	}
+	node, ok := r.nodes[path]
+	return ok && node.dir
+}
+
+func (r *MemoryRepository) children(path string) []string {
+	var children []string
+	for p := range r.nodes {
+		if p != path && filepath.Dir(p) == path {
+			children = append(children, p)
+		}
+	}
+	return children
+}
+
+func (r *MemoryRepository) descendants(path string) []string {
+	prefix := path + string(filepath.Separator)
+	if isRoot(path) {
+		prefix = path
+	}
+
+	var descendants []string
+	for p := range r.nodes {
+		if p != path && strings.HasPrefix(p, prefix) {
+			descendants = append(descendants, p)
+		}
+	}
+	return descendants
+}
+
+func (r *MemoryRepository) info(path string) os.FileInfo {
+	node := r.nodes[path]
+	return &memoryFileInfo{
+		name:    filepath.Base(path),
+		size:    int64(len(node.data)),
+		mode:    node.mode,
+		modTime: node.modTime,
+	}
+}
+
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *memoryFileInfo) Name() string       { return i.name }
+func (i *memoryFileInfo) Size() int64        { return i.size }
+func (i *memoryFileInfo) Mode() os.FileMode  { return i.mode }
+func (i *memoryFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memoryFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memoryFileInfo) Sys() any           { return nil }
+
+func clean(path string) string {
+	return filepath.Clean(path)
+}
+
+func isRoot(path string) bool {
+	return filepath.Dir(path) == path
+}
@@ -0,0 +1,433 @@
+//go:build unit
+
+package repository
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cetteup/conman/pkg/config"
+	"github.com/cetteup/conman/pkg/handler"
+)
+
+// Ensure both repositories can be used with the handler
+var (
+	_ handler.FileRepository = NewMemory()
+	_ handler.FileRepository = NewOS()
+)
+
+var (
+	testProfilesPath = filepath.Join("Documents", "Battlefield 2", "Profiles")
+)
+
+func TestMemoryRepository_WriteFile(t *testing.T) {
+	t.Run("writes file in existing folder", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.MkdirAll(testProfilesPath, 0777))
+		path := filepath.Join(testProfilesPath, "Global.con")
+
+		// WHEN
+		err := repository.WriteFile(path, []byte("GlobalSettings.setDefaultUser \"0001\"\r\n"), 0640)
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repository.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0001\"\r\n"), data)
+		info, err := repository.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode())
+		assert.Equal(t, int64(38), info.Size())
+	})
+
+	t.Run("keeps permissions of existing file", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.WriteFile("Global.con", []byte{}, 0600))
+
+		// WHEN
+		err := repository.WriteFile("Global.con", []byte("GlobalSettings.setDefaultUser \"0001\"\r\n"), 0666)
+
+		// THEN
+		require.NoError(t, err)
+		info, err := repository.Stat("Global.con")
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode())
+	})
+
+	t.Run("error for missing parent folder", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+
+		// WHEN
+		err := repository.WriteFile(filepath.Join(testProfilesPath, "Global.con"), []byte{}, 0666)
+
+		// THEN
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("error for existing folder", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.MkdirAll(testProfilesPath, 0777))
+
+		// WHEN
+		err := repository.WriteFile(testProfilesPath, []byte{}, 0666)
+
+		// THEN
+		require.ErrorContains(t, err, "is a directory")
+	})
+}
+
+func TestMemoryRepository_FileExists_DirExists(t *testing.T) {
+	// GIVEN
+	repository := NewMemory()
+	require.NoError(t, repository.MkdirAll(filepath.Join(testProfilesPath, "0001"), 0777))
+	require.NoError(t, repository.WriteFile(filepath.Join(testProfilesPath, "0001", "Profile.con"), []byte{}, 0666))
+
+	type test struct {
+		name           string
+		givenPath      string
+		wantFileExists bool
+		wantDirExists  bool
+	}
+
+	tests := []test{
+		{
+			name:           "existing file",
+			givenPath:      filepath.Join(testProfilesPath, "0001", "Profile.con"),
+			wantFileExists: true,
+		},
+		{
+			name:          "existing folder",
+			givenPath:     filepath.Join(testProfilesPath, "0001"),
+			wantDirExists: true,
+		},
+		{
+			name:          "existing parent folder (unclean path)",
+			givenPath:     filepath.Join(testProfilesPath, "0001") + string(filepath.Separator) + "..",
+			wantDirExists: true,
+		},
+		{
+			name:      "non existing path",
+			givenPath: filepath.Join(testProfilesPath, "0002"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			fileExists, fileErr := repository.FileExists(tt.givenPath)
+			dirExists, dirErr := repository.DirExists(tt.givenPath)
+
+			// THEN
+			require.NoError(t, fileErr)
+			require.NoError(t, dirErr)
+			assert.Equal(t, tt.wantFileExists, fileExists)
+			assert.Equal(t, tt.wantDirExists, dirExists)
+		})
+	}
+}
+
+func TestMemoryRepository_ReadDir(t *testing.T) {
+	// GIVEN
+	repository := NewMemory()
+	require.NoError(t, repository.MkdirAll(filepath.Join(testProfilesPath, "0002"), 0777))
+	require.NoError(t, repository.MkdirAll(filepath.Join(testProfilesPath, "0001"), 0777))
+	require.NoError(t, repository.WriteFile(filepath.Join(testProfilesPath, "Global.con"), []byte{}, 0666))
+	require.NoError(t, repository.WriteFile(filepath.Join(testProfilesPath, "0001", "Profile.con"), []byte{}, 0666))
+
+	t.Run("lists direct children sorted by name", func(t *testing.T) {
+		// WHEN
+		entries, err := repository.ReadDir(testProfilesPath)
+
+		// THEN
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "0001", entries[0].Name())
+		assert.True(t, entries[0].IsDir())
+		assert.Equal(t, "0002", entries[1].Name())
+		assert.True(t, entries[1].IsDir())
+		assert.Equal(t, "Global.con", entries[2].Name())
+		assert.False(t, entries[2].IsDir())
+	})
+
+	t.Run("error for file", func(t *testing.T) {
+		// WHEN
+		_, err := repository.ReadDir(filepath.Join(testProfilesPath, "Global.con"))
+
+		// THEN
+		require.ErrorContains(t, err, "not a directory")
+	})
+
+	t.Run("error for non existing folder", func(t *testing.T) {
+		// WHEN
+		_, err := repository.ReadDir(filepath.Join(testProfilesPath, "0003"))
+
+		// THEN
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestMemoryRepository_Glob(t *testing.T) {
+	// GIVEN
+	repository := NewMemory()
+	modsPath := filepath.Join("Documents", "Battlefield 2", "mods")
+	require.NoError(t, repository.MkdirAll(filepath.Join(modsPath, "bf2", "cache", "{D7B71E3E}_112_1"), 0777))
+	require.NoError(t, repository.MkdirAll(filepath.Join(modsPath, "xpack", "cache", "{D7B71E3E}_112_2"), 0777))
+	require.NoError(t, repository.WriteFile(filepath.Join(modsPath, "bf2", "cache", "{D7B71E3E}_112_1", "shader.cfx"), []byte{}, 0666))
+
+	t.Run("matches wildcards per path element", func(t *testing.T) {
+		// WHEN
+		matches, err := repository.Glob(filepath.Join(modsPath, "*", "cache", "*"))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(modsPath, "bf2", "cache", "{D7B71E3E}_112_1"),
+			filepath.Join(modsPath, "xpack", "cache", "{D7B71E3E}_112_2"),
+		}, matches)
+	})
+
+	t.Run("returns nothing if nothing matches", func(t *testing.T) {
+		// WHEN
+		matches, err := repository.Glob(filepath.Join(modsPath, "*", "logos", "*"))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+	})
+
+	t.Run("error for invalid pattern", func(t *testing.T) {
+		// WHEN
+		_, err := repository.Glob(filepath.Join(modsPath, "["))
+
+		// THEN
+		require.ErrorIs(t, err, filepath.ErrBadPattern)
+	})
+}
+
+func TestMemoryRepository_RemoveAll(t *testing.T) {
+	// GIVEN
+	repository := NewMemory()
+	require.NoError(t, repository.MkdirAll(filepath.Join(testProfilesPath, "0001"), 0777))
+	require.NoError(t, repository.MkdirAll(filepath.Join(testProfilesPath, "00010"), 0777))
+	require.NoError(t, repository.WriteFile(filepath.Join(testProfilesPath, "0001", "Profile.con"), []byte{}, 0666))
+
+	// WHEN
+	err := repository.RemoveAll(filepath.Join(testProfilesPath, "0001"))
+
+	// THEN
+	require.NoError(t, err)
+	exists, _ := repository.FileExists(filepath.Join(testProfilesPath, "0001", "Profile.con"))
+	assert.False(t, exists)
+	exists, _ = repository.DirExists(filepath.Join(testProfilesPath, "0001"))
+	assert.False(t, exists)
+	// Sibling sharing the same prefix must not be removed
+	exists, _ = repository.DirExists(filepath.Join(testProfilesPath, "00010"))
+	assert.True(t, exists)
+	// Removing non-existing paths is not an error
+	require.NoError(t, repository.RemoveAll(filepath.Join(testProfilesPath, "0003")))
+}
+
+func TestMemoryRepository_Rename(t *testing.T) {
+	t.Run("replaces existing file", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.WriteFile("Global.con", []byte("old"), 0666))
+		require.NoError(t, repository.WriteFile("Global.con.tmp", []byte("new"), 0666))
+
+		// WHEN
+		err := repository.Rename("Global.con.tmp", "Global.con")
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repository.ReadFile("Global.con")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("new"), data)
+		exists, _ := repository.FileExists("Global.con.tmp")
+		assert.False(t, exists)
+	})
+
+	t.Run("moves folder including contents", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.MkdirAll(filepath.Join(testProfilesPath, "0001"), 0777))
+		require.NoError(t, repository.WriteFile(filepath.Join(testProfilesPath, "0001", "Profile.con"), []byte("profile"), 0666))
+
+		// WHEN
+		err := repository.Rename(filepath.Join(testProfilesPath, "0001"), filepath.Join(testProfilesPath, "0002"))
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repository.ReadFile(filepath.Join(testProfilesPath, "0002", "Profile.con"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("profile"), data)
+		exists, _ := repository.DirExists(filepath.Join(testProfilesPath, "0001"))
+		assert.False(t, exists)
+	})
+
+	t.Run("error for non existing source", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+
+		// WHEN
+		err := repository.Rename("Global.con.tmp", "Global.con")
+
+		// THEN
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("error for replacing folder with file", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.MkdirAll("Global.con", 0777))
+		require.NoError(t, repository.WriteFile("Global.con.tmp", []byte("new"), 0666))
+
+		// WHEN
+		err := repository.Rename("Global.con.tmp", "Global.con")
+
+		// THEN
+		require.ErrorContains(t, err, "is a directory")
+	})
+}
+
+func TestMemoryRepository_CreateFile(t *testing.T) {
+	t.Run("creates new file", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+
+		// WHEN
+		err := repository.CreateFile("conman.lock", []byte("lock"), 0666)
+
+		// THEN
+		require.NoError(t, err)
+		data, err := repository.ReadFile("conman.lock")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("lock"), data)
+	})
+
+	t.Run("error for existing file", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.WriteFile("conman.lock", []byte("other"), 0666))
+
+		// WHEN
+		err := repository.CreateFile("conman.lock", []byte("lock"), 0666)
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrExist)
+		data, err := repository.ReadFile("conman.lock")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("other"), data)
+	})
+
+	t.Run("error for non existing parent folder", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+
+		// WHEN
+		err := repository.CreateFile(filepath.Join(testProfilesPath, "conman.lock"), []byte("lock"), 0666)
+
+		// THEN
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestMemoryRepository_MkdirAll(t *testing.T) {
+	t.Run("creates all missing folders", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+
+		// WHEN
+		err := repository.MkdirAll(filepath.Join(testProfilesPath, "0001"), 0750)
+
+		// THEN
+		require.NoError(t, err)
+		info, err := repository.Stat(testProfilesPath)
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+		assert.Equal(t, fs.ModeDir|0750, info.Mode())
+	})
+
+	t.Run("error for file in path", func(t *testing.T) {
+		// GIVEN
+		repository := NewMemory()
+		require.NoError(t, repository.WriteFile("Documents", []byte{}, 0666))
+
+		// WHEN
+		err := repository.MkdirAll(testProfilesPath, 0777)
+
+		// THEN
+		require.ErrorContains(t, err, "not a directory")
+	})
+}
+
+func TestMemoryRepository_Load(t *testing.T) {
+	// GIVEN
+	modTime := time.Date(2026, time.October, 18, 15, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"Global.con":        {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n"), Mode: 0644, ModTime: modTime},
+		"0001/Profile.con":  {Data: []byte("LocalProfile.setName \"mister249\"\r\n"), Mode: 0644, ModTime: modTime},
+		"Default/Video.con": {Data: []byte{}, Mode: 0644, ModTime: modTime},
+	}
+	repository := NewMemory()
+
+	// WHEN
+	err := repository.Load(fsys, testProfilesPath)
+
+	// THEN
+	require.NoError(t, err)
+	data, err := repository.ReadFile(filepath.Join(testProfilesPath, "0001", "Profile.con"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("LocalProfile.setName \"mister249\"\r\n"), data)
+	info, err := repository.Stat(filepath.Join(testProfilesPath, "Global.con"))
+	require.NoError(t, err)
+	assert.Equal(t, modTime, info.ModTime())
+	exists, err := repository.DirExists(filepath.Join(testProfilesPath, "Default"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestMemoryRepository_WithHandler(t *testing.T) {
+	// GIVEN
+	basePath := filepath.Join("sandbox", "Battlefield 2")
+	repository := NewMemory()
+	require.NoError(t, repository.Load(fstest.MapFS{
+		"Profiles/Global.con":        {Data: []byte("GlobalSettings.setDefaultUser \"0001\"\r\n")},
+		"Profiles/0001/Profile.con":  {Data: []byte("LocalProfile.setName \"mister249\"\r\n")},
+		"Profiles/0002/Profile.con":  {Data: []byte("LocalProfile.setName \"mister250\"\r\n")},
+		"Profiles/Default/Video.con": {Data: []byte{}},
+	}, basePath))
+	h := handler.New(repository, handler.WithBasePath(handler.GameBf2, basePath))
+
+	// WHEN
+	profileKeys, err := h.GetProfileKeys(handler.GameBf2)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0001", "0002"}, profileKeys)
+
+	// WHEN
+	globalCon, err := h.ReadGlobalConfig(handler.GameBf2)
+	require.NoError(t, err)
+	globalCon.SetValue("GlobalSettings.setDefaultUser", *config.NewQuotedValue("0002"))
+	err = h.WriteConfigFile(globalCon)
+
+	// THEN
+	require.NoError(t, err)
+	data, err := repository.ReadFile(filepath.Join(basePath, "Profiles", "Global.con"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("GlobalSettings.setDefaultUser \"0002\"\r\n"), data)
+	tmpFiles, err := repository.Glob(filepath.Join(basePath, "Profiles", "Global.con.*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+}
@@ -0,0 +1,59 @@
+// Implementations of handler.FileRepository
+package repository
+
+import (
+	"os"
+
+	filerepo "github.com/cetteup/filerepo/pkg"
+)
+
+// OSRepository Repository operating on the actual file system (extends filerepo.FileRepository)
+type OSRepository struct {
+	*filerepo.FileRepository
+}
+
+func NewOS() *OSRepository {
+	return &OSRepository{
+		FileRepository: filerepo.New(),
+	}
+}
+
+func (r *OSRepository) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// Commit the file's current content to stable storage
+func (r *OSRepository) Sync(path string) error {
+	// Windows requires write access in order to flush a file's buffers
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func (r *OSRepository) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// Create a new file containing the given data, failing if the file already exists (use errors.Is(err, os.ErrExist) to check)
+func (r *OSRepository) CreateFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package repository
+
+import (
+	"os"
+)
+
+// Commit the folder's entries (e.g. a file renamed into it) to stable storage
+func (r *OSRepository) SyncDir(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
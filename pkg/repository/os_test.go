@@ -0,0 +1,118 @@
+//go:build unit
+
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSRepository_Stat(t *testing.T) {
+	repository := NewOS()
+
+	t.Run("returns file info for existing file", func(t *testing.T) {
+		// GIVEN
+		path := filepath.Join(t.TempDir(), "Profile.con")
+		err := os.WriteFile(path, []byte("LocalProfile.setName \"mister249\"\r\n"), 0666)
+		require.NoError(t, err)
+
+		// WHEN
+		info, err := repository.Stat(path)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, "Profile.con", info.Name())
+		assert.Equal(t, int64(34), info.Size())
+	})
+
+	t.Run("error for non existing file", func(t *testing.T) {
+		// WHEN
+		_, err := repository.Stat(filepath.Join(t.TempDir(), "Profile.con"))
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestOSRepository_Sync(t *testing.T) {
+	repository := NewOS()
+
+	t.Run("syncs existing file", func(t *testing.T) {
+		// GIVEN
+		path := filepath.Join(t.TempDir(), "Profile.con")
+		err := os.WriteFile(path, []byte("LocalProfile.setName \"mister249\"\r\n"), 0666)
+		require.NoError(t, err)
+
+		// WHEN
+		err = repository.Sync(path)
+
+		// THEN
+		require.NoError(t, err)
+	})
+
+	t.Run("error for non existing file", func(t *testing.T) {
+		// WHEN
+		err := repository.Sync(filepath.Join(t.TempDir(), "Profile.con"))
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestOSRepository_SyncDir(t *testing.T) {
+	repository := NewOS()
+
+	t.Run("syncs existing folder", func(t *testing.T) {
+		// WHEN
+		err := repository.SyncDir(t.TempDir())
+
+		// THEN
+		require.NoError(t, err)
+	})
+
+	t.Run("error for non existing folder", func(t *testing.T) {
+		// WHEN
+		err := repository.SyncDir(filepath.Join(t.TempDir(), "Profiles"))
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestOSRepository_CreateFile(t *testing.T) {
+	repository := NewOS()
+
+	t.Run("creates new file", func(t *testing.T) {
+		// GIVEN
+		path := filepath.Join(t.TempDir(), "conman.lock")
+
+		// WHEN
+		err := repository.CreateFile(path, []byte("lock"), 0666)
+
+		// THEN
+		require.NoError(t, err)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("lock"), data)
+	})
+
+	t.Run("error for existing file", func(t *testing.T) {
+		// GIVEN
+		path := filepath.Join(t.TempDir(), "conman.lock")
+		err := os.WriteFile(path, []byte("other"), 0666)
+		require.NoError(t, err)
+
+		// WHEN
+		err = repository.CreateFile(path, []byte("lock"), 0666)
+
+		// THEN
+		require.ErrorIs(t, err, os.ErrExist)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("other"), data)
+	})
+}
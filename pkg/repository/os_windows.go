@@ -0,0 +1,13 @@
+//go:build windows
+
+package repository
+
+import (
+	"os"
+)
+
+// Ensure the folder exists (Windows does not support flushing a folder's buffers, NTFS commits renames on its own)
+func (r *OSRepository) SyncDir(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
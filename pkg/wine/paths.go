@@ -0,0 +1,105 @@
+package wine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	UserRegistryFileName   = "user.reg"
+	SystemRegistryFileName = "system.reg"
+
+	prefixEnvVar         = "WINEPREFIX"
+	defaultPrefixDirName = ".wine"
+	dosDevicesDirName    = "dosdevices"
+
+	// Relative to HKEY_CURRENT_USER (user.reg)
+	shellFoldersKeyPath     = "Software\\Microsoft\\Windows\\CurrentVersion\\Explorer\\Shell Folders"
+	userShellFoldersKeyPath = "Software\\Microsoft\\Windows\\CurrentVersion\\Explorer\\User Shell Folders"
+	personalValueName       = "Personal"
+
+	// Relative to HKEY_LOCAL_MACHINE (system.reg)
+	wow64KeyPath        = "Software\\Wow6432Node"
+	softwareKeyPath     = "Software"
+	installDirValueName = "InstallDir"
+)
+
+// Determine the path of the current Wine prefix (WINEPREFIX environment variable or ~/.wine)
+func GetPrefixPath() (string, error) {
+	if prefix := os.Getenv(prefixEnvVar); prefix != "" {
+		return prefix, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, defaultPrefixDirName), nil
+}
+
+// Get the (Windows) path of the user's Documents folder from a parsed user.reg
+func GetPersonalFolderPath(userReg *Registry) (string, error) {
+	value, err := userReg.Value(shellFoldersKeyPath, personalValueName)
+	if err == nil && value.String() != "" {
+		return value.String(), nil
+	}
+
+	// Fall back to user shell folders, which is only usable if it does not reference any environment variables
+	value, err = userReg.Value(userShellFoldersKeyPath, personalValueName)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(value.String(), "%") {
+		return "", fmt.Errorf("personal folder path contains unresolvable environment variables: %s", value.String())
+	}
+
+	return value.String(), nil
+}
+
+// Get a game's (Windows) install path from a parsed system.reg, looking at both the 32-bit (Wow6432Node) and regular software key
+func GetInstallPath(systemReg *Registry, keyPath string) (string, error) {
+	return GetInstallPathFromValue(systemReg, keyPath, installDirValueName)
+}
+
+// Get a game's (Windows) install path from the given value of a parsed system.reg (for games not using the InstallDir value)
+func GetInstallPathFromValue(systemReg *Registry, keyPath string, valueName string) (string, error) {
+	var firstErr error
+	for _, parent := range []string{wow64KeyPath, softwareKeyPath} {
+		value, err := systemReg.Value(parent+keyPathSeparator+keyPath, valueName)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if value.String() != "" {
+			return value.String(), nil
+		}
+	}
+
+	if firstErr == nil {
+		firstErr = &ErrNoSuchValue{path: keyPath, name: valueName}
+	}
+	return "", firstErr
+}
+
+// Convert a Windows path (e.g. C:\users\user\Documents) to the corresponding path in the given Wine prefix
+func ToUnixPath(prefix string, windowsPath string) (string, error) {
+	// Expect an absolute path with a drive letter
+	if len(windowsPath) < 2 || windowsPath[1] != ':' {
+		return "", fmt.Errorf("not an absolute windows path: %s", windowsPath)
+	}
+
+	drive := strings.ToLower(windowsPath[:2])
+	elements := []string{prefix, dosDevicesDirName, drive}
+	for _, element := range strings.Split(windowsPath[2:], "\\") {
+		if element != "" {
+			elements = append(elements, element)
+		}
+	}
+
+	return filepath.Join(elements...), nil
+}
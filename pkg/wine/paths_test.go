@@ -0,0 +1,206 @@
+//go:build unit
+
+package wine
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPrefixPath(t *testing.T) {
+	t.Run("uses WINEPREFIX if set", func(t *testing.T) {
+		// GIVEN
+		t.Setenv("WINEPREFIX", "/opt/prefixes/bf2")
+
+		// WHEN
+		prefix, err := GetPrefixPath()
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, "/opt/prefixes/bf2", prefix)
+	})
+
+	t.Run("falls back to .wine in home directory", func(t *testing.T) {
+		// GIVEN
+		t.Setenv("WINEPREFIX", "")
+		t.Setenv("HOME", "/home/user")
+
+		// WHEN
+		prefix, err := GetPrefixPath()
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/home/user", ".wine"), prefix)
+	})
+}
+
+func TestGetPersonalFolderPath(t *testing.T) {
+	type test struct {
+		name            string
+		givenUserReg    string
+		wantPath        string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "gets path from shell folders",
+			givenUserReg: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Microsoft\\\\Windows\\\\CurrentVersion\\\\Explorer\\\\Shell Folders] 1700000000\n" +
+				"\"Personal\"=\"D:\\\\Moved Documents\"\n" +
+				"[Software\\\\Microsoft\\\\Windows\\\\CurrentVersion\\\\Explorer\\\\User Shell Folders] 1700000000\n" +
+				"\"Personal\"=str(2):\"%USERPROFILE%\\\\Documents\"\n",
+			wantPath: "D:\\Moved Documents",
+		},
+		{
+			name: "falls back to user shell folders",
+			givenUserReg: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Microsoft\\\\Windows\\\\CurrentVersion\\\\Explorer\\\\User Shell Folders] 1700000000\n" +
+				"\"Personal\"=str(2):\"C:\\\\users\\\\user\\\\Documents\"\n",
+			wantPath: "C:\\users\\user\\Documents",
+		},
+		{
+			name: "error for user shell folder path containing environment variables",
+			givenUserReg: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Microsoft\\\\Windows\\\\CurrentVersion\\\\Explorer\\\\User Shell Folders] 1700000000\n" +
+				"\"Personal\"=str(2):\"%USERPROFILE%\\\\Documents\"\n",
+			wantErrContains: "unresolvable environment variables",
+		},
+		{
+			name:            "error for missing shell folders",
+			givenUserReg:    "WINE REGISTRY Version 2\n",
+			wantErrContains: "no such registry key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			userReg, err := Parse([]byte(tt.givenUserReg))
+			require.NoError(t, err)
+
+			// WHEN
+			path, err := GetPersonalFolderPath(userReg)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantPath, path)
+			}
+		})
+	}
+}
+
+func TestGetInstallPath(t *testing.T) {
+	type test struct {
+		name            string
+		givenSystemReg  string
+		wantPath        string
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "gets path from 32-bit software key",
+			givenSystemReg: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Wow6432Node\\\\Electronic Arts\\\\EA Games\\\\Battlefield 2] 1700000000\n" +
+				"\"InstallDir\"=\"C:\\\\Program Files (x86)\\\\EA Games\\\\Battlefield 2\"\n",
+			wantPath: "C:\\Program Files (x86)\\EA Games\\Battlefield 2",
+		},
+		{
+			name: "gets path from software key",
+			givenSystemReg: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Electronic Arts\\\\EA Games\\\\Battlefield 2] 1700000000\n" +
+				"\"InstallDir\"=\"C:\\\\Games\\\\Battlefield 2\"\n",
+			wantPath: "C:\\Games\\Battlefield 2",
+		},
+		{
+			name:            "error for missing install key",
+			givenSystemReg:  "WINE REGISTRY Version 2\n",
+			wantErrContains: "no such registry key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			systemReg, err := Parse([]byte(tt.givenSystemReg))
+			require.NoError(t, err)
+
+			// WHEN
+			path, err := GetInstallPath(systemReg, "Electronic Arts\\EA Games\\Battlefield 2")
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantPath, path)
+			}
+		})
+	}
+}
+
+func TestToUnixPath(t *testing.T) {
+	type test struct {
+		name             string
+		givenWindowsPath string
+		wantPath         string
+		wantErrContains  string
+	}
+
+	tests := []test{
+		{
+			name:             "converts path on drive C",
+			givenWindowsPath: "C:\\users\\user\\Documents",
+			wantPath:         filepath.Join("/home/user/.wine", "dosdevices", "c:", "users", "user", "Documents"),
+		},
+		{
+			name:             "converts path on other drive with trailing backslash",
+			givenWindowsPath: "D:\\Documents\\",
+			wantPath:         filepath.Join("/home/user/.wine", "dosdevices", "d:", "Documents"),
+		},
+		{
+			name:             "error for relative path",
+			givenWindowsPath: "users\\user\\Documents",
+			wantErrContains:  "not an absolute windows path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			path, err := ToUnixPath("/home/user/.wine", tt.givenWindowsPath)
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantPath, path)
+			}
+		})
+	}
+}
+
+func TestGetInstallPathFromValue(t *testing.T) {
+	// GIVEN
+	systemReg, err := Parse([]byte("WINE REGISTRY Version 2\n" +
+		"[Software\\\\Wow6432Node\\\\EA GAMES\\\\Battlefield 1942] 1700000000\n" +
+		"\"GAMEDIR\"=\"C:\\\\Program Files (x86)\\\\EA Games\\\\Battlefield 1942\"\n"))
+	require.NoError(t, err)
+
+	// WHEN
+	path, err := GetInstallPathFromValue(systemReg, "EA GAMES\\Battlefield 1942", "GAMEDIR")
+	_, missingErr := GetInstallPathFromValue(systemReg, "EA GAMES\\Battlefield 1942", "InstallDir")
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "C:\\Program Files (x86)\\EA Games\\Battlefield 1942", path)
+	require.ErrorContains(t, missingErr, "no such value")
+}
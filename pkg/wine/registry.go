@@ -0,0 +1,460 @@
+// Read and parse Wine's text-based registry files (user.reg, system.reg)
+package wine
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	registryHeader      = "WINE REGISTRY Version 2"
+	keyPathSeparator    = "\\"
+	lineContinuation    = "\\"
+	defaultValueName    = "@"
+	hexPrefix           = "hex"
+	dwordPrefix         = "dword:"
+	typedStringPrefix   = "str("
+	deletedValueContent = "-"
+)
+
+// ValueType Windows registry value types (REG_*), as used in Wine's str(x): and hex(x): notations
+type ValueType uint32
+
+const (
+	ValueTypeNone         ValueType = 0
+	ValueTypeString       ValueType = 1
+	ValueTypeExpandString ValueType = 2
+	ValueTypeBinary       ValueType = 3
+	ValueTypeDword        ValueType = 4
+	ValueTypeMultiString  ValueType = 7
+	ValueTypeQword        ValueType = 11
+)
+
+type ErrInvalidRegistry struct {
+	line   int
+	reason string
+}
+
+func (e *ErrInvalidRegistry) Error() string {
+	if e.line == 0 {
+		return fmt.Sprintf("invalid wine registry: %s", e.reason)
+	}
+	return fmt.Sprintf("invalid wine registry (line %d): %s", e.line, e.reason)
+}
+
+type ErrNoSuchKey struct {
+	path string
+}
+
+func (e *ErrNoSuchKey) Error() string {
+	return fmt.Sprintf("no such registry key: %q", e.path)
+}
+
+type ErrNoSuchValue struct {
+	path string
+	name string
+}
+
+func (e *ErrNoSuchValue) Error() string {
+	return fmt.Sprintf("no such value in registry key %q: %q", e.path, e.name)
+}
+
+type Registry struct {
+	// keys are indexed by their lower-cased path, since the Windows registry is case-insensitive
+	keys map[string]*Key
+}
+
+type Key struct {
+	Path   string
+	values map[string]Value
+}
+
+type Value struct {
+	Name string
+	Type ValueType
+	// Data holds the raw value data for binary/hex values (and the UTF-16LE encoded data for hex-encoded strings)
+	Data []byte
+	str  []string
+	num  uint64
+}
+
+// Parse a Wine registry file
+func Parse(data []byte) (*Registry, error) {
+	// Split on \n in order to make parsing work with either \r\n or just \n line breaks
+	lines := strings.Split(string(data), "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], "\r") != registryHeader {
+		return nil, &ErrInvalidRegistry{reason: "missing header"}
+	}
+
+	registry := &Registry{
+		keys: map[string]*Key{},
+	}
+
+	var current *Key
+	for i := 1; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimRight(lines[i], "\r")
+
+		// Hex values may span multiple lines, with all but the last one ending on a backslash
+		for strings.Contains(line, "="+hexPrefix) && strings.HasSuffix(line, lineContinuation) && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, lineContinuation) + strings.TrimSpace(strings.TrimRight(lines[i], "\r"))
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, ";"), strings.HasPrefix(trimmed, "#"):
+			// Empty line, comment or metadata (e.g. #time=, #arch=, #link)
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			path, err := parseKeyLine(trimmed)
+			if err != nil {
+				return nil, &ErrInvalidRegistry{line: lineNumber, reason: err.Error()}
+			}
+			current = registry.addKey(path)
+		default:
+			if current == nil {
+				return nil, &ErrInvalidRegistry{line: lineNumber, reason: "value outside of key"}
+			}
+			value, deleted, err := parseValueLine(trimmed)
+			if err != nil {
+				return nil, &ErrInvalidRegistry{line: lineNumber, reason: err.Error()}
+			}
+			if deleted {
+				delete(current.values, strings.ToLower(value.Name))
+				continue
+			}
+			current.values[strings.ToLower(value.Name)] = value
+		}
+	}
+
+	return registry, nil
+}
+
+// Get the key at the given path (backslash separated, relative to the registry file's root)
+func (r *Registry) Key(path string) (*Key, error) {
+	key, ok := r.keys[normalizeKeyPath(path)]
+	if !ok {
+		return nil, &ErrNoSuchKey{path: path}
+	}
+	return key, nil
+}
+
+// Get the given value of the key at the given path
+func (r *Registry) Value(path string, name string) (Value, error) {
+	key, err := r.Key(path)
+	if err != nil {
+		return Value{}, err
+	}
+	return key.Value(name)
+}
+
+func (r *Registry) addKey(path string) *Key {
+	normalized := normalizeKeyPath(path)
+	if key, exists := r.keys[normalized]; exists {
+		return key
+	}
+
+	key := &Key{
+		Path:   path,
+		values: map[string]Value{},
+	}
+	r.keys[normalized] = key
+	return key
+}
+
+// Get the value with the given name (use an empty name for the key's default value)
+func (k *Key) Value(name string) (Value, error) {
+	value, ok := k.values[strings.ToLower(name)]
+	if !ok {
+		return Value{}, &ErrNoSuchValue{path: k.Path, name: name}
+	}
+	return value, nil
+}
+
+// Names of all values stored in the key
+func (k *Key) ValueNames() []string {
+	names := make([]string, 0, len(k.values))
+	for _, value := range k.values {
+		names = append(names, value.Name)
+	}
+	return names
+}
+
+// String content of string values (multi-string values are joined by a semicolon)
+func (v Value) String() string {
+	switch v.Type {
+	case ValueTypeDword, ValueTypeQword:
+		return strconv.FormatUint(v.num, 10)
+	case ValueTypeString, ValueTypeExpandString, ValueTypeMultiString:
+		return strings.Join(v.str, ";")
+	default:
+		return hex.EncodeToString(v.Data)
+	}
+}
+
+// Strings contained in a multi-string value (single element slice for any other string value)
+func (v Value) Strings() []string {
+	return v.str
+}
+
+// Numeric content of dword/qword values
+func (v Value) Uint64() uint64 {
+	return v.num
+}
+
+func parseKeyLine(line string) (string, error) {
+	// Key line format: `[Software\\Wine\\Drives] 1660000000`, with the (optional) modification timestamp after the closing bracket
+	end := strings.LastIndex(line, "]")
+	if end == -1 {
+		return "", fmt.Errorf("unterminated key")
+	}
+
+	path, err := unescape(line[1:end])
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func parseValueLine(line string) (Value, bool, error) {
+	var name, rest string
+	if strings.HasPrefix(line, defaultValueName+"=") {
+		rest = line[len(defaultValueName)+1:]
+	} else if strings.HasPrefix(line, "\"") {
+		end := findClosingQuote(line, 1)
+		if end == -1 {
+			return Value{}, false, fmt.Errorf("unterminated value name")
+		}
+		unescaped, err := unescape(line[1:end])
+		if err != nil {
+			return Value{}, false, err
+		}
+		name = unescaped
+		rest = line[end+1:]
+		if !strings.HasPrefix(rest, "=") {
+			return Value{}, false, fmt.Errorf("missing '=' after value name")
+		}
+		rest = rest[1:]
+	} else {
+		return Value{}, false, fmt.Errorf("unexpected content: %s", line)
+	}
+
+	if rest == deletedValueContent {
+		return Value{Name: name}, true, nil
+	}
+
+	value, err := parseValueData(rest)
+	if err != nil {
+		return Value{}, false, err
+	}
+	value.Name = name
+
+	return value, false, nil
+}
+
+func parseValueData(data string) (Value, error) {
+	switch {
+	case strings.HasPrefix(data, "\""):
+		return parseStringData(ValueTypeString, data)
+	case strings.HasPrefix(data, typedStringPrefix):
+		// Typed string, e.g. str(2):"%USERPROFILE%\\Documents"
+		end := strings.Index(data, "):")
+		if end == -1 {
+			return Value{}, fmt.Errorf("invalid typed string: %s", data)
+		}
+		valueType, err := strconv.ParseUint(data[len(typedStringPrefix):end], 16, 32)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid typed string type: %s", data)
+		}
+		return parseStringData(ValueType(valueType), data[end+2:])
+	case strings.HasPrefix(data, dwordPrefix):
+		num, err := strconv.ParseUint(data[len(dwordPrefix):], 16, 32)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid dword: %s", data)
+		}
+		return Value{Type: ValueTypeDword, num: num}, nil
+	case strings.HasPrefix(data, hexPrefix):
+		return parseHexData(data)
+	default:
+		return Value{}, fmt.Errorf("unknown value format: %s", data)
+	}
+}
+
+func parseStringData(valueType ValueType, data string) (Value, error) {
+	end := findClosingQuote(data, 1)
+	if !strings.HasPrefix(data, "\"") || end != len(data)-1 {
+		return Value{}, fmt.Errorf("invalid string: %s", data)
+	}
+
+	content, err := unescape(data[1:end])
+	if err != nil {
+		return Value{}, err
+	}
+
+	if valueType == ValueTypeMultiString {
+		return Value{Type: valueType, str: splitMultiString(content)}, nil
+	}
+
+	return Value{Type: valueType, str: []string{content}}, nil
+}
+
+func parseHexData(data string) (Value, error) {
+	// Hex data format: `hex:de,ad,be,ef` (REG_BINARY) or `hex(2):25,00,55,00` (any other type)
+	valueType := ValueTypeBinary
+	rest := strings.TrimPrefix(data, hexPrefix)
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end == -1 {
+			return Value{}, fmt.Errorf("invalid hex type: %s", data)
+		}
+		parsed, err := strconv.ParseUint(rest[1:end], 16, 32)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid hex type: %s", data)
+		}
+		valueType = ValueType(parsed)
+		rest = rest[end+1:]
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return Value{}, fmt.Errorf("invalid hex value: %s", data)
+	}
+	rest = rest[1:]
+
+	var bytes []byte
+	if rest != "" {
+		decoded, err := hex.DecodeString(strings.ReplaceAll(rest, ",", ""))
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid hex value: %s", data)
+		}
+		bytes = decoded
+	}
+
+	value := Value{Type: valueType, Data: bytes}
+	switch valueType {
+	case ValueTypeString, ValueTypeExpandString:
+		value.str = []string{strings.TrimRight(decodeUTF16(bytes), "\x00")}
+	case ValueTypeMultiString:
+		value.str = splitMultiString(decodeUTF16(bytes))
+	case ValueTypeDword:
+		if len(bytes) == 4 {
+			value.num = uint64(binary.LittleEndian.Uint32(bytes))
+		}
+	case ValueTypeQword:
+		if len(bytes) == 8 {
+			value.num = binary.LittleEndian.Uint64(bytes)
+		}
+	}
+
+	return value, nil
+}
+
+// findClosingQuote Find the index of the first unescaped quote character at or after start
+func findClosingQuote(s string, start int) int {
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape Resolve the escape sequences Wine uses when writing registry strings (see parse_strW in Wine's server/unicode.c)
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' {
+			b.WriteRune(runes[i])
+			continue
+		}
+
+		i++
+		if i == len(runes) {
+			return "", fmt.Errorf("trailing backslash in string: %s", s)
+		}
+
+		switch r := runes[i]; r {
+		case 'a':
+			b.WriteRune('\a')
+		case 'b':
+			b.WriteRune('\b')
+		case 'e':
+			b.WriteRune('\x1b')
+		case 'f':
+			b.WriteRune('\f')
+		case 'n':
+			b.WriteRune('\n')
+		case 'r':
+			b.WriteRune('\r')
+		case 't':
+			b.WriteRune('\t')
+		case 'v':
+			b.WriteRune('\v')
+		case 'x':
+			// Up to four hex digits
+			j := i + 1
+			for j < len(runes) && j < i+5 && isHexDigit(runes[j]) {
+				j++
+			}
+			if j == i+1 {
+				// Not followed by any hex digits, treat as literal
+				b.WriteRune(r)
+				continue
+			}
+			code, _ := strconv.ParseUint(string(runes[i+1:j]), 16, 16)
+			b.WriteRune(rune(code))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// Up to three octal digits
+			j := i
+			for j < len(runes) && j < i+3 && runes[j] >= '0' && runes[j] <= '7' {
+				j++
+			}
+			code, _ := strconv.ParseUint(string(runes[i:j]), 8, 16)
+			b.WriteRune(rune(code))
+			i = j - 1
+		default:
+			// Any other escaped character (e.g. \\ or \") stands for itself
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isHexDigit(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+func decodeUTF16(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(data[i:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func splitMultiString(content string) []string {
+	// Multi-strings are NUL separated and terminated by two NUL characters
+	parts := strings.Split(strings.TrimRight(content, "\x00"), "\x00")
+	if len(parts) == 1 && parts[0] == "" {
+		return []string{}
+	}
+	return parts
+}
+
+func normalizeKeyPath(path string) string {
+	return strings.ToLower(strings.Trim(path, keyPathSeparator))
+}
@@ -0,0 +1,156 @@
+//go:build unit
+
+package wine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	type test struct {
+		name            string
+		givenData       string
+		wantKeys        map[string]map[string]Value
+		wantErrContains string
+	}
+
+	tests := []test{
+		{
+			name: "parses keys and typed values",
+			givenData: "WINE REGISTRY Version 2\n" +
+				";; All keys relative to \\\\User\\\\S-1-5-21-0-0-0-1000\n" +
+				"\n" +
+				"#arch=win64\n" +
+				"\n" +
+				"[Software\\\\Some Vendor\\\\Some App] 1700000000\n" +
+				"#time=1da0000000000000\n" +
+				"@=\"default\"\n" +
+				"\"String\"=\"C:\\\\users\\\\user\\\\Documents\"\n" +
+				"\"Escaped\"=\"say \\\"hi\\\"\\tplease\\x41\\101\"\n" +
+				"\"Expand\"=str(2):\"%USERPROFILE%\\\\Documents\"\n" +
+				"\"Multi\"=str(7):\"first\\0second\\0\"\n" +
+				"\"Dword\"=dword:0000002a\n" +
+				"\"Binary\"=hex:de,ad,\\\n" +
+				"  be,ef\n" +
+				"\"HexExpand\"=hex(2):25,00,41,00,25,00,00,00\n",
+			wantKeys: map[string]map[string]Value{
+				"Software\\Some Vendor\\Some App": {
+					"":          {Name: "", Type: ValueTypeString, str: []string{"default"}},
+					"String":    {Name: "String", Type: ValueTypeString, str: []string{"C:\\users\\user\\Documents"}},
+					"Escaped":   {Name: "Escaped", Type: ValueTypeString, str: []string{"say \"hi\"\tpleaseAA"}},
+					"Expand":    {Name: "Expand", Type: ValueTypeExpandString, str: []string{"%USERPROFILE%\\Documents"}},
+					"Multi":     {Name: "Multi", Type: ValueTypeMultiString, str: []string{"first", "second"}},
+					"Dword":     {Name: "Dword", Type: ValueTypeDword, num: 42},
+					"Binary":    {Name: "Binary", Type: ValueTypeBinary, Data: []byte{0xde, 0xad, 0xbe, 0xef}},
+					"HexExpand": {Name: "HexExpand", Type: ValueTypeExpandString, Data: []byte{0x25, 0x00, 0x41, 0x00, 0x25, 0x00, 0x00, 0x00}, str: []string{"%A%"}},
+				},
+			},
+		},
+		{
+			name: "parses registry with windows line breaks",
+			givenData: "WINE REGISTRY Version 2\r\n" +
+				"[Software\\\\Some Vendor] 1700000000\r\n" +
+				"\"Value\"=\"some-value\"\r\n",
+			wantKeys: map[string]map[string]Value{
+				"Software\\Some Vendor": {
+					"Value": {Name: "Value", Type: ValueTypeString, str: []string{"some-value"}},
+				},
+			},
+		},
+		{
+			name: "removes deleted values",
+			givenData: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Some Vendor] 1700000000\n" +
+				"\"Value\"=\"some-value\"\n" +
+				"\"Value\"=-\n",
+			wantKeys: map[string]map[string]Value{
+				"Software\\Some Vendor": {},
+			},
+		},
+		{
+			name:            "error for missing header",
+			givenData:       "[Software\\\\Some Vendor] 1700000000\n",
+			wantErrContains: "missing header",
+		},
+		{
+			name: "error for value outside of key",
+			givenData: "WINE REGISTRY Version 2\n" +
+				"\"Value\"=\"some-value\"\n",
+			wantErrContains: "line 2",
+		},
+		{
+			name: "error for unterminated string",
+			givenData: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Some Vendor] 1700000000\n" +
+				"\"Value\"=\"some-value\n",
+			wantErrContains: "invalid string",
+		},
+		{
+			name: "error for invalid hex value",
+			givenData: "WINE REGISTRY Version 2\n" +
+				"[Software\\\\Some Vendor] 1700000000\n" +
+				"\"Value\"=hex:zz\n",
+			wantErrContains: "invalid hex value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			registry, err := Parse([]byte(tt.givenData))
+
+			// THEN
+			if tt.wantErrContains != "" {
+				require.ErrorContains(t, err, tt.wantErrContains)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, registry.keys, len(tt.wantKeys))
+				for path, wantValues := range tt.wantKeys {
+					key, err := registry.Key(path)
+					require.NoError(t, err)
+					assert.Len(t, key.values, len(wantValues))
+					for name, wantValue := range wantValues {
+						value, err := key.Value(name)
+						require.NoError(t, err)
+						assert.Equal(t, wantValue, value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Value(t *testing.T) {
+	registry, err := Parse([]byte("WINE REGISTRY Version 2\n" +
+		"[Software\\\\Some Vendor] 1700000000\n" +
+		"\"Value\"=\"some-value\"\n"))
+	require.NoError(t, err)
+
+	t.Run("looks up key and value case-insensitively", func(t *testing.T) {
+		// WHEN
+		value, err := registry.Value("SOFTWARE\\some vendor", "value")
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, "some-value", value.String())
+	})
+
+	t.Run("error for missing key", func(t *testing.T) {
+		// WHEN
+		_, err := registry.Value("Software\\Other Vendor", "Value")
+
+		// THEN
+		require.ErrorContains(t, err, "no such registry key")
+	})
+
+	t.Run("error for missing value", func(t *testing.T) {
+		// WHEN
+		_, err := registry.Value("Software\\Some Vendor", "Other")
+
+		// THEN
+		require.ErrorContains(t, err, "no such value")
+	})
+}